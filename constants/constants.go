@@ -24,7 +24,7 @@ const (
 	FeeMedium FeeAmount = 3000
 	FeeHigh   FeeAmount = 10000
 
-	// These FeeTiers are used in other dexes such as BaseSwapV3, ArbiDexV3.
+	// The fee amounts of forks, enabled only on their deployments (see BaseSwapV3Base and ArbiDexV3Arbitrum).
 	Fee80   FeeAmount = 80
 	Fee450  FeeAmount = 450
 	Fee2500 FeeAmount = 2500
@@ -35,11 +35,8 @@ const (
 // The default factory tick spacings by fee amount.
 var TickSpacings = map[FeeAmount]int{
 	FeeLowest: 1,
-	Fee80:     1,
 	FeeLow:    10,
-	Fee450:    10,
 	FeeMedium: 60,
-	Fee2500:   60,
 	FeeHigh:   200,
 }
 
@@ -54,5 +51,8 @@ var (
 
 	Q96U256 = new(uint256.Int).Exp(uint256.NewInt(2), uint256.NewInt(96))
 
+	// used in fee growth math
+	Q128U256 = new(uint256.Int).Exp(uint256.NewInt(2), uint256.NewInt(128))
+
 	PercentZero = entities.NewFraction(big.NewInt(0), big.NewInt(1))
 )
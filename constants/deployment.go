@@ -0,0 +1,161 @@
+package constants
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var ErrDeploymentExists = errors.New("deployment already registered")
+
+// Protocol is the name of a Uniswap V3 deployment or of a fork
+type Protocol string
+
+const (
+	ProtocolUniswapV3  Protocol = "uniswap-v3"
+	ProtocolBaseSwapV3 Protocol = "baseswap-v3"
+	ProtocolArbiDexV3  Protocol = "arbidex-v3"
+)
+
+// Deployment holds the contracts and the fee tiers of a protocol on a chain
+type Deployment struct {
+	ChainID  uint
+	Protocol Protocol
+
+	FactoryAddress   common.Address
+	PoolInitCodeHash string // The hash of the pool init code, used to compute the pool addresses
+
+	SwapRouterAddress                 common.Address
+	SwapRouter02Address               common.Address
+	NonfungiblePositionManagerAddress common.Address
+	QuoterAddress                     common.Address
+	QuoterV2Address                   common.Address
+	TickLensAddress                   common.Address
+
+	TickSpacings map[FeeAmount]int // The tick spacings of the enabled fee amounts
+}
+
+// TickSpacing returns the tick spacing of the fee amount, or false if the fee amount isn't enabled
+func (d *Deployment) TickSpacing(fee FeeAmount) (int, bool) {
+	tickSpacing, ok := d.TickSpacings[fee]
+	return tickSpacing, ok
+}
+
+// newUniswapV3Deployment returns a deployment at the mainnet addresses, with the fee amounts enabled on every factory
+func newUniswapV3Deployment(chainID uint) *Deployment {
+	return &Deployment{
+		ChainID:                           chainID,
+		Protocol:                          ProtocolUniswapV3,
+		FactoryAddress:                    FactoryAddress,
+		PoolInitCodeHash:                  PoolInitCodeHash,
+		SwapRouterAddress:                 common.HexToAddress("0xE592427A0AEce92De3Edee1F18E0157C05861564"),
+		SwapRouter02Address:               common.HexToAddress("0x68b3465833fb72A70ecDF485E0e4C7bD8665Fc45"),
+		NonfungiblePositionManagerAddress: common.HexToAddress("0xC36442b4a4522E871399CD717aBDD847Ab11FE88"),
+		QuoterAddress:                     common.HexToAddress("0xb27308f9F90D607463bb33eA1BeBb41C27CE5AB6"),
+		QuoterV2Address:                   common.HexToAddress("0x61fFE014bA17989E743c5F6cB21bF9697530B21e"),
+		TickLensAddress:                   common.HexToAddress("0xbfd8137f7d1516D3ea5cA83523914859ec47F573"),
+		// each deployment has its own map, so that enabling a fee tier on one chain doesn't enable it on the others
+		TickSpacings: map[FeeAmount]int{
+			FeeLowest: 1,
+			FeeLow:    10,
+			FeeMedium: 60,
+			FeeHigh:   200,
+		},
+	}
+}
+
+// The Uniswap V3 deployments which share the addresses of mainnet
+var (
+	UniswapV3Mainnet  = newUniswapV3Deployment(1)
+	UniswapV3Optimism = newUniswapV3Deployment(10)
+	UniswapV3Polygon  = newUniswapV3Deployment(137)
+	UniswapV3Arbitrum = newUniswapV3Deployment(42161)
+)
+
+// The forks whose fee tiers differ from those of Uniswap V3. Their contract addresses aren't known to the SDK, they must
+// be set before computing pool addresses.
+var (
+	BaseSwapV3Base = &Deployment{
+		ChainID:  8453,
+		Protocol: ProtocolBaseSwapV3,
+		TickSpacings: map[FeeAmount]int{
+			Fee80:   1,
+			Fee450:  10,
+			Fee2500: 60,
+		},
+	}
+	ArbiDexV3Arbitrum = &Deployment{
+		ChainID:  42161,
+		Protocol: ProtocolArbiDexV3,
+		TickSpacings: map[FeeAmount]int{
+			Fee80:   1,
+			Fee450:  10,
+			Fee2500: 60,
+		},
+	}
+)
+
+type deploymentKey struct {
+	chainID  uint
+	protocol Protocol
+}
+
+// Registry holds deployments by chain and protocol
+type Registry struct {
+	mu          sync.RWMutex
+	deployments map[deploymentKey]*Deployment
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{deployments: make(map[deploymentKey]*Deployment)}
+}
+
+// DefaultRegistry holds the built-in deployments, and those registered with RegisterDeployment
+var DefaultRegistry = NewRegistry()
+
+func init() {
+	for _, d := range []*Deployment{
+		UniswapV3Mainnet, UniswapV3Optimism, UniswapV3Polygon, UniswapV3Arbitrum, BaseSwapV3Base, ArbiDexV3Arbitrum,
+	} {
+		DefaultRegistry.deployments[deploymentKey{d.ChainID, d.Protocol}] = d
+	}
+}
+
+/**
+ * Registers a deployment, e.g. of a fork, so that it can be looked up by chain and protocol
+ * @param deployment The deployment to register
+ */
+func (r *Registry) Register(deployment *Deployment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := deploymentKey{deployment.ChainID, deployment.Protocol}
+	if _, ok := r.deployments[key]; ok {
+		return ErrDeploymentExists
+	}
+	r.deployments[key] = deployment
+	return nil
+}
+
+/**
+ * Returns the deployment of a protocol on a chain
+ * @param chainID The chain of the deployment
+ * @param protocol The protocol of the deployment
+ */
+func (r *Registry) Get(chainID uint, protocol Protocol) (*Deployment, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	deployment, ok := r.deployments[deploymentKey{chainID, protocol}]
+	return deployment, ok
+}
+
+// RegisterDeployment registers a deployment in the default registry
+func RegisterDeployment(deployment *Deployment) error {
+	return DefaultRegistry.Register(deployment)
+}
+
+// GetDeployment returns a deployment of the default registry
+func GetDeployment(chainID uint, protocol Protocol) (*Deployment, bool) {
+	return DefaultRegistry.Get(chainID, protocol)
+}
@@ -0,0 +1,105 @@
+package entities
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/daoleno/uniswap-sdk-core/entities"
+)
+
+var (
+	ErrInvalidGasModel       = errors.New("invalid gas model")
+	ErrGasTokenPriceMismatch = errors.New("gas token price is not quoted in the output currency")
+)
+
+// GasModel estimates the gas used by a trade and its cost in the output currency of the trade
+type GasModel struct {
+	GasPerHop             uint64          // the gas of a swap through a pool
+	GasPerInitializedTick uint64          // the gas of crossing an initialized tick in a swap
+	GasPrice              *big.Int        // the gas price, in the smallest unit of the gas token
+	GasTokenPrice         *entities.Price // the price of the gas token (e.g. WETH) in the output currency of the trades
+}
+
+// validate checks that the model can price the gas of trades to the given output currency, a nil model is valid
+func (m *GasModel) validate(currencyOut entities.Currency) error {
+	if m == nil {
+		return nil
+	}
+	if m.GasPrice == nil || m.GasPrice.Sign() < 0 || m.GasTokenPrice == nil {
+		return ErrInvalidGasModel
+	}
+	if !m.GasTokenPrice.QuoteCurrency.Wrapped().Equal(currencyOut.Wrapped()) {
+		return ErrGasTokenPriceMismatch
+	}
+	return nil
+}
+
+/**
+ * Returns the estimated gas of the trade: the gas of each hop and of each initialized tick crossed in its swaps
+ * @param model The gas model
+ */
+func (t *Trade) EstimateGas(model *GasModel) uint64 {
+	var gas uint64
+	for _, swap := range t.Swaps {
+		gas += uint64(len(swap.Route.Pools))*model.GasPerHop + uint64(swap.CrossInitTickLoops)*model.GasPerInitializedTick
+	}
+	return gas
+}
+
+/**
+ * Returns the estimated gas cost of the trade in its output currency
+ * @param model The gas model, its gas token price must be quoted in the output currency of the trade
+ */
+func (t *Trade) GasCost(model *GasModel) (*entities.CurrencyAmount, error) {
+	outputCurrency := t.OutputAmount().Currency
+	if err := model.validate(outputCurrency); err != nil {
+		return nil, err
+	}
+	gas := new(big.Int).SetUint64(t.EstimateGas(model))
+	gasAmount := entities.FromRawAmount(model.GasTokenPrice.BaseCurrency, gas.Mul(gas, model.GasPrice))
+	cost, err := model.GasTokenPrice.Quote(gasAmount)
+	if err != nil {
+		return nil, err
+	}
+	return entities.FromFractionalAmount(outputCurrency, cost.Numerator, cost.Denominator), nil
+}
+
+/**
+ * Returns the amount of the trade net of its gas cost: for exact input, the output amount less the gas cost, and for
+ * exact output, the input amount plus the gas cost converted to the input currency at the execution price
+ * @param model The gas model
+ */
+func (t *Trade) amountAfterGas(model *GasModel) (*entities.Fraction, error) {
+	cost, err := t.GasCost(model)
+	if err != nil {
+		return nil, err
+	}
+	if t.TradeType == entities.ExactInput {
+		return t.OutputAmount().Fraction.Subtract(cost.Fraction), nil
+	}
+	inputCost := cost.Fraction.Multiply(t.InputAmount().Fraction).Divide(t.OutputAmount().Fraction)
+	return t.InputAmount().Fraction.Add(inputCost), nil
+}
+
+/**
+ * Trades comparator that ranks the trades by their amounts net of the estimated gas cost, i.e. exact input trades
+ * with more output after gas and exact output trades with less input after gas come first. Trades with the same
+ * amounts after gas are ordered by tradeComparator. The amounts after gas must be computed before, see
+ * BestTradeOptions.insert.
+ * @param a The first trade to compare
+ * @param b The second trade to compare
+ * @returns A sorted ordering for two neighboring elements in a trade array
+ */
+func tradeAfterGasComparator(a, b *Trade) int {
+	if a.amountAfterGasCache.EqualTo(b.amountAfterGasCache) {
+		return tradeComparator(a, b)
+	}
+	better := a.amountAfterGasCache.GreaterThan(b.amountAfterGasCache)
+	if a.TradeType == entities.ExactOutput {
+		better = a.amountAfterGasCache.LessThan(b.amountAfterGasCache)
+	}
+	if better {
+		return -1
+	}
+	return 1
+}
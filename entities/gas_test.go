@@ -0,0 +1,127 @@
+package entities
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/constants"
+	"github.com/daoleno/uniswap-sdk-core/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateGas(t *testing.T) {
+	pool := newBenchPool(t)
+	route, err := NewRoute([]*Pool{pool}, DAI, USDC)
+	require.NoError(t, err)
+	model := &GasModel{
+		GasPerHop:             100000,
+		GasPerInitializedTick: 30000,
+		GasPrice:              big.NewInt(2),
+		GasTokenPrice:         entities.NewPrice(entities.WETH9[1], USDC, big.NewInt(4), big.NewInt(1)),
+	}
+
+	amountIn := entities.FromRawAmount(DAI, big.NewInt(1e16))
+	expected, err := pool.GetOutputAmount(amountIn, nil)
+	require.NoError(t, err)
+	require.Greater(t, expected.CrossInitTickLoops, 1)
+	trade, err := FromRoute(route, amountIn, entities.ExactInput)
+	require.NoError(t, err)
+	assert.Equal(t, expected.CrossInitTickLoops, trade.Swaps[0].CrossInitTickLoops)
+	gas := 100000 + 30000*uint64(expected.CrossInitTickLoops)
+	assert.Equal(t, gas, trade.EstimateGas(model))
+
+	// gas * gas price wei of WETH, at a quarter of USDC per wei
+	cost, err := trade.GasCost(model)
+	require.NoError(t, err)
+	assert.Equal(t, USDC, cost.Currency)
+	assert.True(t, cost.EqualTo(entities.FromFractionalAmount(USDC, new(big.Int).SetUint64(gas*2), big.NewInt(4)).Fraction))
+
+	// the exact output trade crosses the ticks crossed by swapping its input amount
+	amountOut := entities.FromRawAmount(USDC, big.NewInt(1e16))
+	trade, err = FromRoute(route, amountOut, entities.ExactOutput)
+	require.NoError(t, err)
+	expected, err = pool.GetOutputAmount(trade.InputAmount(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, expected.CrossInitTickLoops, trade.Swaps[0].CrossInitTickLoops)
+
+	// the gas token price must be quoted in the output currency
+	_, err = trade.GasCost(&GasModel{GasPrice: big.NewInt(1), GasTokenPrice: entities.NewPrice(entities.WETH9[1], DAI, big.NewInt(1), big.NewInt(1))})
+	assert.ErrorIs(t, err, ErrGasTokenPriceMismatch)
+	_, err = trade.GasCost(&GasModel{GasTokenPrice: model.GasTokenPrice})
+	assert.ErrorIs(t, err, ErrInvalidGasModel)
+}
+
+func TestBestTradeWithGasModel(t *testing.T) {
+	// the route through token1 gives more output than the direct pool, but uses an extra hop
+	pool_0_1_deep := v2StylePool(
+		token0,
+		token1,
+		entities.FromRawAmount(token0, big.NewInt(10000000)),
+		entities.FromRawAmount(token1, big.NewInt(10000000)),
+		constants.FeeLowest,
+	)
+	pool_1_2_deep := v2StylePool(
+		token1,
+		token2,
+		entities.FromRawAmount(token1, big.NewInt(10000000)),
+		entities.FromRawAmount(token2, big.NewInt(10000000)),
+		constants.FeeLowest,
+	)
+	pool_0_2_direct := v2StylePool(
+		token0,
+		token2,
+		entities.FromRawAmount(token0, big.NewInt(1000000)),
+		entities.FromRawAmount(token2, big.NewInt(1000000)),
+		constants.FeeMedium,
+	)
+	pools := []*Pool{pool_0_1_deep, pool_1_2_deep, pool_0_2_direct}
+	router, err := NewRouter(pools)
+	require.NoError(t, err)
+	amountIn := entities.FromRawAmount(token0, big.NewInt(10000))
+	amountOut := entities.FromRawAmount(token2, big.NewInt(10000))
+
+	result, err := BestTradeExactIn(pools, amountIn, token2, nil, nil, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+	assert.Len(t, result[0].Swaps[0].Route.Pools, 2)
+	result, err = BestTradeExactOut(pools, token0, amountOut, nil, nil, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+	assert.Len(t, result[0].Swaps[0].Route.Pools, 2)
+
+	// a hop costs more than the extra output of the longer route
+	model := &GasModel{
+		GasPerHop:     1000,
+		GasPrice:      big.NewInt(1),
+		GasTokenPrice: entities.NewPrice(entities.WETH9[1], token2, big.NewInt(1), big.NewInt(1)),
+	}
+	opts := &BestTradeOptions{MaxNumResults: 3, MaxHops: 3, GasModel: model}
+
+	result, err = BestTradeExactIn(pools, amountIn, token2, opts, nil, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+	assert.Equal(t, []*Pool{pool_0_2_direct}, result[0].Swaps[0].Route.Pools)
+	assert.Equal(t, uint64(1000), result[0].EstimateGas(model))
+	assert.Equal(t, uint64(2000), result[1].EstimateGas(model))
+	assert.True(t, result[0].OutputAmount().LessThan(result[1].OutputAmount().Fraction))
+	routed, err := router.BestTradeExactIn(amountIn, token2, opts)
+	require.NoError(t, err)
+	assertSameTrades(t, result, routed)
+
+	result, err = BestTradeExactOut(pools, token0, amountOut, opts, nil, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+	assert.Equal(t, []*Pool{pool_0_2_direct}, result[0].Swaps[0].Route.Pools)
+	assert.True(t, result[0].InputAmount().GreaterThan(result[1].InputAmount().Fraction))
+	routed, err = router.BestTradeExactOut(token0, amountOut, opts)
+	require.NoError(t, err)
+	assertSameTrades(t, result, routed)
+
+	// the gas token price must be quoted in the output currency
+	opts.GasModel = &GasModel{GasPrice: big.NewInt(1), GasTokenPrice: entities.NewPrice(entities.WETH9[1], token1, big.NewInt(1), big.NewInt(1))}
+	_, err = BestTradeExactIn(pools, amountIn, token2, opts, nil, nil, nil)
+	assert.ErrorIs(t, err, ErrGasTokenPriceMismatch)
+	_, err = router.BestTradeExactOut(token0, amountOut, opts)
+	assert.ErrorIs(t, err, ErrGasTokenPriceMismatch)
+}
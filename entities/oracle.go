@@ -0,0 +1,389 @@
+package entities
+
+import (
+	"errors"
+
+	"github.com/holiman/uint256"
+
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/utils"
+)
+
+var (
+	ErrOracleNotInitialized         = errors.New("oracle not initialized")
+	ErrInvalidOracle                = errors.New("oracle observations don't cover its cardinality")
+	ErrOracleTooOld                 = errors.New("target observation is older than the oldest observation")
+	ErrZeroSecondsAgo               = errors.New("seconds ago must be greater than zero")
+	ErrZeroSecondsPerLiquidityDelta = errors.New("seconds per liquidity delta is zero")
+)
+
+// Observation is a snapshot of the oracle accumulators at a block timestamp, see Oracle.Observation in the core contract
+type Observation struct {
+	// the block timestamp of the observation
+	BlockTimestamp uint32
+	// the tick accumulator, i.e. tick * time elapsed since the pool was first initialized
+	TickCumulative int64
+	// the seconds per liquidity, i.e. seconds elapsed / max(1, liquidity) since the pool was first initialized
+	SecondsPerLiquidityCumulativeX128 *utils.Uint160
+	// whether or not the observation is initialized
+	Initialized bool
+}
+
+// Oracle is a ring buffer of observations, see Oracle.sol in the core contract.
+// Index, Cardinality and CardinalityNext mirror the corresponding fields of slot0 of the pool.
+type Oracle struct {
+	Observations    []Observation
+	Index           uint16 // the index of the most recently written observation
+	Cardinality     uint16 // the current maximum number of observations that are being stored
+	CardinalityNext uint16 // the next maximum number of observations to store, triggered in Write
+}
+
+/**
+ * Constructs an oracle with its first observation, see Oracle.initialize
+ * @param time The time of the oracle initialization, truncated to uint32
+ */
+func NewOracle(time uint32) *Oracle {
+	return &Oracle{
+		Observations: []Observation{{
+			BlockTimestamp:                    time,
+			SecondsPerLiquidityCumulativeX128: new(utils.Uint160),
+			Initialized:                       true,
+		}},
+		Cardinality:     1,
+		CardinalityNext: 1,
+	}
+}
+
+// check returns an error if the oracle isn't initialized, or if its observations, e.g. of an oracle built by the
+// caller, are fewer than its cardinalities or don't hold its index
+func (o *Oracle) check() error {
+	if o.Cardinality == 0 {
+		return ErrOracleNotInitialized
+	}
+	if o.Index >= o.Cardinality || int(o.Cardinality) > len(o.Observations) ||
+		int(o.CardinalityNext) > len(o.Observations) {
+		return ErrInvalidOracle
+	}
+	return nil
+}
+
+/**
+ * Transforms a previous observation into a new observation, given the passage of time and the current tick and
+ * liquidity values, see Oracle.transform
+ * @param last The specified observation to be transformed
+ * @param blockTimestamp The timestamp of the new observation
+ * @param tick The active tick at the time of the new observation
+ * @param liquidity The total in-range liquidity at the time of the new observation
+ */
+func transformObservation(last Observation, blockTimestamp uint32, tick int, liquidity *utils.Uint128) Observation {
+	delta := blockTimestamp - last.BlockTimestamp
+
+	var secondsPerLiquidity utils.Uint160
+	secondsPerLiquidity.Lsh(uint256.NewInt(uint64(delta)), 128)
+	if liquidity.IsZero() {
+		secondsPerLiquidity.Div(&secondsPerLiquidity, uint256.NewInt(1))
+	} else {
+		secondsPerLiquidity.Div(&secondsPerLiquidity, liquidity)
+	}
+	secondsPerLiquidity.Add(&secondsPerLiquidity, valueOrZero(last.SecondsPerLiquidityCumulativeX128))
+	secondsPerLiquidity.And(&secondsPerLiquidity, utils.Uint160Max)
+
+	return Observation{
+		BlockTimestamp:                    blockTimestamp,
+		TickCumulative:                    last.TickCumulative + int64(tick)*int64(delta),
+		SecondsPerLiquidityCumulativeX128: &secondsPerLiquidity,
+		Initialized:                       true,
+	}
+}
+
+/**
+ * Writes an oracle observation, at most once per block, see Oracle.write.
+ * Index and Cardinality are updated, the cardinality grows to CardinalityNext once the buffer wraps around.
+ * @param blockTimestamp The timestamp of the new observation
+ * @param tick The active tick at the time of the new observation
+ * @param liquidity The total in-range liquidity at the time of the new observation
+ */
+func (o *Oracle) Write(blockTimestamp uint32, tick int, liquidity *utils.Uint128) error {
+	if err := o.check(); err != nil {
+		return err
+	}
+	last := o.Observations[o.Index]
+
+	// early return if we've already written an observation this block
+	if last.BlockTimestamp == blockTimestamp {
+		return nil
+	}
+
+	// if the conditions are right, we can bump the cardinality
+	cardinalityUpdated := o.Cardinality
+	if o.CardinalityNext > o.Cardinality && o.Index == o.Cardinality-1 {
+		cardinalityUpdated = o.CardinalityNext
+	}
+
+	indexUpdated := uint16((uint32(o.Index) + 1) % uint32(cardinalityUpdated))
+	o.Observations[indexUpdated] = transformObservation(last, blockTimestamp, tick, liquidity)
+	o.Index = indexUpdated
+	o.Cardinality = cardinalityUpdated
+	return nil
+}
+
+/**
+ * Prepares the oracle array to store up to `next` observations, see Oracle.grow
+ * @param next The proposed next cardinality which will be populated in the oracle array
+ */
+func (o *Oracle) Grow(next uint16) error {
+	if o.Cardinality == 0 {
+		return ErrOracleNotInitialized
+	}
+	// no-op if the passed next value isn't greater than the current next value
+	if next <= o.CardinalityNext {
+		return nil
+	}
+	// store in each slot to prevent fresh SSTOREs in swaps, this data will not be used because the initialized
+	// boolean is still false
+	for i := len(o.Observations); i < int(next); i++ {
+		o.Observations = append(o.Observations, Observation{BlockTimestamp: 1})
+	}
+	o.CardinalityNext = next
+	return nil
+}
+
+/**
+ * Fetches the observations beforeOrAt and atOrAfter a target, i.e. where [beforeOrAt, atOrAfter] is satisfied,
+ * see Oracle.binarySearch. The result may be the same observation, or adjacent observations.
+ * The answer must be contained in the array, used when the target is located within the stored observation
+ * boundaries: older than the most recent observation and younger, or the same age as, the oldest observation
+ * @param time The current block.timestamp
+ * @param target The timestamp at which the reserved observation should be for
+ */
+func (o *Oracle) binarySearch(time, target uint32) (beforeOrAt, atOrAfter Observation) {
+	cardinality := uint(o.Cardinality)
+	l := (uint(o.Index) + 1) % cardinality // oldest observation
+	r := l + cardinality - 1               // newest observation
+	for {
+		i := (l + r) / 2
+
+		beforeOrAt = o.Observations[i%cardinality]
+
+		// we've landed on an uninitialized tick, keep searching higher (more recently)
+		if !beforeOrAt.Initialized {
+			l = i + 1
+			continue
+		}
+
+		atOrAfter = o.Observations[(i+1)%cardinality]
+
+		targetAtOrAfter := lte(time, beforeOrAt.BlockTimestamp, target)
+
+		// check if we've found the answer!
+		if targetAtOrAfter && lte(time, target, atOrAfter.BlockTimestamp) {
+			return beforeOrAt, atOrAfter
+		}
+
+		if !targetAtOrAfter {
+			r = i - 1
+		} else {
+			l = i + 1
+		}
+	}
+}
+
+/**
+ * Fetches the observations beforeOrAt and atOrAfter a given target, i.e. where [beforeOrAt, atOrAfter] is satisfied,
+ * see Oracle.getSurroundingObservations. If the target is younger than the latest observation, the returned
+ * atOrAfter is a counterfactual observation as of the target.
+ * @param time The current block.timestamp
+ * @param target The timestamp at which the reserved observation should be for
+ * @param tick The active tick at the time of the returned or simulated observation
+ * @param liquidity The total pool liquidity at the time of the call
+ */
+func (o *Oracle) getSurroundingObservations(time, target uint32, tick int, liquidity *utils.Uint128) (beforeOrAt, atOrAfter Observation, err error) {
+	// optimistically set before to the newest observation
+	beforeOrAt = o.Observations[o.Index]
+
+	// if the target is chronologically at or after the newest observation, we can early return
+	if lte(time, beforeOrAt.BlockTimestamp, target) {
+		if beforeOrAt.BlockTimestamp == target {
+			// if newest observation equals target, we're in the same block, so we can ignore atOrAfter
+			return beforeOrAt, atOrAfter, nil
+		}
+		// otherwise, we need to transform
+		return beforeOrAt, transformObservation(beforeOrAt, target, tick, liquidity), nil
+	}
+
+	// now, set before to the oldest observation
+	beforeOrAt = o.Observations[(uint32(o.Index)+1)%uint32(o.Cardinality)]
+	if !beforeOrAt.Initialized {
+		beforeOrAt = o.Observations[0]
+	}
+
+	// ensure that the target is chronologically at or after the oldest observation
+	if !lte(time, beforeOrAt.BlockTimestamp, target) {
+		return beforeOrAt, atOrAfter, ErrOracleTooOld
+	}
+
+	// if we've reached this point, we have to binary search
+	beforeOrAt, atOrAfter = o.binarySearch(time, target)
+	return beforeOrAt, atOrAfter, nil
+}
+
+/**
+ * Returns the accumulator values as of secondsAgo seconds ago from the given time, see Oracle.observeSingle.
+ * Reverts (returns ErrOracleTooOld) if secondsAgo is before the oldest observation.
+ * @param time The current block timestamp
+ * @param secondsAgo The amount of time to look back, in seconds, at which point to return an observation
+ * @param tick The current tick
+ * @param liquidity The current in-range pool liquidity
+ */
+func (o *Oracle) ObserveSingle(time, secondsAgo uint32, tick int, liquidity *utils.Uint128) (tickCumulative int64,
+	secondsPerLiquidityCumulativeX128 *utils.Uint160, err error) {
+	if err := o.check(); err != nil {
+		return 0, nil, err
+	}
+
+	if secondsAgo == 0 {
+		last := o.Observations[o.Index]
+		if last.BlockTimestamp != time {
+			last = transformObservation(last, time, tick, liquidity)
+		}
+		return last.TickCumulative, copyOrZero(last.SecondsPerLiquidityCumulativeX128), nil
+	}
+
+	target := time - secondsAgo
+
+	beforeOrAt, atOrAfter, err := o.getSurroundingObservations(time, target, tick, liquidity)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if target == beforeOrAt.BlockTimestamp {
+		// we're at the left boundary
+		return beforeOrAt.TickCumulative, copyOrZero(beforeOrAt.SecondsPerLiquidityCumulativeX128), nil
+	}
+	if target == atOrAfter.BlockTimestamp {
+		// we're at the right boundary
+		return atOrAfter.TickCumulative, copyOrZero(atOrAfter.SecondsPerLiquidityCumulativeX128), nil
+	}
+
+	// we're in the middle
+	observationTimeDelta := atOrAfter.BlockTimestamp - beforeOrAt.BlockTimestamp
+	targetDelta := target - beforeOrAt.BlockTimestamp
+	tickCumulative = beforeOrAt.TickCumulative +
+		((atOrAfter.TickCumulative-beforeOrAt.TickCumulative)/int64(observationTimeDelta))*int64(targetDelta)
+
+	secondsPerLiquidityCumulativeX128 = new(utils.Uint160).Sub(
+		valueOrZero(atOrAfter.SecondsPerLiquidityCumulativeX128), valueOrZero(beforeOrAt.SecondsPerLiquidityCumulativeX128))
+	secondsPerLiquidityCumulativeX128.And(secondsPerLiquidityCumulativeX128, utils.Uint160Max)
+	secondsPerLiquidityCumulativeX128.Mul(secondsPerLiquidityCumulativeX128, uint256.NewInt(uint64(targetDelta)))
+	secondsPerLiquidityCumulativeX128.Div(secondsPerLiquidityCumulativeX128, uint256.NewInt(uint64(observationTimeDelta)))
+	secondsPerLiquidityCumulativeX128.Add(secondsPerLiquidityCumulativeX128, valueOrZero(beforeOrAt.SecondsPerLiquidityCumulativeX128))
+	secondsPerLiquidityCumulativeX128.And(secondsPerLiquidityCumulativeX128, utils.Uint160Max)
+	return tickCumulative, secondsPerLiquidityCumulativeX128, nil
+}
+
+/**
+ * Returns the accumulator values as of each time seconds ago from the given time in the array of secondsAgos,
+ * see Oracle.observe. Reverts (returns ErrOracleTooOld) if secondsAgos > oldest observation.
+ * @param time The current block.timestamp
+ * @param secondsAgos Each amount of time to look back, in seconds, at which point to return an observation
+ * @param tick The current tick
+ * @param liquidity The current in-range pool liquidity
+ */
+func (o *Oracle) Observe(time uint32, secondsAgos []uint32, tick int, liquidity *utils.Uint128) (tickCumulatives []int64,
+	secondsPerLiquidityCumulativeX128s []*utils.Uint160, err error) {
+	if err := o.check(); err != nil {
+		return nil, nil, err
+	}
+
+	tickCumulatives = make([]int64, len(secondsAgos))
+	secondsPerLiquidityCumulativeX128s = make([]*utils.Uint160, len(secondsAgos))
+	for i, secondsAgo := range secondsAgos {
+		tickCumulatives[i], secondsPerLiquidityCumulativeX128s[i], err = o.ObserveSingle(time, secondsAgo, tick, liquidity)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return tickCumulatives, secondsPerLiquidityCumulativeX128s, nil
+}
+
+/**
+ * Returns the oracle values that a swap at the given time writes into the ticks it crosses, to be passed to ExecuteSwap
+ * @param time The current block.timestamp
+ * @param tick The current tick, before the swap
+ * @param liquidity The current in-range pool liquidity, before the swap
+ */
+func (o *Oracle) CrossValues(time uint32, tick int, liquidity *utils.Uint128) (*TickCrossOracleValues, error) {
+	tickCumulative, secondsPerLiquidityCumulativeX128, err := o.ObserveSingle(time, 0, tick, liquidity)
+	if err != nil {
+		return nil, err
+	}
+	return &TickCrossOracleValues{
+		SecondsPerLiquidityCumulativeX128: secondsPerLiquidityCumulativeX128,
+		TickCumulative:                    tickCumulative,
+		BlockTimestamp:                    time,
+	}, nil
+}
+
+/**
+ * Calculates time-weighted means of tick and liquidity over the last secondsAgo seconds, see OracleLibrary.consult
+ * in the periphery contracts
+ * @param time The current block.timestamp
+ * @param secondsAgo Number of seconds in the past from which to calculate the time-weighted means
+ * @param tick The current tick
+ * @param liquidity The current in-range pool liquidity
+ * @returns arithmeticMeanTick The arithmetic mean tick from (time - secondsAgo) to time
+ * @returns harmonicMeanLiquidity The harmonic mean liquidity from (time - secondsAgo) to time
+ */
+func (o *Oracle) Consult(time, secondsAgo uint32, tick int, liquidity *utils.Uint128) (arithmeticMeanTick int,
+	harmonicMeanLiquidity *utils.Uint128, err error) {
+	if secondsAgo == 0 {
+		return 0, nil, ErrZeroSecondsAgo
+	}
+
+	tickCumulatives, secondsPerLiquidityCumulativeX128s, err := o.Observe(time, []uint32{secondsAgo, 0}, tick, liquidity)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	tickCumulativesDelta := tickCumulatives[1] - tickCumulatives[0]
+	var secondsPerLiquidityCumulativesDelta utils.Uint160
+	secondsPerLiquidityCumulativesDelta.Sub(secondsPerLiquidityCumulativeX128s[1], secondsPerLiquidityCumulativeX128s[0])
+	secondsPerLiquidityCumulativesDelta.And(&secondsPerLiquidityCumulativesDelta, utils.Uint160Max)
+
+	arithmeticMeanTick = int(tickCumulativesDelta / int64(secondsAgo))
+	// always round to negative infinity
+	if tickCumulativesDelta < 0 && tickCumulativesDelta%int64(secondsAgo) != 0 {
+		arithmeticMeanTick--
+	}
+
+	// we are multiplying here instead of shifting to ensure that harmonicMeanLiquidity doesn't overflow uint128
+	if secondsPerLiquidityCumulativesDelta.IsZero() {
+		return 0, nil, ErrZeroSecondsPerLiquidityDelta
+	}
+	secondsAgoX160 := new(utils.Uint256).Mul(uint256.NewInt(uint64(secondsAgo)), utils.Uint160Max)
+	harmonicMeanLiquidity = secondsAgoX160.Div(secondsAgoX160, secondsPerLiquidityCumulativesDelta.Lsh(&secondsPerLiquidityCumulativesDelta, 32))
+	harmonicMeanLiquidity.And(harmonicMeanLiquidity, utils.Uint128Max)
+	return arithmeticMeanTick, harmonicMeanLiquidity, nil
+}
+
+/**
+ * Comparator for 32-bit timestamps, see Oracle.lte
+ * @dev safe for 0 or 1 overflows, a and b _must_ be chronologically before or equal to time
+ * @param time A timestamp truncated to 32 bits
+ * @param a A comparison timestamp from which to determine the relative position of `time`
+ * @param b From which to determine the relative position of `time`
+ */
+func lte(time, a, b uint32) bool {
+	// if there hasn't been overflow, no need to adjust
+	if a <= time && b <= time {
+		return a <= b
+	}
+
+	aAdjusted, bAdjusted := uint64(a), uint64(b)
+	if a <= time {
+		aAdjusted += 1 << 32
+	}
+	if b <= time {
+		bAdjusted += 1 << 32
+	}
+	return aAdjusted <= bAdjusted
+}
@@ -0,0 +1,121 @@
+package entities
+
+import (
+	"math"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOracleWrite(t *testing.T) {
+	oracle := NewOracle(0)
+	assert.Equal(t, uint16(0), oracle.Index)
+	assert.Equal(t, uint16(1), oracle.Cardinality)
+	assert.Equal(t, uint16(1), oracle.CardinalityNext)
+
+	// single element array gets overwritten
+	require.NoError(t, oracle.Write(1, 2, uint256.NewInt(5)))
+	assert.Equal(t, uint16(0), oracle.Index)
+	assert.Equal(t, Observation{
+		BlockTimestamp:                    1,
+		TickCumulative:                    2,
+		SecondsPerLiquidityCumulativeX128: uint256.MustFromDecimal("68056473384187692692674921486353642291"),
+		Initialized:                       true,
+	}, oracle.Observations[0])
+
+	require.NoError(t, oracle.Write(6, -7, uint256.NewInt(6)))
+	assert.Equal(t, Observation{
+		BlockTimestamp:                    6,
+		TickCumulative:                    -33,
+		SecondsPerLiquidityCumulativeX128: uint256.MustFromDecimal("351625112484969745578820427679493818504"),
+		Initialized:                       true,
+	}, oracle.Observations[0])
+
+	// does nothing if time has not changed
+	require.NoError(t, oracle.Write(6, 10, uint256.NewInt(6)))
+	assert.Equal(t, int64(-33), oracle.Observations[0].TickCumulative)
+
+	// the cardinality only grows once the index wraps around
+	require.NoError(t, oracle.Grow(3))
+	assert.Equal(t, uint16(1), oracle.Cardinality)
+	assert.Equal(t, uint16(3), oracle.CardinalityNext)
+	assert.False(t, oracle.Observations[2].Initialized)
+	require.NoError(t, oracle.Write(7, 1, uint256.NewInt(1)))
+	assert.Equal(t, uint16(1), oracle.Index)
+	assert.Equal(t, uint16(3), oracle.Cardinality)
+	require.NoError(t, oracle.Write(8, 1, uint256.NewInt(1)))
+	require.NoError(t, oracle.Write(9, 1, uint256.NewInt(1)))
+	assert.Equal(t, uint16(0), oracle.Index, "wraps around")
+	assert.Equal(t, uint32(9), oracle.Observations[0].BlockTimestamp)
+
+	// does not shrink
+	require.NoError(t, oracle.Grow(2))
+	assert.Equal(t, uint16(3), oracle.CardinalityNext)
+
+	assert.ErrorIs(t, (&Oracle{}).Write(1, 0, uint256.NewInt(1)), ErrOracleNotInitialized)
+
+	// an oracle whose observations don't cover its cardinality
+	oracle = NewOracle(1)
+	oracle.CardinalityNext = 2
+	assert.ErrorIs(t, oracle.Write(2, 0, uint256.NewInt(1)), ErrInvalidOracle)
+	oracle = NewOracle(1)
+	oracle.Index = 1
+	_, _, err := oracle.ObserveSingle(2, 0, 0, uint256.NewInt(1))
+	assert.ErrorIs(t, err, ErrInvalidOracle)
+}
+
+func TestOracleObserve(t *testing.T) {
+	liquidity := uint256.NewInt(1000)
+	oracle := NewOracle(0)
+	require.NoError(t, oracle.Grow(4))
+	require.NoError(t, oracle.Write(10, 5, liquidity))
+	require.NoError(t, oracle.Write(20, -3, liquidity))
+
+	tickCumulatives, secondsPerLiquidityCumulativeX128s, err := oracle.Observe(30, []uint32{30, 20, 15, 10, 0}, -3, liquidity)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{0, 50, 35, 20, -10}, tickCumulatives)
+	assert.Equal(t, []*uint256.Int{
+		uint256.NewInt(0),
+		uint256.MustFromDecimal("3402823669209384634633746074317682114"),
+		uint256.MustFromDecimal("5104235503814076951950619111476523171"), // interpolated
+		uint256.MustFromDecimal("6805647338418769269267492148635364228"),
+		uint256.MustFromDecimal("10208471007628153903901238222953046342"), // counterfactual
+	}, secondsPerLiquidityCumulativeX128s)
+
+	_, _, err = oracle.Observe(30, []uint32{31}, -3, liquidity)
+	assert.ErrorIs(t, err, ErrOracleTooOld)
+
+	arithmeticMeanTick, harmonicMeanLiquidity, err := oracle.Consult(30, 20, -3, liquidity)
+	require.NoError(t, err)
+	assert.Equal(t, -3, arithmeticMeanTick)
+	assert.Equal(t, liquidity, harmonicMeanLiquidity)
+
+	arithmeticMeanTick, _, err = oracle.Consult(30, 30, -3, liquidity)
+	require.NoError(t, err)
+	assert.Equal(t, -1, arithmeticMeanTick, "rounds to negative infinity")
+
+	_, _, err = oracle.Consult(30, 0, -3, liquidity)
+	assert.ErrorIs(t, err, ErrZeroSecondsAgo)
+
+	values, err := oracle.CrossValues(30, -3, liquidity)
+	require.NoError(t, err)
+	assert.Equal(t, int64(-10), values.TickCumulative)
+	assert.Equal(t, uint32(30), values.BlockTimestamp)
+}
+
+func TestOracleTimestampOverflow(t *testing.T) {
+	liquidity := uint256.NewInt(1)
+	oracle := NewOracle(math.MaxUint32 - 5)
+	require.NoError(t, oracle.Grow(2))
+	require.NoError(t, oracle.Write(4, 1, liquidity))
+
+	tickCumulatives, _, err := oracle.Observe(10, []uint32{16, 13, 0}, 2, liquidity)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{0, 3, 22}, tickCumulatives)
+
+	assert.True(t, lte(5, math.MaxUint32-10, 3))
+	assert.False(t, lte(5, 3, math.MaxUint32-10))
+	assert.True(t, lte(5, 2, 3))
+}
@@ -19,6 +19,9 @@ var (
 	ErrTokenNotInvolved         = errors.New("token not involved in pool")
 	ErrSqrtPriceLimitX96TooLow  = errors.New("SqrtPriceLimitX96 too low")
 	ErrSqrtPriceLimitX96TooHigh = errors.New("SqrtPriceLimitX96 too high")
+	ErrZeroMintAmount           = errors.New("mint amount must be greater than 0")
+	ErrTickDataNotMutable       = errors.New("tick data provider is not mutable")
+	ErrLiquidityOverflow        = errors.New("liquidity overflow")
 )
 
 type StepComputations struct {
@@ -41,20 +44,41 @@ type Pool struct {
 	TickCurrent      int
 	TickDataProvider TickDataProvider
 
+	// Optional fee state, only needed to compute fee growth, a nil value is treated as zero
+	FeeGrowthGlobal0X128 *utils.Uint256 // The all-time global fee growth, per unit of liquidity, in token0
+	FeeGrowthGlobal1X128 *utils.Uint256 // The all-time global fee growth, per unit of liquidity, in token1
+	FeeProtocol          uint8          // The protocol fee for both tokens of the pool, as encoded in slot0
+	ProtocolFees0        *utils.Uint128 // The amount of token0 owed to the protocol
+	ProtocolFees1        *utils.Uint128 // The amount of token1 owed to the protocol
+
+	// The optional deployment of the pool, which gives its address and tick spacing. The Uniswap V3 factory and the
+	// default tick spacings are used if nil.
+	Deployment *constants.Deployment
+
 	KnownVarsOps	[]string
 
 
 	token0Price *entities.Price
 	token1Price *entities.Price
+	spacing     int // the tick spacing read from chain, if zero it is taken from the deployment or the fee tier
 }
 
 type SwapResult struct {
-	amountCalculated   *utils.Int256
-	sqrtRatioX96       *utils.Uint160
-	liquidity          *utils.Uint128
-	remainingAmountIn  *utils.Int256
-	currentTick        int
-	crossInitTickLoops int
+	amountCalculated    *utils.Int256
+	sqrtRatioX96        *utils.Uint160
+	liquidity           *utils.Uint128
+	remainingAmountIn   *utils.Int256
+	currentTick         int
+	crossInitTickLoops  int
+	feeGrowthGlobalX128 *utils.Uint256 // the global fee growth of the input token after the swap
+	protocolFee         *utils.Uint128 // the amount of input token paid as protocol fee
+}
+
+// tickCrossing records the global fee growth at the time an initialized tick is crossed
+type tickCrossing struct {
+	tick                 int
+	feeGrowthGlobal0X128 utils.Uint256
+	feeGrowthGlobal1X128 utils.Uint256
 }
 
 type GetAmountResult struct {
@@ -64,6 +88,16 @@ type GetAmountResult struct {
 	CrossInitTickLoops int
 }
 
+type GetAmountsToPriceResult struct {
+	ZeroForOne         bool           // whether token0 is swapped for token1 to reach the target price
+	AmountIn           *utils.Uint256 // the input amount required, fees included
+	AmountOut          *utils.Uint256 // the output amount received
+	FeeAmount          *utils.Uint256 // the part of the input amount paid as fee
+	Liquidity          *utils.Uint128 // the in range liquidity at the target price
+	CurrentTick        int            // the current tick at the target price
+	CrossInitTickLoops int
+}
+
 type GetAmountResultV2 struct {
 	ReturnedAmount     *utils.Int256
 	RemainingAmountIn  *utils.Int256
@@ -73,11 +107,23 @@ type GetAmountResultV2 struct {
 	CrossInitTickLoops int
 }
 
+// GetAddress computes the address of a pool of the Uniswap V3 factory, Pool.Address uses the factory of the deployment
+// of the pool instead
 func GetAddress(tokenA, tokenB *entities.Token, fee constants.FeeAmount,
 	initCodeHashManualOverride string) (common.Address, error) {
 	return utils.ComputePoolAddress(constants.FactoryAddress, tokenA, tokenB, fee, initCodeHashManualOverride)
 }
 
+/**
+ * Returns the address of the pool, computed from the factory and init code hash of its deployment
+ */
+func (p *Pool) Address() (common.Address, error) {
+	if p.Deployment != nil {
+		return utils.ComputeDeploymentPoolAddress(p.Deployment, p.Token0, p.Token1, p.Fee)
+	}
+	return GetAddress(p.Token0, p.Token1, p.Fee, "")
+}
+
 // deprecated
 func NewPool(tokenA, tokenB *entities.Token, fee constants.FeeAmount, sqrtRatioX96 *big.Int, liquidity *big.Int,
 	tickCurrent int, ticks TickDataProvider) (*Pool, error) {
@@ -106,19 +152,9 @@ func NewPoolV2(tokenA, tokenB *entities.Token, fee constants.FeeAmount, sqrtRati
 		return nil, ErrFeeTooHigh
 	}
 
-	var tickCurrentSqrtRatioX96, nextTickSqrtRatioX96 utils.Uint160
-	err := utils.GetSqrtRatioAtTickV2(tickCurrent, &tickCurrentSqrtRatioX96)
-	if err != nil {
-		return nil, err
-	}
-	err = utils.GetSqrtRatioAtTickV2(tickCurrent+1, &nextTickSqrtRatioX96)
-	if err != nil {
+	if err := checkSqrtRatioX96(sqrtRatioX96, tickCurrent); err != nil {
 		return nil, err
 	}
-
-	if sqrtRatioX96.Cmp(&tickCurrentSqrtRatioX96) < 0 || sqrtRatioX96.Cmp(&nextTickSqrtRatioX96) > 0 {
-		return nil, ErrInvalidSqrtRatioX96
-	}
 	token0 := tokenA
 	token1 := tokenB
 	isSorted, err := utils.SortsBefore(tokenA, tokenB)
@@ -141,6 +177,58 @@ func NewPoolV2(tokenA, tokenB *entities.Token, fee constants.FeeAmount, sqrtRati
 	}, nil
 }
 
+/**
+ * Construct a pool with its actual tick spacing, as read from chain, instead of the one of its fee tier
+ * @param tokenA One of the tokens in the pool
+ * @param tokenB The other token in the pool
+ * @param fee The fee in hundredths of a bips of the input amount of every swap that is collected by the pool
+ * @param tickSpacing The tick spacing of the pool
+ * @param sqrtRatioX96 The sqrt of the current ratio of amounts of token1 to token0
+ * @param liquidity The current value of in range liquidity
+ * @param tickCurrent The current tick of the pool
+ * @param ticks The current state of the pool ticks or a data provider that can return tick data
+ */
+func NewPoolWithTickSpacing(tokenA, tokenB *entities.Token, fee constants.FeeAmount, tickSpacing int,
+	sqrtRatioX96 *utils.Uint160, liquidity *utils.Uint128, tickCurrent int, ticks TickDataProvider) (*Pool, error) {
+	if tickSpacing <= 0 {
+		return nil, ErrZeroTickSpacing
+	}
+	if tickSpacing >= maxTickSpacing {
+		return nil, ErrInvalidTickSpacing
+	}
+	// the provider must have been built for the same spacing
+	if provider, ok := ticks.(interface{ TickSpacing() int }); ok && provider.TickSpacing() != tickSpacing {
+		return nil, ErrInvalidTickSpacing
+	}
+	pool, err := NewPoolV2(tokenA, tokenB, fee, sqrtRatioX96, liquidity, tickCurrent, ticks)
+	if err != nil {
+		return nil, err
+	}
+	pool.spacing = tickSpacing
+	return pool, nil
+}
+
+// The bound of the tick spacing enabled by the factory, exclusive
+const maxTickSpacing = 16384
+
+// checkSqrtRatioX96 ensures the sqrt ratio lies within the bounds of the given tick
+func checkSqrtRatioX96(sqrtRatioX96 *utils.Uint160, tick int) error {
+	var tickSqrtRatioX96, nextTickSqrtRatioX96 utils.Uint160
+	err := utils.GetSqrtRatioAtTickV2(tick, &tickSqrtRatioX96)
+	if err != nil {
+		return err
+	}
+	err = utils.GetSqrtRatioAtTickV2(tick+1, &nextTickSqrtRatioX96)
+	if err != nil {
+		return err
+	}
+
+	if sqrtRatioX96.Cmp(&tickSqrtRatioX96) < 0 || sqrtRatioX96.Cmp(&nextTickSqrtRatioX96) > 0 {
+		return ErrInvalidSqrtRatioX96
+	}
+	return nil
+}
+
 /**
  * Returns true if the token is either token0 or token1
  * @param token The token to check
@@ -206,7 +294,7 @@ func (p *Pool) GetOutputAmount(inputAmount *entities.CurrencyAmount,
 	if err != nil {
 		return nil, err
 	}
-	swapResult, err := p.swap(zeroForOne, q, sqrtPriceLimitX96)
+	swapResult, err := p.swap(zeroForOne, q, sqrtPriceLimitX96, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -216,15 +304,7 @@ func (p *Pool) GetOutputAmount(inputAmount *entities.CurrencyAmount,
 	} else {
 		outputToken = p.Token0
 	}
-	pool, err := NewPoolV2(
-		p.Token0,
-		p.Token1,
-		p.Fee,
-		swapResult.sqrtRatioX96,
-		swapResult.liquidity,
-		swapResult.currentTick,
-		p.TickDataProvider,
-	)
+	pool, err := p.newState(swapResult.sqrtRatioX96, swapResult.liquidity, swapResult.currentTick)
 	if err != nil {
 		return nil, err
 	}
@@ -239,7 +319,7 @@ func (p *Pool) GetOutputAmount(inputAmount *entities.CurrencyAmount,
 
 func (p *Pool) GetOutputAmountV2(inputAmount *utils.Int256, zeroForOne bool,
 	sqrtPriceLimitX96 *utils.Uint160) (*GetAmountResultV2, error) {
-	swapResult, err := p.swap(zeroForOne, inputAmount, sqrtPriceLimitX96)
+	swapResult, err := p.swap(zeroForOne, inputAmount, sqrtPriceLimitX96, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -261,18 +341,25 @@ func (p *Pool) GetOutputAmountV2(inputAmount *utils.Int256, zeroForOne bool,
  */
 func (p *Pool) GetInputAmount(outputAmount *entities.CurrencyAmount,
 	sqrtPriceLimitX96 *utils.Uint160) (*entities.CurrencyAmount, *Pool, error) {
+	inputAmount, pool, _, err := p.getInputAmount(outputAmount, sqrtPriceLimitX96)
+	return inputAmount, pool, err
+}
+
+// getInputAmount is GetInputAmount, but also returns the number of initialized ticks crossed by the swap
+func (p *Pool) getInputAmount(outputAmount *entities.CurrencyAmount,
+	sqrtPriceLimitX96 *utils.Uint160) (*entities.CurrencyAmount, *Pool, int, error) {
 	if !(outputAmount.Currency.IsToken() && p.InvolvesToken(outputAmount.Currency.Wrapped())) {
-		return nil, nil, ErrTokenNotInvolved
+		return nil, nil, 0, ErrTokenNotInvolved
 	}
 	zeroForOne := outputAmount.Currency.Equal(p.Token1)
 	q, err := int256.FromBig(outputAmount.Quotient())
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, 0, err
 	}
 	q.Neg(q)
-	swapResult, err := p.swap(zeroForOne, q, sqrtPriceLimitX96)
+	swapResult, err := p.swap(zeroForOne, q, sqrtPriceLimitX96, nil)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, 0, err
 	}
 	var inputToken *entities.Token
 	if zeroForOne {
@@ -280,19 +367,173 @@ func (p *Pool) GetInputAmount(outputAmount *entities.CurrencyAmount,
 	} else {
 		inputToken = p.Token1
 	}
-	pool, err := NewPoolV2(
-		p.Token0,
-		p.Token1,
-		p.Fee,
-		swapResult.sqrtRatioX96,
-		swapResult.liquidity,
-		swapResult.currentTick,
-		p.TickDataProvider,
-	)
+	pool, err := p.newState(swapResult.sqrtRatioX96, swapResult.liquidity, swapResult.currentTick)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, 0, err
+	}
+	return entities.FromRawAmount(inputToken, swapResult.amountCalculated.ToBig()), pool, swapResult.crossInitTickLoops, nil
+}
+
+/**
+ * Given a desired output amount, return the computed input amount and the state of the pool after the trade,
+ * without converting to big.Int or constructing a new Pool
+ * @param outputAmount The output amount for which to quote the input amount, must be positive
+ * @param zeroForOne Whether the input token is token0 (and the output token is token1) or the other way around
+ * @param sqrtPriceLimitX96 The Q64.96 sqrt price limit. If zero for one, the price cannot be less than this value after the swap. If one for zero, the price cannot be greater than this value after the swap
+ * @returns The input amount as ReturnedAmount, and the part of the output amount that could not be filled
+ * (because the price limit or the end of the tick data was reached) as RemainingAmountIn
+ */
+func (p *Pool) GetInputAmountV2(outputAmount *utils.Int256, zeroForOne bool,
+	sqrtPriceLimitX96 *utils.Uint160) (*GetAmountResultV2, error) {
+	swapResult, err := p.swap(zeroForOne, new(utils.Int256).Neg(outputAmount), sqrtPriceLimitX96, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &GetAmountResultV2{
+		ReturnedAmount:     swapResult.amountCalculated,
+		RemainingAmountIn:  new(utils.Int256).Neg(swapResult.remainingAmountIn),
+		SqrtRatioX96:       swapResult.sqrtRatioX96,
+		Liquidity:          swapResult.liquidity,
+		CurrentTick:        swapResult.currentTick,
+		CrossInitTickLoops: swapResult.crossInitTickLoops,
+	}, nil
+}
+
+/**
+ * Returns the amounts that must be swapped to move the price of the pool to the given sqrt price, walking the
+ * initialized ticks in between. The result is the same as that of an exact input swap with an unbounded input amount
+ * and the target as sqrtPriceLimitX96, but is computed range by range from the amount deltas.
+ * @param sqrtPriceTargetX96 The Q64.96 sqrt price to move the pool to
+ */
+func (p *Pool) GetAmountsToSqrtPrice(sqrtPriceTargetX96 *utils.Uint160) (*GetAmountsToPriceResult, error) {
+	if sqrtPriceTargetX96.Cmp(utils.MinSqrtRatioU256) <= 0 {
+		return nil, ErrSqrtPriceLimitX96TooLow
 	}
-	return entities.FromRawAmount(inputToken, swapResult.amountCalculated.ToBig()), pool, nil
+	if sqrtPriceTargetX96.Cmp(utils.MaxSqrtRatioU256) >= 0 {
+		return nil, ErrSqrtPriceLimitX96TooHigh
+	}
+
+	zeroForOne := sqrtPriceTargetX96.Cmp(p.SqrtRatioX96) < 0
+	var feePips, maxFeeMinusFeePips utils.Uint256
+	feePips.SetUint64(uint64(p.Fee))
+	maxFeeMinusFeePips.SetUint64(utils.MaxFeeInt - uint64(p.Fee))
+
+	result := &GetAmountsToPriceResult{
+		ZeroForOne:  zeroForOne,
+		AmountIn:    new(utils.Uint256),
+		AmountOut:   new(utils.Uint256),
+		FeeAmount:   new(utils.Uint256),
+		Liquidity:   new(utils.Uint128).Set(p.Liquidity),
+		CurrentTick: p.TickCurrent,
+	}
+	sqrtPriceX96 := new(utils.Uint160).Set(p.SqrtRatioX96)
+
+	for sqrtPriceX96.Cmp(sqrtPriceTargetX96) != 0 {
+		tickNext, initialized, err := nextSwapTick(p.TickDataProvider, result.CurrentTick, zeroForOne)
+		if err != nil {
+			return nil, err
+		}
+		if tickNext < utils.MinTick {
+			tickNext = utils.MinTick
+		} else if tickNext > utils.MaxTick {
+			tickNext = utils.MaxTick
+		}
+
+		var sqrtPriceNextX96, stepTargetX96 utils.Uint160
+		if err := utils.GetSqrtRatioAtTickV2(tickNext, &sqrtPriceNextX96); err != nil {
+			return nil, err
+		}
+		if (zeroForOne && sqrtPriceNextX96.Cmp(sqrtPriceTargetX96) < 0) ||
+			(!zeroForOne && sqrtPriceNextX96.Cmp(sqrtPriceTargetX96) > 0) {
+			stepTargetX96.Set(sqrtPriceTargetX96)
+		} else {
+			stepTargetX96.Set(&sqrtPriceNextX96)
+		}
+
+		// the whole range is swapped, so the amounts are rounded as in a swap step that reaches its target
+		var amountIn, amountOut, feeAmount utils.Uint256
+		if zeroForOne {
+			if err := utils.GetAmount0DeltaV2(&stepTargetX96, sqrtPriceX96, result.Liquidity, true, &amountIn); err != nil {
+				return nil, err
+			}
+			if err := utils.GetAmount1DeltaV2(&stepTargetX96, sqrtPriceX96, result.Liquidity, false, &amountOut); err != nil {
+				return nil, err
+			}
+		} else {
+			if err := utils.GetAmount1DeltaV2(sqrtPriceX96, &stepTargetX96, result.Liquidity, true, &amountIn); err != nil {
+				return nil, err
+			}
+			if err := utils.GetAmount0DeltaV2(sqrtPriceX96, &stepTargetX96, result.Liquidity, false, &amountOut); err != nil {
+				return nil, err
+			}
+		}
+		if err := utils.MulDivRoundingUpV2(&amountIn, &feePips, &maxFeeMinusFeePips, &feeAmount); err != nil {
+			return nil, err
+		}
+		result.AmountIn.Add(result.AmountIn, &amountIn).Add(result.AmountIn, &feeAmount)
+		result.AmountOut.Add(result.AmountOut, &amountOut)
+		result.FeeAmount.Add(result.FeeAmount, &feeAmount)
+
+		if stepTargetX96.Cmp(&sqrtPriceNextX96) == 0 {
+			// if the tick is initialized, run the tick transition
+			if initialized {
+				tick, err := p.TickDataProvider.GetTick(tickNext)
+				if err != nil {
+					return nil, err
+				}
+				var liquidityNet utils.Int128
+				liquidityNet.Set(tick.LiquidityNet)
+				if zeroForOne {
+					liquidityNet.Neg(&liquidityNet)
+				}
+				if err := utils.AddDeltaInPlace(result.Liquidity, &liquidityNet); err != nil {
+					return nil, err
+				}
+				result.CrossInitTickLoops++
+			}
+			if zeroForOne {
+				result.CurrentTick = tickNext - 1
+			} else {
+				result.CurrentTick = tickNext
+			}
+		} else {
+			tick, err := utils.GetTickAtSqrtRatioV2(&stepTargetX96)
+			if err != nil {
+				return nil, err
+			}
+			result.CurrentTick = tick
+		}
+		sqrtPriceX96.Set(&stepTargetX96)
+	}
+
+	return result, nil
+}
+
+/**
+ * Returns the amounts that must be swapped to move the price of the pool to the price at the given tick,
+ * see GetAmountsToSqrtPrice
+ * @param tick The tick to move the pool to
+ */
+func (p *Pool) GetAmountsToTick(tick int) (*GetAmountsToPriceResult, error) {
+	var sqrtPriceTargetX96 utils.Uint160
+	if err := utils.GetSqrtRatioAtTickV2(tick, &sqrtPriceTargetX96); err != nil {
+		return nil, err
+	}
+	return p.GetAmountsToSqrtPrice(&sqrtPriceTargetX96)
+}
+
+// SwapState holds the state of a swap simulation. It is owned by the caller of SwapInto and can be reused across
+// calls, so that quoting does not allocate.
+type SwapState struct {
+	AmountSpecifiedRemaining utils.Int256  // the amount remaining to be swapped in/out of the input/output asset
+	AmountCalculated         utils.Int256  // the amount already swapped out/in of the output/input asset
+	SqrtPriceX96             utils.Uint160 // current sqrt(price)
+	Tick                     int           // the tick associated with the current price
+	Liquidity                utils.Uint128 // the current liquidity in range
+	CrossInitTickLoops       int           // the number of initialized ticks crossed
+
+	feeGrowthGlobalX128 utils.Uint256 // the global fee growth of the input token
+	protocolFee         utils.Uint128 // amount of input token paid as protocol fee
 }
 
 /**
@@ -300,71 +541,113 @@ func (p *Pool) GetInputAmount(outputAmount *entities.CurrencyAmount,
  * @param zeroForOne Whether the amount in is token0 or token1
  * @param amountSpecified The amount of the swap, which implicitly configures the swap as exact input (positive), or exact output (negative)
  * @param sqrtPriceLimitX96 The Q64.96 sqrt price limit. If zero for one, the price cannot be less than this value after the swap. If one for zero, the price cannot be greater than this value after the swap
+ * @param crossings If not nil, every initialized tick crossed is appended along with the fee growth at that time
  * @returns swapResult.amountCalculated
  * @returns swapResult.sqrtRatioX96
  * @returns swapResult.liquidity
  * @returns swapResult.tickCurrent
  */
-func (p *Pool) swap(zeroForOne bool, amountSpecified *utils.Int256, sqrtPriceLimitX96 *utils.Uint160) (*SwapResult,
-	error) {
+func (p *Pool) swap(zeroForOne bool, amountSpecified *utils.Int256, sqrtPriceLimitX96 *utils.Uint160,
+	crossings *[]tickCrossing) (*SwapResult, error) {
+	var state SwapState
+	if err := p.swapInto(zeroForOne, amountSpecified, sqrtPriceLimitX96, &state, crossings); err != nil {
+		return nil, err
+	}
+
+	return &SwapResult{
+		amountCalculated:    &state.AmountCalculated,
+		sqrtRatioX96:        &state.SqrtPriceX96,
+		liquidity:           &state.Liquidity,
+		currentTick:         state.Tick,
+		remainingAmountIn:   &state.AmountSpecifiedRemaining,
+		crossInitTickLoops:  state.CrossInitTickLoops,
+		feeGrowthGlobalX128: &state.feeGrowthGlobalX128,
+		protocolFee:         &state.protocolFee,
+	}, nil
+}
+
+/**
+ * Simulates a swap like GetOutputAmountV2 (exact input) or GetInputAmountV2 (exact output), writing the state of
+ * the pool after the swap into the caller provided state instead of allocating a result. Note that, unlike the
+ * results of GetOutputAmountV2, state.AmountCalculated is negative for exact input swaps (the amount out of the pool).
+ * @param zeroForOne Whether the amount in is token0 or token1
+ * @param amountSpecified The amount of the swap, which implicitly configures the swap as exact input (positive), or exact output (negative)
+ * @param sqrtPriceLimitX96 The Q64.96 sqrt price limit, may be nil
+ * @param state The state to write the result into, its previous content is ignored
+ */
+func (p *Pool) SwapInto(zeroForOne bool, amountSpecified *utils.Int256, sqrtPriceLimitX96 *utils.Uint160,
+	state *SwapState) error {
+	return p.swapInto(zeroForOne, amountSpecified, sqrtPriceLimitX96, state, nil)
+}
+
+func (p *Pool) swapInto(zeroForOne bool, amountSpecified *utils.Int256, sqrtPriceLimitX96 *utils.Uint160,
+	state *SwapState, crossings *[]tickCrossing) error {
 	var err error
+	var defaultSqrtPriceLimitX96 utils.Uint160
 	if sqrtPriceLimitX96 == nil {
 		if zeroForOne {
-			sqrtPriceLimitX96 = new(uint256.Int).AddUint64(utils.MinSqrtRatioU256, 1)
+			defaultSqrtPriceLimitX96.AddUint64(utils.MinSqrtRatioU256, 1)
 		} else {
-			sqrtPriceLimitX96 = new(uint256.Int).SubUint64(utils.MaxSqrtRatioU256, 1)
+			defaultSqrtPriceLimitX96.SubUint64(utils.MaxSqrtRatioU256, 1)
 		}
+		sqrtPriceLimitX96 = &defaultSqrtPriceLimitX96
 	}
 
 	if zeroForOne {
 		if sqrtPriceLimitX96.Cmp(utils.MinSqrtRatioU256) < 0 {
-			return nil, ErrSqrtPriceLimitX96TooLow
+			return ErrSqrtPriceLimitX96TooLow
 		}
 		if sqrtPriceLimitX96.Cmp(p.SqrtRatioX96) >= 0 {
-			return nil, ErrSqrtPriceLimitX96TooHigh
+			return ErrSqrtPriceLimitX96TooHigh
 		}
 	} else {
 		if sqrtPriceLimitX96.Cmp(utils.MaxSqrtRatioU256) > 0 {
-			return nil, ErrSqrtPriceLimitX96TooHigh
+			return ErrSqrtPriceLimitX96TooHigh
 		}
 		if sqrtPriceLimitX96.Cmp(p.SqrtRatioX96) <= 0 {
-			return nil, ErrSqrtPriceLimitX96TooLow
+			return ErrSqrtPriceLimitX96TooLow
 		}
 	}
 
 	exactInput := amountSpecified.Sign() >= 0
 
-	// keep track of swap state
+	// the protocol fee of the input token
+	var feeProtocol uint8
+	if zeroForOne {
+		feeProtocol = p.FeeProtocol % 16
+	} else {
+		feeProtocol = p.FeeProtocol >> 4
+	}
+	var feeProtocolU utils.Uint256
+	feeProtocolU.SetUint64(uint64(feeProtocol))
 
-	state := struct {
-		amountSpecifiedRemaining *utils.Int256
-		amountCalculated         *utils.Int256
-		sqrtPriceX96             *utils.Uint160
-		tick                     int
-		liquidity                *utils.Uint128
-	}{
-		amountSpecifiedRemaining: new(utils.Int256).Set(amountSpecified),
-		amountCalculated:         int256.NewInt(0),
-		sqrtPriceX96:             new(utils.Uint160).Set(p.SqrtRatioX96),
-		tick:                     p.TickCurrent,
-		liquidity:                new(utils.Uint128).Set(p.Liquidity),
-	}
-
-	// crossInitTickLoops is the number of loops that cross an initialized tick.
+	// keep track of swap state
+	state.AmountSpecifiedRemaining.Set(amountSpecified)
+	state.AmountCalculated.Clear()
+	state.SqrtPriceX96.Set(p.SqrtRatioX96)
+	state.Tick = p.TickCurrent
+	state.Liquidity.Set(p.Liquidity)
+	// CrossInitTickLoops is the number of loops that cross an initialized tick.
 	// We only count when tick passes an initialized tick, since gas only significant in this case.
-	crossInitTickLoops := 0
+	state.CrossInitTickLoops = 0
+	if zeroForOne {
+		state.feeGrowthGlobalX128.Set(valueOrZero(p.FeeGrowthGlobal0X128))
+	} else {
+		state.feeGrowthGlobalX128.Set(valueOrZero(p.FeeGrowthGlobal1X128))
+	}
+	state.protocolFee.Clear()
 
 	// start swap while loop
-	for !state.amountSpecifiedRemaining.IsZero() && state.sqrtPriceX96.Cmp(sqrtPriceLimitX96) != 0 {
+	for !state.AmountSpecifiedRemaining.IsZero() && state.SqrtPriceX96.Cmp(sqrtPriceLimitX96) != 0 {
 		var step StepComputations
-		step.sqrtPriceStartX96.Set(state.sqrtPriceX96)
+		step.sqrtPriceStartX96.Set(&state.SqrtPriceX96)
 
 		// because each iteration of the while loop rounds, we can't optimize this code (relative to the smart contract)
 		// by simply traversing to the next available tick, we instead need to exactly replicate
 		// tickBitmap.nextInitializedTickWithinOneWord
-		step.tickNext, step.initialized, err = p.TickDataProvider.NextInitializedTickIndex(state.tick, zeroForOne)
+		step.tickNext, step.initialized, err = nextSwapTick(p.TickDataProvider, state.Tick, zeroForOne)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
 		if step.tickNext < utils.MinTick {
@@ -375,7 +658,7 @@ func (p *Pool) swap(zeroForOne bool, amountSpecified *utils.Int256, sqrtPriceLim
 
 		err = utils.GetSqrtRatioAtTickV2(step.tickNext, &step.sqrtPriceNextX96)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		var targetValue utils.Uint160
 		if zeroForOne {
@@ -393,13 +676,13 @@ func (p *Pool) swap(zeroForOne bool, amountSpecified *utils.Int256, sqrtPriceLim
 		}
 
 		var nxtSqrtPriceX96 utils.Uint160
-		err = utils.ComputeSwapStep(state.sqrtPriceX96, &targetValue, state.liquidity, state.amountSpecifiedRemaining,
+		err = utils.ComputeSwapStep(&state.SqrtPriceX96, &targetValue, &state.Liquidity, &state.AmountSpecifiedRemaining,
 			p.Fee,
 			&nxtSqrtPriceX96, &step.amountIn, &step.amountOut, &step.feeAmount)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		state.sqrtPriceX96.Set(&nxtSqrtPriceX96)
+		state.SqrtPriceX96.Set(&nxtSqrtPriceX96)
 
 		var amountInPlusFee utils.Uint256
 		amountInPlusFee.Add(&step.amountIn, &step.feeAmount)
@@ -407,67 +690,323 @@ func (p *Pool) swap(zeroForOne bool, amountSpecified *utils.Int256, sqrtPriceLim
 		var amountInPlusFeeSigned utils.Int256
 		err = utils.ToInt256(&amountInPlusFee, &amountInPlusFeeSigned)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
 		var amountOutSigned utils.Int256
 		err = utils.ToInt256(&step.amountOut, &amountOutSigned)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
 		if exactInput {
-			state.amountSpecifiedRemaining.Sub(state.amountSpecifiedRemaining, &amountInPlusFeeSigned)
-			state.amountCalculated.Sub(state.amountCalculated, &amountOutSigned)
+			state.AmountSpecifiedRemaining.Sub(&state.AmountSpecifiedRemaining, &amountInPlusFeeSigned)
+			state.AmountCalculated.Sub(&state.AmountCalculated, &amountOutSigned)
 		} else {
-			state.amountSpecifiedRemaining.Add(state.amountSpecifiedRemaining, &amountOutSigned)
-			state.amountCalculated.Add(state.amountCalculated, &amountInPlusFeeSigned)
+			state.AmountSpecifiedRemaining.Add(&state.AmountSpecifiedRemaining, &amountOutSigned)
+			state.AmountCalculated.Add(&state.AmountCalculated, &amountInPlusFeeSigned)
+		}
+
+		// if the protocol fee is on, calculate how much is owed, decrement feeAmount, and increment protocolFee
+		if feeProtocol > 0 {
+			var delta utils.Uint256
+			delta.Div(&step.feeAmount, &feeProtocolU)
+			step.feeAmount.Sub(&step.feeAmount, &delta)
+			state.protocolFee.Add(&state.protocolFee, &delta)
+		}
+
+		// update global fee tracker
+		if !state.Liquidity.IsZero() {
+			var feeGrowthDelta utils.Uint256
+			err = utils.MulDivV2(&step.feeAmount, constants.Q128U256, &state.Liquidity, &feeGrowthDelta, nil)
+			if err != nil {
+				return err
+			}
+			state.feeGrowthGlobalX128.Add(&state.feeGrowthGlobalX128, &feeGrowthDelta)
 		}
 
 		// TODO
-		if state.sqrtPriceX96.Cmp(&step.sqrtPriceNextX96) == 0 {
+		if state.SqrtPriceX96.Cmp(&step.sqrtPriceNextX96) == 0 {
 			// if the tick is initialized, run the tick transition
 			if step.initialized {
 				tick, err := p.TickDataProvider.GetTick(step.tickNext)
 				if err != nil {
-					return nil, err
+					return err
 				}
 
-				liquidityNet := tick.LiquidityNet
+				var liquidityNet utils.Int128
+				liquidityNet.Set(tick.LiquidityNet)
 				// if we're moving leftward, we interpret liquidityNet as the opposite sign
 				// safe because liquidityNet cannot be type(int128).min
 				if zeroForOne {
-					liquidityNet = new(utils.Int128).Neg(liquidityNet)
+					liquidityNet.Neg(&liquidityNet)
+				}
+				utils.AddDeltaInPlace(&state.Liquidity, &liquidityNet)
+
+				state.CrossInitTickLoops++
+
+				if crossings != nil {
+					crossing := tickCrossing{tick: step.tickNext}
+					if zeroForOne {
+						crossing.feeGrowthGlobal0X128.Set(&state.feeGrowthGlobalX128)
+						crossing.feeGrowthGlobal1X128.Set(valueOrZero(p.FeeGrowthGlobal1X128))
+					} else {
+						crossing.feeGrowthGlobal0X128.Set(valueOrZero(p.FeeGrowthGlobal0X128))
+						crossing.feeGrowthGlobal1X128.Set(&state.feeGrowthGlobalX128)
+					}
+					*crossings = append(*crossings, crossing)
 				}
-				utils.AddDeltaInPlace(state.liquidity, liquidityNet)
-
-				crossInitTickLoops++
 			}
 			if zeroForOne {
-				state.tick = step.tickNext - 1
+				state.Tick = step.tickNext - 1
 			} else {
-				state.tick = step.tickNext
+				state.Tick = step.tickNext
 			}
 
-		} else if state.sqrtPriceX96.Cmp(&step.sqrtPriceStartX96) != 0 {
+		} else if state.SqrtPriceX96.Cmp(&step.sqrtPriceStartX96) != 0 {
 			// recompute unless we're on a lower tick boundary (i.e. already transitioned ticks), and haven't moved
-			state.tick, err = utils.GetTickAtSqrtRatioV2(state.sqrtPriceX96)
+			state.Tick, err = utils.GetTickAtSqrtRatioV2(&state.SqrtPriceX96)
 			if err != nil {
-				return nil, err
+				return err
 			}
 		}
 	}
 
-	return &SwapResult{
-		amountCalculated:   state.amountCalculated,
-		sqrtRatioX96:       state.sqrtPriceX96,
-		liquidity:          state.liquidity,
-		currentTick:        state.tick,
-		remainingAmountIn:  state.amountSpecifiedRemaining,
-		crossInitTickLoops: crossInitTickLoops,
-	}, nil
+	return nil
 }
 
-func (p *Pool) tickSpacing() int {
+/**
+ * Executes a swap against the pool in place, as UniswapV3Pool.swap does: the price, liquidity, current tick,
+ * global fee growth and protocol fees of the pool are updated, and every initialized tick crossed is flipped.
+ * The pool's TickDataProvider must be a MutableTickDataProvider.
+ * @param zeroForOne Whether the amount in is token0 or token1
+ * @param amountSpecified The amount of the swap, which implicitly configures the swap as exact input (positive), or exact output (negative)
+ * @param sqrtPriceLimitX96 The Q64.96 sqrt price limit. If zero for one, the price cannot be less than this value after the swap. If one for zero, the price cannot be greater than this value after the swap
+ * @param oracle The oracle accumulators written into crossed ticks, if nil only their fee growth outside is flipped
+ * @returns amount0 The delta of the balance of token0 of the pool, exact when negative, minimum when positive
+ * @returns amount1 The delta of the balance of token1 of the pool, exact when negative, minimum when positive
+ */
+func (p *Pool) ExecuteSwap(zeroForOne bool, amountSpecified *utils.Int256, sqrtPriceLimitX96 *utils.Uint160,
+	oracle *TickCrossOracleValues) (amount0, amount1 *utils.Int256, err error) {
+	ticks, ok := p.TickDataProvider.(MutableTickDataProvider)
+	if !ok {
+		return nil, nil, ErrTickDataNotMutable
+	}
+
+	var crossings []tickCrossing
+	swapResult, err := p.swap(zeroForOne, amountSpecified, sqrtPriceLimitX96, &crossings)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, c := range crossings {
+		tick, err := getTickOrEmpty(ticks, c.tick)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := ticks.SetTick(tick.cross(&c.feeGrowthGlobal0X128, &c.feeGrowthGlobal1X128, oracle)); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	p.SqrtRatioX96 = swapResult.sqrtRatioX96
+	p.Liquidity = swapResult.liquidity
+	p.TickCurrent = swapResult.currentTick
+	if zeroForOne {
+		p.FeeGrowthGlobal0X128 = swapResult.feeGrowthGlobalX128
+		p.ProtocolFees0 = new(utils.Uint128).Add(valueOrZero(p.ProtocolFees0), swapResult.protocolFee)
+	} else {
+		p.FeeGrowthGlobal1X128 = swapResult.feeGrowthGlobalX128
+		p.ProtocolFees1 = new(utils.Uint128).Add(valueOrZero(p.ProtocolFees1), swapResult.protocolFee)
+	}
+	p.token0Price = nil
+	p.token1Price = nil
+
+	amountSpecifiedUsed := new(utils.Int256).Sub(amountSpecified, swapResult.remainingAmountIn)
+	exactInput := amountSpecified.Sign() >= 0
+	if zeroForOne == exactInput {
+		return amountSpecifiedUsed, swapResult.amountCalculated, nil
+	}
+	return swapResult.amountCalculated, amountSpecifiedUsed, nil
+}
+
+/**
+ * Applies a Mint event to the pool in place, adding liquidity to the range [tickLower, tickUpper)
+ * as UniswapV3Pool.mint does. The pool's TickDataProvider must be a MutableTickDataProvider.
+ * @param tickLower The lower tick of the position
+ * @param tickUpper The upper tick of the position
+ * @param amount The amount of liquidity minted
+ * @param oracle The oracle accumulators written into the ticks initialized at or below the current tick, they are
+ * left zero if nil
+ */
+func (p *Pool) Mint(tickLower, tickUpper int, amount *utils.Uint128, oracle *TickCrossOracleValues) error {
+	if amount.IsZero() {
+		return ErrZeroMintAmount
+	}
+	var liquidityDelta utils.Int128
+	if err := toLiquidityDelta(amount, &liquidityDelta); err != nil {
+		return err
+	}
+	return p.modifyPosition(tickLower, tickUpper, &liquidityDelta, oracle)
+}
+
+/**
+ * Applies a Burn event to the pool in place, removing liquidity from the range [tickLower, tickUpper)
+ * as UniswapV3Pool.burn does. The pool's TickDataProvider must be a MutableTickDataProvider.
+ * @param tickLower The lower tick of the position
+ * @param tickUpper The upper tick of the position
+ * @param amount The amount of liquidity burned
+ */
+func (p *Pool) Burn(tickLower, tickUpper int, amount *utils.Uint128) error {
+	var liquidityDelta utils.Int128
+	if err := toLiquidityDelta(amount, &liquidityDelta); err != nil {
+		return err
+	}
+	liquidityDelta.Neg(&liquidityDelta)
+	// a burn never initializes a tick
+	return p.modifyPosition(tickLower, tickUpper, &liquidityDelta, nil)
+}
+
+/**
+ * Applies a Swap event to the pool in place. Ticks are not touched, since a swap only moves the price
+ * and the in range liquidity.
+ * @param sqrtPriceX96 The sqrt price of the pool after the swap
+ * @param liquidity The in range liquidity of the pool after the swap
+ * @param tick The current tick of the pool after the swap
+ */
+func (p *Pool) Swap(sqrtPriceX96 *utils.Uint160, liquidity *utils.Uint128, tick int) error {
+	if err := checkSqrtRatioX96(sqrtPriceX96, tick); err != nil {
+		return err
+	}
+	if liquidity.Cmp(utils.Uint128Max) > 0 {
+		return utils.ErrOverflowUint128
+	}
+	p.SqrtRatioX96 = new(utils.Uint160).Set(sqrtPriceX96)
+	p.Liquidity = new(utils.Uint128).Set(liquidity)
+	p.TickCurrent = tick
+	p.token0Price = nil
+	p.token1Price = nil
+	return nil
+}
+
+// modifyPosition ports UniswapV3Pool._modifyPosition, without the position bookkeeping
+func (p *Pool) modifyPosition(tickLower, tickUpper int, liquidityDelta *utils.Int128,
+	oracle *TickCrossOracleValues) error {
+	if tickLower >= tickUpper {
+		return ErrTickOrder
+	}
+	if tickLower < utils.MinTick {
+		return ErrTickLower
+	}
+	if tickUpper > utils.MaxTick {
+		return ErrTickUpper
+	}
+
+	ticks, ok := p.TickDataProvider.(MutableTickDataProvider)
+	if !ok {
+		return ErrTickDataNotMutable
+	}
+
+	tickSpacing := p.TickSpacing()
+	if tickSpacing <= 0 {
+		return ErrZeroTickSpacing
+	}
+	if tickLower%tickSpacing != 0 || tickUpper%tickSpacing != 0 {
+		return ErrInvalidTickSpacing
+	}
+
+	// compute everything before writing anything, so an error leaves the pool untouched
+	var liquidity *utils.Uint128
+	if p.TickCurrent >= tickLower && p.TickCurrent < tickUpper {
+		liquidity = new(utils.Uint128).Set(p.Liquidity)
+		if err := utils.AddDeltaInPlace(liquidity, liquidityDelta); err != nil {
+			return err
+		}
+	}
+
+	if liquidityDelta.IsZero() {
+		return nil
+	}
+
+	maxLiquidity := maxLiquidityPerTick(tickSpacing)
+	lower, err := updateTick(ticks, tickLower, p.TickCurrent, liquidityDelta, p.FeeGrowthGlobal0X128,
+		p.FeeGrowthGlobal1X128, false, maxLiquidity, oracle)
+	if err != nil {
+		return err
+	}
+	upper, err := updateTick(ticks, tickUpper, p.TickCurrent, liquidityDelta, p.FeeGrowthGlobal0X128,
+		p.FeeGrowthGlobal1X128, true, maxLiquidity, oracle)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range []Tick{lower, upper} {
+		// clear any tick data that is no longer needed
+		if t.LiquidityGross.IsZero() {
+			err = ticks.RemoveTick(t.Index)
+		} else {
+			err = ticks.SetTick(t)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if liquidity != nil {
+		p.Liquidity = liquidity
+	}
+	return nil
+}
+
+// toLiquidityDelta casts a uint128 liquidity amount to a signed delta
+func toLiquidityDelta(amount *utils.Uint128, result *utils.Int128) error {
+	if amount.Cmp(utils.Uint128Max) > 0 {
+		return utils.ErrOverflowUint128
+	}
+	return utils.ToInt256(amount, result)
+}
+
+// newState constructs the pool at a new price, keeping its deployment and tick spacing. A tick list is shared with the
+// new state but not its provider, so that minting on one of the pools doesn't change the other.
+func (p *Pool) newState(sqrtRatioX96 *utils.Uint160, liquidity *utils.Uint128, tickCurrent int) (*Pool, error) {
+	ticks := p.TickDataProvider
+	if list, ok := ticks.(*TickListDataProvider); ok {
+		ticks = &TickListDataProvider{ticks: list.ticks, tickSpacing: list.tickSpacing}
+	}
+	pool, err := NewPoolV2(p.Token0, p.Token1, p.Fee, sqrtRatioX96, liquidity, tickCurrent, ticks)
+	if err != nil {
+		return nil, err
+	}
+	pool.Deployment = p.Deployment
+	pool.spacing = p.spacing
+	return pool, nil
+}
+
+/**
+ * Returns the tick spacing of the pool: the one it was constructed with, else the one of its fee tier in its
+ * deployment or in the default tick spacings. It is zero if the fee tier is unknown.
+ */
+func (p *Pool) TickSpacing() int {
+	if p.spacing != 0 {
+		return p.spacing
+	}
+	if p.Deployment != nil {
+		tickSpacing, _ := p.Deployment.TickSpacing(p.Fee)
+		return tickSpacing
+	}
 	return constants.TickSpacings[p.Fee]
 }
+
+/**
+ * Returns the closest tick to a given tick that is usable with the tick spacing of the pool
+ * @param tick the target tick
+ */
+func (p *Pool) NearestUsableTick(tick int) (int, error) {
+	tickSpacing := p.TickSpacing()
+	if tickSpacing <= 0 {
+		return 0, ErrZeroTickSpacing
+	}
+	if tick < utils.MinTick || tick > utils.MaxTick {
+		return 0, utils.ErrInvalidTick
+	}
+	return NearestUsableTick(tick, tickSpacing), nil
+}
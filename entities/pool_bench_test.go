@@ -0,0 +1,88 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/KyberNetwork/int256"
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/constants"
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/utils"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newBenchPool returns a pool with positions of equal liquidity starting on every 100th tick in [-1000, 1000], so that
+// quotes cross a few initialized ticks
+func newBenchPool(tb testing.TB) *Pool {
+	var ticks []Tick
+	for i := -1000; i <= 1000; i += 100 {
+		ticks = append(ticks, Tick{Index: i, LiquidityNet: int256.NewInt(1e17), LiquidityGross: uint256.NewInt(1e17)})
+	}
+	ticks[len(ticks)-1].LiquidityNet = int256.NewInt(-1e17 * int64(len(ticks)-1))
+	ticks[len(ticks)-1].LiquidityGross = uint256.NewInt(1e17 * uint64(len(ticks)-1))
+	provider, err := NewTickListDataProvider(ticks, constants.TickSpacings[constants.FeeLow])
+	require.NoError(tb, err)
+	pool, err := NewPoolV2(USDC, DAI, constants.FeeLow, uint256.MustFromBig(utils.EncodeSqrtRatioX96(constants.One, constants.One)),
+		uint256.NewInt(11e17), 0, provider)
+	require.NoError(tb, err)
+	return pool
+}
+
+var benchAmounts = []*utils.Int256{
+	int256.NewInt(1e12),
+	int256.NewInt(1e15),
+	int256.NewInt(1e16), // crosses a few ticks
+}
+
+func TestSwapInto(t *testing.T) {
+	pool := newBenchPool(t)
+	var state SwapState
+	for _, amount := range benchAmounts {
+		for _, zeroForOne := range []bool{true, false} {
+			expected, err := pool.GetOutputAmountV2(amount, zeroForOne, nil)
+			require.NoError(t, err)
+			require.NoError(t, pool.SwapInto(zeroForOne, amount, nil, &state))
+			assert.Equal(t, expected.ReturnedAmount, new(int256.Int).Neg(&state.AmountCalculated))
+			assert.Equal(t, expected.RemainingAmountIn, &state.AmountSpecifiedRemaining)
+			assert.Equal(t, expected.SqrtRatioX96, &state.SqrtPriceX96)
+			assert.Equal(t, expected.Liquidity, &state.Liquidity)
+			assert.Equal(t, expected.CurrentTick, state.Tick)
+			assert.Equal(t, expected.CrossInitTickLoops, state.CrossInitTickLoops)
+
+			expectedIn, err := pool.GetInputAmountV2(amount, zeroForOne, nil)
+			require.NoError(t, err)
+			require.NoError(t, pool.SwapInto(zeroForOne, new(int256.Int).Neg(amount), nil, &state))
+			assert.Equal(t, expectedIn.ReturnedAmount, &state.AmountCalculated)
+			assert.Equal(t, expectedIn.SqrtRatioX96, &state.SqrtPriceX96)
+		}
+	}
+	require.NoError(t, pool.SwapInto(true, benchAmounts[len(benchAmounts)-1], nil, &state))
+	assert.Greater(t, state.CrossInitTickLoops, 1)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		for _, amount := range benchAmounts {
+			_ = pool.SwapInto(true, amount, nil, &state)
+			_ = pool.SwapInto(false, amount, nil, &state)
+		}
+	})
+	assert.Zero(t, allocs)
+}
+
+func BenchmarkGetOutputAmountV2(b *testing.B) {
+	pool := newBenchPool(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = pool.GetOutputAmountV2(benchAmounts[i%len(benchAmounts)], i%2 == 0, nil)
+	}
+}
+
+func BenchmarkSwapInto(b *testing.B) {
+	pool := newBenchPool(b)
+	var state SwapState
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = pool.SwapInto(i%2 == 0, benchAmounts[i%len(benchAmounts)], nil, &state)
+	}
+}
@@ -53,6 +53,108 @@ func TestGetAddress(t *testing.T) {
 	assert.Equal(t, addr, common.HexToAddress("0x6c6Bc977E13Df9b0de53b251522280BB72383700"), "matches an example")
 }
 
+func TestPoolDeployment(t *testing.T) {
+	pool, err := NewPool(USDC, DAI, constants.FeeLow, utils.EncodeSqrtRatioX96(constants.One, constants.One), big.NewInt(0), 0, nil)
+	assert.NoError(t, err)
+	addr, err := pool.Address()
+	assert.NoError(t, err)
+	assert.Equal(t, common.HexToAddress("0x6c6Bc977E13Df9b0de53b251522280BB72383700"), addr, "defaults to uniswap v3")
+	pool.Deployment = constants.UniswapV3Mainnet
+	addr, err = pool.Address()
+	assert.NoError(t, err)
+	assert.Equal(t, common.HexToAddress("0x6c6Bc977E13Df9b0de53b251522280BB72383700"), addr, "matches the mainnet deployment")
+
+	fork := &constants.Deployment{
+		ChainID:          1,
+		Protocol:         "fork",
+		FactoryAddress:   common.HexToAddress("0x1111111111111111111111111111111111111111"),
+		PoolInitCodeHash: "0x2222222222222222222222222222222222222222222222222222222222222222",
+		TickSpacings:     map[constants.FeeAmount]int{constants.FeeLow: 5, 250: 2},
+	}
+	pool, err = NewPool(USDC, DAI, 250, utils.EncodeSqrtRatioX96(constants.One, constants.One), big.NewInt(0), 0, nil)
+	assert.NoError(t, err)
+	pool.Deployment = fork
+	addr, err = pool.Address()
+	assert.NoError(t, err)
+	assert.Equal(t, common.HexToAddress("0x152B246463F61E897Aa7F6434e2587773f9eb7B1"), addr,
+		"uses the factory and init code hash of the deployment")
+
+	// the tick spacing of the fee tier of the deployment
+	_, err = NewPosition(pool, OneEther, -4, 2)
+	assert.NoError(t, err)
+	_, err = NewPosition(pool, OneEther, -3, 2)
+	assert.ErrorIs(t, err, ErrTickLower)
+
+	// the built-in deployments don't share their fee tiers
+	constants.UniswapV3Polygon.TickSpacings[250] = 2
+	defer delete(constants.UniswapV3Polygon.TickSpacings, 250)
+	_, ok := constants.UniswapV3Mainnet.TickSpacing(250)
+	assert.False(t, ok)
+
+	// the fee tiers of the forks are enabled only on their deployments
+	pool, err = NewPool(USDC, DAI, constants.Fee450, utils.EncodeSqrtRatioX96(constants.One, constants.One), big.NewInt(0), 0, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, pool.TickSpacing())
+	deployment, ok := constants.GetDeployment(8453, constants.ProtocolBaseSwapV3)
+	assert.True(t, ok)
+	pool.Deployment = deployment
+	assert.Equal(t, 10, pool.TickSpacing())
+	_, err = pool.Address()
+	assert.ErrorIs(t, err, utils.ErrNoFactoryAddress, "the addresses of the fork are unknown")
+}
+
+func TestNewPoolWithTickSpacing(t *testing.T) {
+	sqrtRatioX96 := uint256.MustFromBig(utils.EncodeSqrtRatioX96(constants.One, constants.One))
+
+	// a fee tier without a known tick spacing
+	pool, err := NewPoolV2(USDC, DAI, 250, sqrtRatioX96, uint256.NewInt(0), 0, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, pool.TickSpacing())
+	_, err = NewPosition(pool, OneEther, -10, 10)
+	assert.ErrorIs(t, err, ErrZeroTickSpacing)
+	_, err = pool.NearestUsableTick(7)
+	assert.ErrorIs(t, err, ErrZeroTickSpacing)
+
+	_, err = NewPoolWithTickSpacing(USDC, DAI, 250, 0, sqrtRatioX96, uint256.NewInt(0), 0, nil)
+	assert.ErrorIs(t, err, ErrZeroTickSpacing)
+	_, err = NewPoolWithTickSpacing(USDC, DAI, 250, 16384, sqrtRatioX96, uint256.NewInt(0), 0, nil)
+	assert.ErrorIs(t, err, ErrInvalidTickSpacing)
+	ticks := []Tick{
+		{Index: NearestUsableTick(utils.MinTick, 5), LiquidityNet: OneEtherI256, LiquidityGross: OneEtherUI256},
+		{Index: NearestUsableTick(utils.MaxTick, 5), LiquidityNet: new(int256.Int).Neg(OneEtherI256), LiquidityGross: OneEtherUI256},
+	}
+	provider, err := NewTickListDataProvider(ticks, 1)
+	assert.NoError(t, err)
+	_, err = NewPoolWithTickSpacing(USDC, DAI, 250, 5, sqrtRatioX96, uint256.NewInt(0), 0, provider)
+	assert.ErrorIs(t, err, ErrInvalidTickSpacing, "the provider was built with another spacing")
+
+	provider, err = NewTickListDataProvider(ticks, 5)
+	assert.NoError(t, err)
+	pool, err = NewPoolWithTickSpacing(USDC, DAI, 250, 5, sqrtRatioX96, uint256.MustFromBig(OneEther), 0, provider)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, pool.TickSpacing())
+	_, err = NewPosition(pool, OneEther, -10, 15)
+	assert.NoError(t, err)
+	_, err = NewPosition(pool, OneEther, -10, 12)
+	assert.ErrorIs(t, err, ErrTickUpper)
+	tick, err := pool.NearestUsableTick(7)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, tick)
+	tick, err = pool.NearestUsableTick(utils.MinTick)
+	assert.NoError(t, err)
+	assert.Equal(t, NearestUsableTick(utils.MinTick, 5), tick)
+	_, err = pool.NearestUsableTick(utils.MaxTick + 1)
+	assert.ErrorIs(t, err, utils.ErrInvalidTick)
+
+	// the spacing takes precedence over the deployment and is kept by the pool after a swap
+	pool.Deployment = constants.UniswapV3Mainnet
+	assert.Equal(t, 5, pool.TickSpacing())
+	result, err := pool.GetOutputAmount(entities.FromRawAmount(USDC, big.NewInt(100)), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, result.NewPoolState.TickSpacing())
+	assert.Equal(t, constants.UniswapV3Mainnet, result.NewPoolState.Deployment)
+}
+
 func TestToken0(t *testing.T) {
 	pool, _ := NewPool(USDC, DAI, constants.FeeLow, utils.EncodeSqrtRatioX96(constants.One, constants.One), big.NewInt(0), 0, nil)
 	assert.Equal(t, pool.Token0, DAI, "always is the token that sorts before")
@@ -185,3 +287,247 @@ func TestGetInputAmount(t *testing.T) {
 	assert.True(t, inputAmount.Currency.Equal(DAI))
 	assert.Equal(t, inputAmount.Quotient(), big.NewInt(100))
 }
+
+func TestGetInputAmountV2(t *testing.T) {
+	pool := newTestPool()
+
+	// USDC -> DAI
+	result, err := pool.GetInputAmountV2(int256.NewInt(98), false, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int256.NewInt(100), result.ReturnedAmount)
+	assert.True(t, result.RemainingAmountIn.IsZero())
+	_, expectedPool, err := pool.GetInputAmount(entities.FromRawAmount(DAI, big.NewInt(98)), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedPool.SqrtRatioX96, result.SqrtRatioX96)
+	assert.Equal(t, expectedPool.Liquidity, result.Liquidity)
+	assert.Equal(t, expectedPool.TickCurrent, result.CurrentTick)
+
+	// DAI -> USDC
+	result, err = pool.GetInputAmountV2(int256.NewInt(98), true, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int256.NewInt(100), result.ReturnedAmount)
+
+	// the output that cannot be filled before the price limit is returned
+	limit := new(utils.Uint160)
+	assert.NoError(t, utils.GetSqrtRatioAtTickV2(-10, limit))
+	result, err = pool.GetInputAmountV2(OneEtherI256, true, limit)
+	assert.NoError(t, err)
+	assert.Equal(t, limit, result.SqrtRatioX96)
+	assert.True(t, result.RemainingAmountIn.Sign() > 0)
+	assert.True(t, result.RemainingAmountIn.Lt(OneEtherI256))
+	filled, err := pool.GetOutputAmountV2(result.ReturnedAmount, true, nil)
+	assert.NoError(t, err)
+	assert.False(t, filled.ReturnedAmount.Lt(new(int256.Int).Sub(OneEtherI256, result.RemainingAmountIn)),
+		"the input amount is enough to buy the filled output")
+}
+
+func TestMintBurn(t *testing.T) {
+	pool := newTestPool()
+	amount := uint256.NewInt(1e18)
+
+	// mint in range
+	err := pool.Mint(-10, 10, amount, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, uint256.NewInt(2e18), pool.Liquidity, "in range mint adds to the pool liquidity")
+	lower, err := pool.TickDataProvider.GetTick(-10)
+	assert.NoError(t, err)
+	assert.Equal(t, -10, lower.Index)
+	assert.Equal(t, OneEtherUI256, lower.LiquidityGross)
+	assert.Equal(t, OneEtherI256, lower.LiquidityNet)
+	upper, err := pool.TickDataProvider.GetTick(10)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, upper.Index)
+	assert.Equal(t, new(int256.Int).Neg(OneEtherI256), upper.LiquidityNet)
+
+	// the mutated pool quotes like a pool built from the same ticks
+	expectedTicks, _ := NewTickListDataProvider([]Tick{
+		{Index: NearestUsableTick(utils.MinTick, 10), LiquidityNet: OneEtherI256, LiquidityGross: OneEtherUI256},
+		{Index: -10, LiquidityNet: OneEtherI256, LiquidityGross: OneEtherUI256},
+		{Index: 10, LiquidityNet: new(int256.Int).Neg(OneEtherI256), LiquidityGross: OneEtherUI256},
+		{Index: NearestUsableTick(utils.MaxTick, 10), LiquidityNet: new(int256.Int).Neg(OneEtherI256), LiquidityGross: OneEtherUI256},
+	}, 10)
+	expectedPool, _ := NewPoolV2(USDC, DAI, constants.FeeLow, pool.SqrtRatioX96, uint256.NewInt(2e18), 0, expectedTicks)
+	amountIn := int256.MustFromDec("3000000000000000000")
+	actual, err := pool.GetOutputAmountV2(amountIn, true, nil)
+	assert.NoError(t, err)
+	expected, err := expectedPool.GetOutputAmountV2(amountIn, true, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, actual)
+
+	// mint out of range only touches ticks
+	err = pool.Mint(100, 200, amount, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, uint256.NewInt(2e18), pool.Liquidity, "out of range mint does not change the pool liquidity")
+
+	// burn everything back
+	assert.NoError(t, pool.Burn(100, 200, amount))
+	assert.NoError(t, pool.Burn(-10, 10, amount))
+	assert.Equal(t, OneEtherUI256, pool.Liquidity)
+	tick, err := pool.TickDataProvider.GetTick(10)
+	assert.NoError(t, err)
+	assert.NotEqual(t, 10, tick.Index, "ticks without liquidity are cleared")
+
+	// invalid updates
+	assert.ErrorIs(t, pool.Mint(-10, 10, uint256.NewInt(0), nil), ErrZeroMintAmount)
+	assert.ErrorIs(t, pool.Mint(10, -10, amount, nil), ErrTickOrder)
+	assert.ErrorIs(t, pool.Mint(-5, 10, amount, nil), ErrInvalidTickSpacing)
+	assert.ErrorIs(t, pool.Burn(-10, 10, amount), utils.ErrOverflowUint128, "cannot burn more than minted")
+	assert.Equal(t, OneEtherUI256, pool.Liquidity, "failed updates leave the pool untouched")
+
+	immutable, _ := NewPool(USDC, DAI, constants.FeeLow, utils.EncodeSqrtRatioX96(constants.One, constants.One), OneEther, 0, nil)
+	assert.ErrorIs(t, immutable.Mint(-10, 10, amount, nil), ErrTickDataNotMutable)
+
+	// ranges outside of the ticks of the list
+	ticks, err := NewTickListDataProvider([]Tick{
+		{Index: -10, LiquidityNet: OneEtherI256, LiquidityGross: OneEtherUI256},
+		{Index: 10, LiquidityNet: new(int256.Int).Neg(OneEtherI256), LiquidityGross: OneEtherUI256},
+	}, 10)
+	assert.NoError(t, err)
+	pool, err = NewPool(USDC, DAI, constants.FeeLow, utils.EncodeSqrtRatioX96(constants.One, constants.One), OneEther, 0, ticks)
+	assert.NoError(t, err)
+	assert.NoError(t, pool.Mint(100, 200, uint256.NewInt(5), nil))
+	assert.NoError(t, pool.Mint(-200, -100, uint256.NewInt(5), nil))
+	upper, err = pool.TickDataProvider.GetTick(200)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, upper.Index)
+	assert.Equal(t, int256.NewInt(-5), upper.LiquidityNet)
+	assert.NoError(t, pool.Burn(100, 200, uint256.NewInt(5)))
+	assert.NoError(t, pool.Burn(-200, -100, uint256.NewInt(5)))
+	assert.Len(t, pool.TickDataProvider.(ListableTickDataProvider).Ticks(), 2)
+
+	// the ticks of the caller and of other pool states are left untouched
+	initial := []Tick{
+		{Index: -10, LiquidityNet: OneEtherI256, LiquidityGross: OneEtherUI256},
+		{Index: 10, LiquidityNet: new(int256.Int).Neg(OneEtherI256), LiquidityGross: OneEtherUI256},
+	}
+	list := append([]Tick(nil), initial...)
+	ticks, err = NewTickListDataProvider(list, 10)
+	assert.NoError(t, err)
+	pool, err = NewPool(USDC, DAI, constants.FeeLow, utils.EncodeSqrtRatioX96(constants.One, constants.One), OneEther, 0, ticks)
+	assert.NoError(t, err)
+	result, err := pool.GetOutputAmount(entities.FromRawAmount(USDC, big.NewInt(1000)), nil)
+	assert.NoError(t, err)
+	assert.NoError(t, pool.Mint(-10, 10, amount, nil))
+	assert.NoError(t, pool.Burn(-10, 10, OneEtherUI256))
+	assert.Equal(t, initial, list)
+	assert.Equal(t, initial, result.NewPoolState.TickDataProvider.(ListableTickDataProvider).Ticks())
+	assert.NoError(t, result.NewPoolState.Mint(100, 200, amount, nil))
+	assert.Len(t, pool.TickDataProvider.(ListableTickDataProvider).Ticks(), 2)
+}
+
+func TestSwapEvent(t *testing.T) {
+	pool := newTestPool()
+	price := pool.Token0Price()
+
+	result, err := pool.GetOutputAmountV2(int256.NewInt(1e17), true, nil)
+	assert.NoError(t, err)
+	err = pool.Swap(result.SqrtRatioX96, result.Liquidity, result.CurrentTick)
+	assert.NoError(t, err)
+	assert.Equal(t, result.SqrtRatioX96, pool.SqrtRatioX96)
+	assert.Equal(t, result.CurrentTick, pool.TickCurrent)
+	assert.NotEqual(t, price.ToSignificant(5), pool.Token0Price().ToSignificant(5), "cached prices are reset")
+
+	err = pool.Swap(result.SqrtRatioX96, result.Liquidity, result.CurrentTick+5)
+	assert.ErrorIs(t, err, ErrInvalidSqrtRatioX96)
+}
+
+func TestExecuteSwap(t *testing.T) {
+	pool := newTestPool()
+	pool.FeeProtocol = 4 | 4<<4
+	assert.NoError(t, pool.Mint(-10, 10, OneEtherUI256, nil))
+
+	amountIn := int256.MustFromDec("3000000000000000000")
+	expected, err := pool.GetOutputAmountV2(amountIn, true, nil)
+	assert.NoError(t, err)
+
+	oracle := &TickCrossOracleValues{
+		SecondsPerLiquidityCumulativeX128: uint256.NewInt(1000),
+		TickCumulative:                    100,
+		BlockTimestamp:                    50,
+	}
+	amount0, amount1, err := pool.ExecuteSwap(true, amountIn, nil, oracle)
+	assert.NoError(t, err)
+	assert.Equal(t, amountIn, amount0)
+	assert.Equal(t, new(int256.Int).Neg(expected.ReturnedAmount), amount1)
+	assert.Equal(t, expected.SqrtRatioX96, pool.SqrtRatioX96)
+	assert.Equal(t, expected.Liquidity, pool.Liquidity)
+	assert.Equal(t, expected.CurrentTick, pool.TickCurrent)
+
+	// fee growth only accrues on the input token, a quarter of the fee goes to the protocol
+	assert.False(t, pool.FeeGrowthGlobal0X128.IsZero())
+	assert.Nil(t, pool.FeeGrowthGlobal1X128)
+	assert.False(t, pool.ProtocolFees0.IsZero())
+	assert.Nil(t, pool.ProtocolFees1)
+
+	// the crossed tick flips its outside values
+	lower, err := pool.TickDataProvider.GetTick(-10)
+	assert.NoError(t, err)
+	assert.False(t, lower.FeeGrowthOutside0X128.IsZero())
+	assert.True(t, lower.FeeGrowthOutside0X128.Lt(pool.FeeGrowthGlobal0X128), "only the growth before the crossing is outside")
+	assert.True(t, lower.FeeGrowthOutside1X128.IsZero())
+	assert.Equal(t, int64(100), lower.TickCumulativeOutside)
+	assert.Equal(t, uint256.NewInt(1000), lower.SecondsPerLiquidityOutsideX128)
+	assert.Equal(t, uint32(50), lower.SecondsOutside)
+
+	// the tick that was not crossed is untouched
+	upper, err := pool.TickDataProvider.GetTick(10)
+	assert.NoError(t, err)
+	assert.True(t, upper.FeeGrowthOutside0X128.IsZero())
+	assert.Zero(t, upper.SecondsOutside)
+
+	// a tick initialized at or below the current tick assumes that everything happened below it
+	oracle = &TickCrossOracleValues{
+		SecondsPerLiquidityCumulativeX128: uint256.NewInt(3000),
+		TickCumulative:                    -500,
+		BlockTimestamp:                    80,
+	}
+	tickLower := NearestUsableTick(pool.TickCurrent-100, 10)
+	assert.NoError(t, pool.Mint(tickLower, 100, OneEtherUI256, oracle))
+	lower, err = pool.TickDataProvider.GetTick(tickLower)
+	assert.NoError(t, err)
+	assert.Equal(t, pool.FeeGrowthGlobal0X128, lower.FeeGrowthOutside0X128)
+	assert.Equal(t, int64(-500), lower.TickCumulativeOutside)
+	assert.Equal(t, uint256.NewInt(3000), lower.SecondsPerLiquidityOutsideX128)
+	assert.Equal(t, uint32(80), lower.SecondsOutside)
+	upper, err = pool.TickDataProvider.GetTick(100)
+	assert.NoError(t, err)
+	assert.True(t, upper.FeeGrowthOutside0X128.IsZero())
+	assert.Zero(t, upper.TickCumulativeOutside)
+	assert.Nil(t, upper.SecondsPerLiquidityOutsideX128)
+	assert.Zero(t, upper.SecondsOutside)
+
+	immutable, _ := NewPool(USDC, DAI, constants.FeeLow, utils.EncodeSqrtRatioX96(constants.One, constants.One), OneEther, 0, nil)
+	_, _, err = immutable.ExecuteSwap(true, amountIn, nil, nil)
+	assert.ErrorIs(t, err, ErrTickDataNotMutable)
+}
+
+func TestGetAmountsToSqrtPrice(t *testing.T) {
+	pool := newBenchPool(t)
+
+	for _, tick := range []int{-550, -200, -100, 0, 1, 100, 730} {
+		result, err := pool.GetAmountsToTick(tick)
+		assert.NoError(t, err)
+
+		// the same as an unbounded exact input swap limited at the target price
+		target := new(utils.Uint160)
+		assert.NoError(t, utils.GetSqrtRatioAtTickV2(tick, target))
+		if tick == 0 {
+			assert.True(t, result.AmountIn.IsZero())
+			assert.True(t, result.AmountOut.IsZero())
+			continue
+		}
+		amountIn := int256.MustFromDec("1000000000000000000000000")
+		var state SwapState
+		assert.NoError(t, pool.SwapInto(tick < 0, amountIn, target, &state))
+		assert.Equal(t, tick < 0, result.ZeroForOne)
+		assert.Equal(t, new(int256.Int).Sub(amountIn, &state.AmountSpecifiedRemaining).Dec(), result.AmountIn.Dec())
+		assert.Equal(t, new(int256.Int).Neg(&state.AmountCalculated).Dec(), result.AmountOut.Dec())
+		assert.Equal(t, &state.Liquidity, result.Liquidity)
+		assert.Equal(t, state.Tick, result.CurrentTick)
+		assert.Equal(t, state.CrossInitTickLoops, result.CrossInitTickLoops)
+		assert.False(t, result.FeeAmount.IsZero())
+	}
+
+	_, err := pool.GetAmountsToSqrtPrice(utils.MinSqrtRatioU256)
+	assert.ErrorIs(t, err, ErrSqrtPriceLimitX96TooLow)
+}
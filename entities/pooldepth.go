@@ -0,0 +1,110 @@
+package entities
+
+import (
+	"math/big"
+
+	"github.com/holiman/uint256"
+
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/utils"
+)
+
+const bpsDenominator = 10000
+
+// DepthLevel is the liquidity depth of a pool between its current price and a target price.
+// When the target is below the current price, Amounts.AmountIn is the token0 needed (fees included) and
+// Amounts.AmountOut the token1 available, and the other way around when the target is above.
+type DepthLevel struct {
+	SqrtPriceX96 *utils.Uint160 // the target price
+	Amounts      *GetAmountsToPriceResult
+}
+
+/**
+ * Returns the depth of the pool between its current price and each of the given sqrt prices. Prices beyond the
+ * bounds of the pool are clamped to the min/max sqrt price that a swap can reach.
+ * @param sqrtPricesX96 The Q64.96 sqrt prices to compute the depth at
+ */
+func (p *Pool) GetDepth(sqrtPricesX96 []*utils.Uint160) ([]*DepthLevel, error) {
+	levels := make([]*DepthLevel, len(sqrtPricesX96))
+	for i, sqrtPriceX96 := range sqrtPricesX96 {
+		target := clampSqrtPrice(sqrtPriceX96)
+		amounts, err := p.GetAmountsToSqrtPrice(target)
+		if err != nil {
+			return nil, err
+		}
+		levels[i] = &DepthLevel{SqrtPriceX96: target, Amounts: amounts}
+	}
+	return levels, nil
+}
+
+/**
+ * Returns the depth of the pool between its current price and the prices at the given offsets from the current tick
+ * @param tickOffsets The tick offsets from the current tick, negative offsets are below the current price
+ */
+func (p *Pool) GetDepthAtTickOffsets(tickOffsets []int) ([]*DepthLevel, error) {
+	sqrtPricesX96 := make([]*utils.Uint160, len(tickOffsets))
+	for i, offset := range tickOffsets {
+		tick := p.TickCurrent + offset
+		if tick < utils.MinTick {
+			tick = utils.MinTick
+		} else if tick > utils.MaxTick {
+			tick = utils.MaxTick
+		}
+		sqrtPricesX96[i] = new(utils.Uint160)
+		if err := utils.GetSqrtRatioAtTickV2(tick, sqrtPricesX96[i]); err != nil {
+			return nil, err
+		}
+	}
+	return p.GetDepth(sqrtPricesX96)
+}
+
+/**
+ * Returns the depth of the pool between its current price and the given relative offsets of the current price
+ * @param bpsOffsets The price offsets in basis points, e.g. -200 for the depth down to 98% of the current price of token0
+ */
+func (p *Pool) GetDepthAtPriceOffsets(bpsOffsets []int) ([]*DepthLevel, error) {
+	priceX192 := new(big.Int).Mul(p.SqrtRatioX96.ToBig(), p.SqrtRatioX96.ToBig())
+	sqrtPricesX96 := make([]*utils.Uint160, len(bpsOffsets))
+	for i, offset := range bpsOffsets {
+		if offset <= -bpsDenominator {
+			sqrtPricesX96[i] = new(utils.Uint160).Set(utils.MinSqrtRatioU256)
+			continue
+		}
+		// sqrtPriceX96 * sqrt((10000 + offset) / 10000)
+		targetX192 := new(big.Int).Mul(priceX192, big.NewInt(int64(bpsDenominator+offset)))
+		targetX192.Quo(targetX192, big.NewInt(bpsDenominator))
+		target, overflow := uint256.FromBig(targetX192.Sqrt(targetX192))
+		if overflow {
+			target = new(utils.Uint160).Set(utils.MaxSqrtRatioU256)
+		}
+		sqrtPricesX96[i] = target
+	}
+	return p.GetDepth(sqrtPricesX96)
+}
+
+/**
+ * Returns the sampled output amounts of exact input swaps of the given input amounts, reusing the same swap state
+ * @param zeroForOne Whether the amount in is token0 or token1
+ * @param amountsIn The input amounts to sample, must be positive
+ */
+func (p *Pool) GetOutputCurve(zeroForOne bool, amountsIn []*utils.Int256) ([]*utils.Int256, error) {
+	var state SwapState
+	amountsOut := make([]*utils.Int256, len(amountsIn))
+	for i, amountIn := range amountsIn {
+		if err := p.SwapInto(zeroForOne, amountIn, nil, &state); err != nil {
+			return nil, err
+		}
+		amountsOut[i] = new(utils.Int256).Neg(&state.AmountCalculated)
+	}
+	return amountsOut, nil
+}
+
+// clampSqrtPrice returns a copy of sqrtPriceX96 clamped to the range reachable by a swap
+func clampSqrtPrice(sqrtPriceX96 *utils.Uint160) *utils.Uint160 {
+	if sqrtPriceX96.Cmp(utils.MinSqrtRatioU256) <= 0 {
+		return new(utils.Uint160).AddUint64(utils.MinSqrtRatioU256, 1)
+	}
+	if sqrtPriceX96.Cmp(utils.MaxSqrtRatioU256) >= 0 {
+		return new(utils.Uint160).SubUint64(utils.MaxSqrtRatioU256, 1)
+	}
+	return new(utils.Uint160).Set(sqrtPriceX96)
+}
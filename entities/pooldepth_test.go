@@ -0,0 +1,66 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/KyberNetwork/int256"
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetDepthAtTickOffsets(t *testing.T) {
+	pool := newBenchPool(t)
+
+	levels, err := pool.GetDepthAtTickOffsets([]int{-500, -100, 0, 100, 500})
+	require.NoError(t, err)
+	require.Len(t, levels, 5)
+	for i, offset := range []int{-500, -100, 0, 100, 500} {
+		expected, err := pool.GetAmountsToTick(offset)
+		require.NoError(t, err)
+		assert.Equal(t, expected, levels[i].Amounts)
+	}
+
+	// depth is cumulative
+	assert.True(t, levels[0].Amounts.AmountOut.Gt(levels[1].Amounts.AmountOut))
+	assert.True(t, levels[2].Amounts.AmountOut.IsZero())
+	assert.True(t, levels[4].Amounts.AmountOut.Gt(levels[3].Amounts.AmountOut))
+	assert.True(t, levels[0].Amounts.ZeroForOne)
+	assert.False(t, levels[4].Amounts.ZeroForOne)
+}
+
+func TestGetDepthAtPriceOffsets(t *testing.T) {
+	pool := newBenchPool(t)
+
+	levels, err := pool.GetDepthAtPriceOffsets([]int{-100, 100})
+	require.NoError(t, err)
+
+	// a 1% price move is about 100 ticks
+	assert.InDelta(t, -101, levels[0].Amounts.CurrentTick, 1)
+	assert.InDelta(t, 99, levels[1].Amounts.CurrentTick, 1)
+
+	// the whole liquidity below the price, the bitmap provider does not end at the last initialized tick
+	ticks, err := NewTickBitmapDataProvider(pool.TickDataProvider.(*TickListDataProvider).ticks, 10)
+	require.NoError(t, err)
+	pool.TickDataProvider = ticks
+	levels, err = pool.GetDepthAtPriceOffsets([]int{-10000})
+	require.NoError(t, err)
+	assert.Equal(t, utils.MinTick, levels[0].Amounts.CurrentTick)
+	assert.True(t, levels[0].Amounts.Liquidity.IsZero())
+}
+
+func TestGetOutputCurve(t *testing.T) {
+	pool := newBenchPool(t)
+
+	amountsOut, err := pool.GetOutputCurve(true, benchAmounts)
+	require.NoError(t, err)
+	for i, amountIn := range benchAmounts {
+		expected, err := pool.GetOutputAmountV2(amountIn, true, nil)
+		require.NoError(t, err)
+		assert.Equal(t, expected.ReturnedAmount, amountsOut[i])
+	}
+	assert.True(t, amountsOut[2].Gt(amountsOut[1]))
+
+	_, err = pool.GetOutputCurve(true, []*utils.Int256{int256.NewInt(1e18)})
+	assert.Error(t, err, "the tick data ends before the input is consumed")
+}
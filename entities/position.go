@@ -7,6 +7,7 @@ import (
 	"github.com/KyberNetwork/uniswapv3-sdk-uint256/constants"
 	"github.com/KyberNetwork/uniswapv3-sdk-uint256/utils"
 	"github.com/daoleno/uniswap-sdk-core/entities"
+	"github.com/holiman/uint256"
 )
 
 var (
@@ -39,10 +40,14 @@ func NewPosition(pool *Pool, liquidity *big.Int, tickLower int, tickUpper int) (
 	if tickLower >= tickUpper {
 		return nil, ErrTickOrder
 	}
-	if tickLower < utils.MinTick || tickLower%pool.tickSpacing() != 0 {
+	tickSpacing := pool.TickSpacing()
+	if tickSpacing <= 0 {
+		return nil, ErrZeroTickSpacing
+	}
+	if tickLower < utils.MinTick || tickLower%tickSpacing != 0 {
 		return nil, ErrTickLower
 	}
-	if tickUpper > utils.MaxTick || tickUpper%pool.tickSpacing() != 0 {
+	if tickUpper > utils.MaxTick || tickUpper%tickSpacing != 0 {
 		return nil, ErrTickUpper
 	}
 
@@ -116,6 +121,50 @@ func (p *Position) Amount1() (*entities.CurrencyAmount, error) {
 	return p.token1Amount, nil
 }
 
+// FeeGrowthInside Returns the all-time fee growth, per unit of liquidity, inside the position's tick range
+func (p *Position) FeeGrowthInside() (feeGrowthInside0X128, feeGrowthInside1X128 *utils.Uint256, err error) {
+	return GetFeeGrowthInside(p.Pool.TickDataProvider, p.TickLower, p.TickUpper, p.Pool.TickCurrent,
+		p.Pool.FeeGrowthGlobal0X128, p.Pool.FeeGrowthGlobal1X128)
+}
+
+/**
+ * Returns the fees owed to the position since the fee growth inside was last checkpointed, see Position.update in the
+ * core contract. The result does not include the tokens already owed to the position in the NonfungiblePositionManager.
+ * @param feeGrowthInside0LastX128 The fee growth of token0 inside the tick range as of the last position update
+ * @param feeGrowthInside1LastX128 The fee growth of token1 inside the tick range as of the last position update
+ */
+func (p *Position) TokensOwed(feeGrowthInside0LastX128, feeGrowthInside1LastX128 *utils.Uint256) (tokensOwed0, tokensOwed1 *utils.Uint128, err error) {
+	feeGrowthInside0X128, feeGrowthInside1X128, err := p.FeeGrowthInside()
+	if err != nil {
+		return nil, nil, err
+	}
+	liquidity, overflow := uint256.FromBig(p.Liquidity)
+	if overflow || liquidity.Cmp(utils.Uint128Max) > 0 {
+		return nil, nil, utils.ErrOverflowUint128
+	}
+	tokensOwed0, err = tokensOwed(feeGrowthInside0X128, feeGrowthInside0LastX128, liquidity)
+	if err != nil {
+		return nil, nil, err
+	}
+	tokensOwed1, err = tokensOwed(feeGrowthInside1X128, feeGrowthInside1LastX128, liquidity)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tokensOwed0, tokensOwed1, nil
+}
+
+// tokensOwed computes uint128(mulDiv(feeGrowthInsideX128 - feeGrowthInsideLastX128, liquidity, Q128)), overflow is
+// accepted as in the contract, the position must be withdrawn before it accumulates more than type(uint128).max fees
+func tokensOwed(feeGrowthInsideX128, feeGrowthInsideLastX128, liquidity *utils.Uint256) (*utils.Uint128, error) {
+	var delta utils.Uint256
+	delta.Sub(feeGrowthInsideX128, valueOrZero(feeGrowthInsideLastX128))
+	owed, err := utils.MulDiv(&delta, liquidity, constants.Q128U256)
+	if err != nil {
+		return nil, err
+	}
+	return owed.And(owed, utils.Uint128Max), nil
+}
+
 /**
  * Returns the lower and upper sqrt ratios if the price 'slips' up to slippage tolerance percentage
  * @param slippageTolerance The amount by which the price can 'slip' before the transaction will revert
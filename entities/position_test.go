@@ -4,10 +4,12 @@ import (
 	"math/big"
 	"testing"
 
+	"github.com/KyberNetwork/int256"
 	"github.com/KyberNetwork/uniswapv3-sdk-uint256/constants"
 	"github.com/KyberNetwork/uniswapv3-sdk-uint256/utils"
 	"github.com/daoleno/uniswap-sdk-core/entities"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 )
@@ -356,3 +358,79 @@ func TestMintAmounts(t *testing.T) {
 	assert.Equal(t, "120054069145287995769397", amount0.String())
 	assert.Equal(t, "79831926243", amount1.String())
 }
+
+func TestTokensOwed(t *testing.T) {
+	pool := newTestPool()
+	assert.NoError(t, pool.Mint(-10, 10, OneEtherUI256, nil))
+	position, err := NewPosition(pool, OneEther, -10, 10)
+	assert.NoError(t, err)
+
+	inside0Last, inside1Last, err := position.FeeGrowthInside()
+	assert.NoError(t, err)
+	assert.True(t, inside0Last.IsZero())
+	assert.True(t, inside1Last.IsZero())
+
+	// 0.05% fee on 1e14, split evenly between the full range liquidity and the position
+	_, _, err = pool.ExecuteSwap(true, int256.NewInt(1e14), nil, nil)
+	assert.NoError(t, err)
+	owed0, owed1, err := position.TokensOwed(inside0Last, inside1Last)
+	assert.NoError(t, err)
+	assert.Equal(t, uint256.NewInt(25e9-1), owed0, "fees are rounded down")
+	assert.True(t, owed1.IsZero())
+
+	// fees earned out of range are not owed to the position
+	inside0Last, inside1Last, err = position.FeeGrowthInside()
+	assert.NoError(t, err)
+	_, _, err = pool.ExecuteSwap(true, int256.MustFromDec("3000000000000000000"), nil, nil)
+	assert.NoError(t, err)
+	assert.Less(t, pool.TickCurrent, -10)
+	inside0OutOfRange, _, err := position.FeeGrowthInside()
+	assert.NoError(t, err)
+	_, _, err = pool.ExecuteSwap(true, int256.NewInt(1e14), nil, nil)
+	assert.NoError(t, err)
+	inside0, _, err := position.FeeGrowthInside()
+	assert.NoError(t, err)
+	assert.Equal(t, inside0OutOfRange, inside0)
+	owed0, _, err = position.TokensOwed(inside0Last, inside1Last)
+	assert.NoError(t, err)
+	assert.False(t, owed0.IsZero(), "fees earned before leaving the range are owed")
+}
+
+func TestFeeGrowthInsideUninitializedTicks(t *testing.T) {
+	ticks, err := NewTickListDataProvider([]Tick{
+		{Index: -10, LiquidityNet: OneEtherI256, LiquidityGross: OneEtherUI256},
+		{Index: 10, LiquidityNet: new(int256.Int).Neg(OneEtherI256), LiquidityGross: OneEtherUI256},
+	}, 10)
+	assert.NoError(t, err)
+	pool, err := NewPool(USDC, DAI, constants.FeeLow, utils.EncodeSqrtRatioX96(constants.One, constants.One), OneEther, 0, ticks)
+	assert.NoError(t, err)
+	pool.FeeGrowthGlobal0X128 = uint256.NewInt(1000)
+	pool.FeeGrowthGlobal1X128 = uint256.NewInt(2000)
+
+	// bounds above the largest tick, below the smallest tick and around the current tick
+	for _, bounds := range [][2]int{{100, 200}, {-200, -100}, {-100, 100}} {
+		position, err := NewPosition(pool, OneEther, bounds[0], bounds[1])
+		assert.NoError(t, err)
+		inside0, inside1, err := position.FeeGrowthInside()
+		assert.NoError(t, err)
+		if bounds[0] < pool.TickCurrent && pool.TickCurrent < bounds[1] {
+			assert.Equal(t, pool.FeeGrowthGlobal0X128, inside0, "uninitialized ticks have no fee growth outside")
+			assert.Equal(t, pool.FeeGrowthGlobal1X128, inside1)
+		} else {
+			assert.True(t, inside0.IsZero())
+			assert.True(t, inside1.IsZero())
+		}
+	}
+
+	// the fees of a swap are only inside the ranges around the current tick
+	_, _, err = pool.ExecuteSwap(true, int256.NewInt(1e14), nil, nil)
+	assert.NoError(t, err)
+	inside0, _, err := GetFeeGrowthInside(pool.TickDataProvider, 100, 200, pool.TickCurrent, pool.FeeGrowthGlobal0X128,
+		pool.FeeGrowthGlobal1X128)
+	assert.NoError(t, err)
+	assert.True(t, inside0.IsZero())
+	inside0, _, err = GetFeeGrowthInside(pool.TickDataProvider, -100, 100, pool.TickCurrent, pool.FeeGrowthGlobal0X128,
+		pool.FeeGrowthGlobal1X128)
+	assert.NoError(t, err)
+	assert.Equal(t, pool.FeeGrowthGlobal0X128, inside0)
+}
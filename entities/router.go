@@ -0,0 +1,218 @@
+package entities
+
+import (
+	"github.com/KyberNetwork/int256"
+	"github.com/daoleno/uniswap-sdk-core/entities"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// DefaultBestTradeOptions are the options used by the router when none are given
+var DefaultBestTradeOptions = &BestTradeOptions{MaxNumResults: 3, MaxHops: 3}
+
+// Router finds the best linear trades through a set of pools. The token graph is indexed once when the router is
+// constructed, so that a search only visits the pools of the tokens it reaches.
+type Router struct {
+	pools     []*Pool
+	adjacency map[common.Address][]int // the indexes of the pools of each token
+}
+
+/**
+ * Constructs a router for the given pools
+ * @param pools The pools to route through
+ */
+func NewRouter(pools []*Pool) (*Router, error) {
+	if len(pools) == 0 {
+		return nil, ErrNoPools
+	}
+	r := &Router{
+		pools:     pools,
+		adjacency: make(map[common.Address][]int),
+	}
+	for i, pool := range pools {
+		r.adjacency[pool.Token0.Address] = append(r.adjacency[pool.Token0.Address], i)
+		r.adjacency[pool.Token1.Address] = append(r.adjacency[pool.Token1.Address], i)
+	}
+	return r, nil
+}
+
+// routeSearch holds the state of a depth first search for trades
+type routeSearch struct {
+	opts       *BestTradeOptions
+	used       []bool // whether each pool is already on the current path
+	path       []*Pool
+	bestTrades []*Trade
+}
+
+/**
+ * Returns the top `opts.MaxNumResults` trades that go from an input token amount to an output token, making at most
+ * `opts.MaxHops` hops, the same as BestTradeExactIn. Paths through pools that can't fill the amount are skipped.
+ * @param currencyAmountIn exact amount of input currency to spend
+ * @param currencyOut the desired currency out
+ * @param opts the search options, if nil DefaultBestTradeOptions are used
+ */
+func (r *Router) BestTradeExactIn(currencyAmountIn *entities.CurrencyAmount, currencyOut entities.Currency, opts *BestTradeOptions) ([]*Trade, error) {
+	search, err := r.newSearch(opts, currencyOut)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.searchExactIn(search, currencyAmountIn, currencyOut.Wrapped(), currencyOut, currencyAmountIn.Wrapped(), 0, search.opts.MaxHops); err != nil {
+		return nil, err
+	}
+	return search.bestTrades, nil
+}
+
+/**
+ * Returns the top `opts.MaxNumResults` trades that go from an input token to an output token amount, making at most
+ * `opts.MaxHops` hops, the same as BestTradeExactOut. Paths through pools that can't fill the amount are skipped.
+ * @param currencyIn the currency to spend
+ * @param currencyAmountOut the desired currency amount out
+ * @param opts the search options, if nil DefaultBestTradeOptions are used
+ */
+func (r *Router) BestTradeExactOut(currencyIn entities.Currency, currencyAmountOut *entities.CurrencyAmount, opts *BestTradeOptions) ([]*Trade, error) {
+	search, err := r.newSearch(opts, currencyAmountOut.Currency)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.searchExactOut(search, currencyIn, currencyIn.Wrapped(), currencyAmountOut, currencyAmountOut.Wrapped(), 0, search.opts.MaxHops); err != nil {
+		return nil, err
+	}
+	return search.bestTrades, nil
+}
+
+func (r *Router) newSearch(opts *BestTradeOptions, currencyOut entities.Currency) (*routeSearch, error) {
+	if opts == nil {
+		opts = DefaultBestTradeOptions
+	}
+	if opts.MaxHops <= 0 {
+		return nil, ErrInvalidMaxHops
+	}
+	if opts.MaxNumResults <= 0 {
+		return nil, ErrInvalidMaxSize
+	}
+	if err := opts.GasModel.validate(currencyOut); err != nil {
+		return nil, err
+	}
+	return &routeSearch{
+		opts: opts,
+		used: make([]bool, len(r.pools)),
+		path: make([]*Pool, 0, opts.MaxHops),
+	}, nil
+}
+
+func (r *Router) searchExactIn(s *routeSearch, currencyAmountIn *entities.CurrencyAmount, tokenOut *entities.Token,
+	currencyOut entities.Currency, amountIn *entities.CurrencyAmount, crossInitTickLoops, hopsLeft int) error {
+	for _, i := range r.adjacency[amountIn.Currency.Wrapped().Address] {
+		if s.used[i] {
+			continue
+		}
+		pool := r.pools[i]
+		amountOut, err := pool.GetOutputAmount(amountIn, nil)
+		if err != nil || amountOut.RemainingAmountIn.Quotient().Sign() != 0 {
+			// the pool can't fill the amount
+			continue
+		}
+
+		s.path = append(s.path, pool)
+		loops := crossInitTickLoops + amountOut.CrossInitTickLoops
+		// we have arrived at the output token, so this is the final trade of one of the paths
+		if amountOut.ReturnedAmount.Currency.Equal(tokenOut) {
+			route, err := NewRoute(append([]*Pool(nil), s.path...), currencyAmountIn.Currency, currencyOut)
+			if err != nil {
+				return err
+			}
+			outputAmount := entities.FromFractionalAmount(currencyOut, amountOut.ReturnedAmount.Numerator, amountOut.ReturnedAmount.Denominator)
+			trade, err := CreateUncheckedTrade(route, currencyAmountIn, outputAmount, entities.ExactInput)
+			if err != nil {
+				return err
+			}
+			trade.Swaps[0].CrossInitTickLoops = loops
+			if s.bestTrades, err = s.opts.insert(s.bestTrades, trade); err != nil {
+				return err
+			}
+		} else if hopsLeft > 1 {
+			// otherwise, consider all the other paths that lead from this token as long as we have not exceeded maxHops
+			s.used[i] = true
+			err = r.searchExactIn(s, currencyAmountIn, tokenOut, currencyOut, amountOut.ReturnedAmount, loops, hopsLeft-1)
+			s.used[i] = false
+			if err != nil {
+				return err
+			}
+		}
+		s.path = s.path[:len(s.path)-1]
+	}
+	return nil
+}
+
+func (r *Router) searchExactOut(s *routeSearch, currencyIn entities.Currency, tokenIn *entities.Token,
+	currencyAmountOut *entities.CurrencyAmount, amountOut *entities.CurrencyAmount, crossInitTickLoops, hopsLeft int) error {
+	for _, i := range r.adjacency[amountOut.Currency.Wrapped().Address] {
+		if s.used[i] {
+			continue
+		}
+		pool := r.pools[i]
+		amountIn, poolLoops, err := getInputAmountFilled(pool, amountOut)
+		if err != nil {
+			// the pool can't fill the amount
+			continue
+		}
+
+		// the path is built from the output backwards
+		s.path = append(s.path, pool)
+		loops := crossInitTickLoops + poolLoops
+		// we have arrived at the input token, so this is the final trade of one of the paths
+		if amountIn.Currency.Equal(tokenIn) {
+			pools := make([]*Pool, len(s.path))
+			for j, p := range s.path {
+				pools[len(pools)-1-j] = p
+			}
+			route, err := NewRoute(pools, currencyIn, currencyAmountOut.Currency)
+			if err != nil {
+				return err
+			}
+			inputAmount := entities.FromFractionalAmount(currencyIn, amountIn.Numerator, amountIn.Denominator)
+			trade, err := CreateUncheckedTrade(route, inputAmount, currencyAmountOut, entities.ExactOutput)
+			if err != nil {
+				return err
+			}
+			trade.Swaps[0].CrossInitTickLoops = loops
+			if s.bestTrades, err = s.opts.insert(s.bestTrades, trade); err != nil {
+				return err
+			}
+		} else if hopsLeft > 1 {
+			// otherwise, consider all the other paths that arrive at this token as long as we have not exceeded maxHops
+			s.used[i] = true
+			err = r.searchExactOut(s, currencyIn, tokenIn, currencyAmountOut, amountIn, loops, hopsLeft-1)
+			s.used[i] = false
+			if err != nil {
+				return err
+			}
+		}
+		s.path = s.path[:len(s.path)-1]
+	}
+	return nil
+}
+
+// getInputAmountFilled is GetInputAmount, but returns ErrInsufficientLiquidity if the pool can't fill the whole output.
+// It also returns the number of initialized ticks crossed by the swap.
+func getInputAmountFilled(pool *Pool, outputAmount *entities.CurrencyAmount) (*entities.CurrencyAmount, int, error) {
+	if !pool.InvolvesToken(outputAmount.Currency.Wrapped()) {
+		return nil, 0, ErrTokenNotInvolved
+	}
+	zeroForOne := outputAmount.Currency.Equal(pool.Token1)
+	amount, err := int256.FromBig(outputAmount.Quotient())
+	if err != nil {
+		return nil, 0, err
+	}
+	result, err := pool.GetInputAmountV2(amount, zeroForOne, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !result.RemainingAmountIn.IsZero() {
+		return nil, 0, ErrInsufficientLiquidity
+	}
+	inputToken := pool.Token1
+	if zeroForOne {
+		inputToken = pool.Token0
+	}
+	return entities.FromRawAmount(inputToken, result.ReturnedAmount.ToBig()), result.CrossInitTickLoops, nil
+}
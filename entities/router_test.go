@@ -0,0 +1,112 @@
+package entities
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/KyberNetwork/int256"
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/constants"
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/utils"
+	"github.com/daoleno/uniswap-sdk-core/entities"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func assertSameTrades(t *testing.T, expected, actual []*Trade) {
+	require.Equal(t, len(expected), len(actual))
+	for i := range expected {
+		assert.Equal(t, expected[i].Swaps[0].Route.TokenPath, actual[i].Swaps[0].Route.TokenPath)
+		assert.Equal(t, expected[i].Swaps[0].Route.Pools, actual[i].Swaps[0].Route.Pools)
+		assert.True(t, expected[i].InputAmount().EqualTo(actual[i].InputAmount().Fraction))
+		assert.True(t, expected[i].OutputAmount().EqualTo(actual[i].OutputAmount().Fraction))
+		assert.Equal(t, expected[i].InputAmount().Currency, actual[i].InputAmount().Currency)
+		assert.Equal(t, expected[i].OutputAmount().Currency, actual[i].OutputAmount().Currency)
+	}
+}
+
+func TestRouterBestTradeExactIn(t *testing.T) {
+	_, err := NewRouter(nil)
+	assert.ErrorIs(t, err, ErrNoPools)
+
+	pools := []*Pool{pool_weth_0, pool_0_1, pool_0_2, pool_0_3, pool_1_2, pool_1_3}
+	router, err := NewRouter(pools)
+	require.NoError(t, err)
+
+	_, err = router.BestTradeExactIn(entities.FromRawAmount(token0, big.NewInt(10000)), token2, &BestTradeOptions{MaxHops: 0})
+	assert.ErrorIs(t, err, ErrInvalidMaxHops)
+
+	// the same trades as BestTradeExactIn
+	for _, tt := range []struct {
+		amountIn    *entities.CurrencyAmount
+		currencyOut entities.Currency
+		opts        *BestTradeOptions
+	}{
+		{entities.FromRawAmount(token0, big.NewInt(10000)), token2, nil},
+		{entities.FromRawAmount(token0, big.NewInt(10)), token2, &BestTradeOptions{MaxNumResults: 3, MaxHops: 1}},
+		{entities.FromRawAmount(token0, big.NewInt(10)), token2, &BestTradeOptions{MaxNumResults: 10, MaxHops: 3}},
+		{entities.FromRawAmount(Ether, big.NewInt(100)), token3, nil},
+		{entities.FromRawAmount(token3, big.NewInt(100)), Ether, nil},
+	} {
+		expected, err := BestTradeExactIn(pools, tt.amountIn, tt.currencyOut, tt.opts, nil, nil, nil)
+		require.NoError(t, err)
+		actual, err := router.BestTradeExactIn(tt.amountIn, tt.currencyOut, tt.opts)
+		require.NoError(t, err)
+		assertSameTrades(t, expected, actual)
+	}
+
+	// no path
+	result, err := router.BestTradeExactIn(entities.FromRawAmount(token2, big.NewInt(10)), entities.WETH9[1], &BestTradeOptions{MaxNumResults: 3, MaxHops: 1})
+	require.NoError(t, err)
+	assert.Empty(t, result)
+}
+
+func TestRouterBestTradeExactOut(t *testing.T) {
+	pools := []*Pool{pool_weth_0, pool_0_1, pool_0_2, pool_0_3, pool_1_2, pool_1_3}
+	router, err := NewRouter(pools)
+	require.NoError(t, err)
+
+	for _, tt := range []struct {
+		currencyIn entities.Currency
+		amountOut  *entities.CurrencyAmount
+		opts       *BestTradeOptions
+	}{
+		{token0, entities.FromRawAmount(token2, big.NewInt(10000)), nil},
+		{token0, entities.FromRawAmount(token2, big.NewInt(10)), &BestTradeOptions{MaxNumResults: 3, MaxHops: 1}},
+		{Ether, entities.FromRawAmount(token3, big.NewInt(100)), nil},
+		{token3, entities.FromRawAmount(Ether, big.NewInt(100)), nil},
+	} {
+		expected, err := BestTradeExactOut(pools, tt.currencyIn, tt.amountOut, tt.opts, nil, nil, nil)
+		require.NoError(t, err)
+		actual, err := router.BestTradeExactOut(tt.currencyIn, tt.amountOut, tt.opts)
+		require.NoError(t, err)
+		assertSameTrades(t, expected, actual)
+	}
+}
+
+func TestRouterSkipsFailingPools(t *testing.T) {
+	// a pool whose tick data ends before the amount is filled fails to quote
+	ticks, err := NewTickListDataProvider([]Tick{
+		{Index: -10, LiquidityNet: int256.NewInt(1e6), LiquidityGross: uint256.NewInt(1e6)},
+		{Index: 10, LiquidityNet: int256.NewInt(-1e6), LiquidityGross: uint256.NewInt(1e6)},
+	}, constants.TickSpacings[constants.FeeLow])
+	require.NoError(t, err)
+	narrow, err := NewPool(token0, token2, constants.FeeLow, utils.EncodeSqrtRatioX96(constants.One, constants.One), big.NewInt(1e6), 0, ticks)
+	require.NoError(t, err)
+	pools := []*Pool{narrow, pool_0_1, pool_0_2, pool_1_2}
+
+	_, err = BestTradeExactIn(pools, entities.FromRawAmount(token0, big.NewInt(10000)), token2, nil, nil, nil, nil)
+	assert.Error(t, err)
+
+	router, err := NewRouter(pools)
+	require.NoError(t, err)
+	result, err := router.BestTradeExactIn(entities.FromRawAmount(token0, big.NewInt(10000)), token2, nil)
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+	assert.Equal(t, []*Pool{pool_0_2}, result[0].Swaps[0].Route.Pools)
+
+	result, err = router.BestTradeExactOut(token0, entities.FromRawAmount(token2, big.NewInt(10000)), nil)
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+	assert.Equal(t, []*Pool{pool_0_2}, result[0].Swaps[0].Route.Pools)
+}
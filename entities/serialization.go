@@ -0,0 +1,1001 @@
+package entities
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	"github.com/KyberNetwork/int256"
+	"github.com/daoleno/uniswap-sdk-core/entities"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/constants"
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/utils"
+)
+
+// Pools, positions and trades are serialized to JSON, with big numbers as decimal strings, and to a compact binary
+// format. The tick data of a pool is serialized as the list of its ticks, its provider must implement
+// ListableTickDataProvider, and it is restored as a TickListDataProvider.
+
+var (
+	ErrTickDataNotListable = errors.New("tick data provider can't list its ticks")
+	ErrUnknownDeployment   = errors.New("unknown deployment")
+	ErrInvalidEncoding     = errors.New("invalid encoding")
+)
+
+// The version of the binary format, written first
+const binaryVersion = 1
+
+type currencyJSON struct {
+	ChainID  uint            `json:"chainId"`
+	Native   bool            `json:"native,omitempty"`
+	Address  *common.Address `json:"address,omitempty"`
+	Decimals uint            `json:"decimals"`
+	Symbol   string          `json:"symbol,omitempty"`
+	Name     string          `json:"name,omitempty"`
+}
+
+func newCurrencyJSON(currency entities.Currency) currencyJSON {
+	c := currencyJSON{
+		ChainID:  currency.ChainId(),
+		Native:   currency.IsNative(),
+		Decimals: currency.Decimals(),
+		Symbol:   currency.Symbol(),
+		Name:     currency.Name(),
+	}
+	if !c.Native {
+		address := currency.Wrapped().Address
+		c.Address = &address
+	}
+	return c
+}
+
+func (c currencyJSON) currency() (entities.Currency, error) {
+	if c.Native {
+		return entities.EtherOnChain(c.ChainID), nil
+	}
+	return c.token()
+}
+
+func (c currencyJSON) token() (*entities.Token, error) {
+	if c.Native || c.Address == nil || c.Decimals >= 255 {
+		return nil, ErrInvalidEncoding
+	}
+	return entities.NewToken(c.ChainID, *c.Address, c.Decimals, c.Symbol, c.Name), nil
+}
+
+type currencyAmountJSON struct {
+	Currency    currencyJSON `json:"currency"`
+	Numerator   string       `json:"numerator"`
+	Denominator string       `json:"denominator"`
+}
+
+func newCurrencyAmountJSON(amount *entities.CurrencyAmount) currencyAmountJSON {
+	return currencyAmountJSON{
+		Currency:    newCurrencyJSON(amount.Currency),
+		Numerator:   amount.Numerator.String(),
+		Denominator: amount.Denominator.String(),
+	}
+}
+
+func (a currencyAmountJSON) currencyAmount() (*entities.CurrencyAmount, error) {
+	currency, err := a.Currency.currency()
+	if err != nil {
+		return nil, err
+	}
+	numerator, ok := new(big.Int).SetString(a.Numerator, 10)
+	if !ok {
+		return nil, ErrInvalidEncoding
+	}
+	denominator, ok := new(big.Int).SetString(a.Denominator, 10)
+	if !ok || denominator.Sign() == 0 {
+		return nil, ErrInvalidEncoding
+	}
+	return entities.FromFractionalAmount(currency, numerator, denominator), nil
+}
+
+type deploymentJSON struct {
+	ChainID  uint               `json:"chainId"`
+	Protocol constants.Protocol `json:"protocol"`
+}
+
+// deployment looks the deployment up in the registry, a nil deployment is left nil
+func (d *deploymentJSON) deployment() (*constants.Deployment, error) {
+	if d == nil {
+		return nil, nil
+	}
+	deployment, ok := constants.GetDeployment(d.ChainID, d.Protocol)
+	if !ok {
+		return nil, ErrUnknownDeployment
+	}
+	return deployment, nil
+}
+
+type tickJSON struct {
+	Index                          int    `json:"index"`
+	LiquidityGross                 string `json:"liquidityGross"`
+	LiquidityNet                   string `json:"liquidityNet"`
+	FeeGrowthOutside0X128          string `json:"feeGrowthOutside0X128,omitempty"`
+	FeeGrowthOutside1X128          string `json:"feeGrowthOutside1X128,omitempty"`
+	TickCumulativeOutside          int64  `json:"tickCumulativeOutside,omitempty"`
+	SecondsPerLiquidityOutsideX128 string `json:"secondsPerLiquidityOutsideX128,omitempty"`
+	SecondsOutside                 uint32 `json:"secondsOutside,omitempty"`
+}
+
+// MarshalJSON encodes the tick, with its big numbers as decimal strings
+func (t Tick) MarshalJSON() ([]byte, error) {
+	return json.Marshal(tickJSON{
+		Index:                          t.Index,
+		LiquidityGross:                 uint256ToDec(t.LiquidityGross),
+		LiquidityNet:                   int256ToDec(t.LiquidityNet),
+		FeeGrowthOutside0X128:          uint256ToDec(t.FeeGrowthOutside0X128),
+		FeeGrowthOutside1X128:          uint256ToDec(t.FeeGrowthOutside1X128),
+		TickCumulativeOutside:          t.TickCumulativeOutside,
+		SecondsPerLiquidityOutsideX128: uint256ToDec(t.SecondsPerLiquidityOutsideX128),
+		SecondsOutside:                 t.SecondsOutside,
+	})
+}
+
+// UnmarshalJSON decodes a tick encoded by MarshalJSON
+func (t *Tick) UnmarshalJSON(data []byte) error {
+	var v tickJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	var (
+		tick Tick
+		err  error
+	)
+	tick.Index = v.Index
+	if tick.LiquidityGross, err = decToUint256(v.LiquidityGross); err != nil {
+		return err
+	}
+	if tick.LiquidityNet, err = decToInt256(v.LiquidityNet); err != nil {
+		return err
+	}
+	if tick.LiquidityGross == nil || tick.LiquidityNet == nil {
+		return ErrInvalidEncoding
+	}
+	if tick.FeeGrowthOutside0X128, err = decToUint256(v.FeeGrowthOutside0X128); err != nil {
+		return err
+	}
+	if tick.FeeGrowthOutside1X128, err = decToUint256(v.FeeGrowthOutside1X128); err != nil {
+		return err
+	}
+	tick.TickCumulativeOutside = v.TickCumulativeOutside
+	if tick.SecondsPerLiquidityOutsideX128, err = decToUint256(v.SecondsPerLiquidityOutsideX128); err != nil {
+		return err
+	}
+	tick.SecondsOutside = v.SecondsOutside
+	*t = tick
+	return nil
+}
+
+type poolJSON struct {
+	Token0               currencyJSON        `json:"token0"`
+	Token1               currencyJSON        `json:"token1"`
+	Fee                  constants.FeeAmount `json:"fee"`
+	TickSpacing          int                 `json:"tickSpacing,omitempty"`
+	Deployment           *deploymentJSON     `json:"deployment,omitempty"`
+	SqrtRatioX96         string              `json:"sqrtRatioX96"`
+	Liquidity            string              `json:"liquidity"`
+	TickCurrent          int                 `json:"tickCurrent"`
+	Ticks                []Tick              `json:"ticks"`
+	FeeGrowthGlobal0X128 string              `json:"feeGrowthGlobal0X128,omitempty"`
+	FeeGrowthGlobal1X128 string              `json:"feeGrowthGlobal1X128,omitempty"`
+	FeeProtocol          uint8               `json:"feeProtocol,omitempty"`
+	ProtocolFees0        string              `json:"protocolFees0,omitempty"`
+	ProtocolFees1        string              `json:"protocolFees1,omitempty"`
+}
+
+// ticks returns the ticks of the tick data provider of the pool, or nil if the pool has none
+func (p *Pool) ticks() ([]Tick, error) {
+	if p.TickDataProvider == nil {
+		return nil, nil
+	}
+	provider, ok := p.TickDataProvider.(ListableTickDataProvider)
+	if !ok {
+		return nil, ErrTickDataNotListable
+	}
+	return provider.Ticks(), nil
+}
+
+// MarshalJSON encodes the state of the pool, including all its ticks
+func (p *Pool) MarshalJSON() ([]byte, error) {
+	ticks, err := p.ticks()
+	if err != nil {
+		return nil, err
+	}
+	v := poolJSON{
+		Token0:               newCurrencyJSON(p.Token0),
+		Token1:               newCurrencyJSON(p.Token1),
+		Fee:                  p.Fee,
+		TickSpacing:          p.spacing,
+		SqrtRatioX96:         uint256ToDec(p.SqrtRatioX96),
+		Liquidity:            uint256ToDec(p.Liquidity),
+		TickCurrent:          p.TickCurrent,
+		Ticks:                ticks,
+		FeeGrowthGlobal0X128: uint256ToDec(p.FeeGrowthGlobal0X128),
+		FeeGrowthGlobal1X128: uint256ToDec(p.FeeGrowthGlobal1X128),
+		FeeProtocol:          p.FeeProtocol,
+		ProtocolFees0:        uint256ToDec(p.ProtocolFees0),
+		ProtocolFees1:        uint256ToDec(p.ProtocolFees1),
+	}
+	if p.Deployment != nil {
+		v.Deployment = &deploymentJSON{ChainID: p.Deployment.ChainID, Protocol: p.Deployment.Protocol}
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON decodes a pool encoded by MarshalJSON, its deployment must be registered
+func (p *Pool) UnmarshalJSON(data []byte) error {
+	var v poolJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	token0, err := v.Token0.token()
+	if err != nil {
+		return err
+	}
+	token1, err := v.Token1.token()
+	if err != nil {
+		return err
+	}
+	deployment, err := v.Deployment.deployment()
+	if err != nil {
+		return err
+	}
+	state := poolState{
+		token0:      token0,
+		token1:      token1,
+		fee:         v.Fee,
+		tickSpacing: v.TickSpacing,
+		deployment:  deployment,
+		tickCurrent: v.TickCurrent,
+		ticks:       v.Ticks,
+		feeProtocol: v.FeeProtocol,
+	}
+	for _, field := range []struct {
+		dec   string
+		value **utils.Uint256
+	}{
+		{v.SqrtRatioX96, &state.sqrtRatioX96},
+		{v.Liquidity, &state.liquidity},
+		{v.FeeGrowthGlobal0X128, &state.feeGrowthGlobal0X128},
+		{v.FeeGrowthGlobal1X128, &state.feeGrowthGlobal1X128},
+		{v.ProtocolFees0, &state.protocolFees0},
+		{v.ProtocolFees1, &state.protocolFees1},
+	} {
+		if *field.value, err = decToUint256(field.dec); err != nil {
+			return err
+		}
+	}
+	pool, err := state.pool()
+	if err != nil {
+		return err
+	}
+	*p = *pool
+	return nil
+}
+
+// poolState holds the decoded fields of a pool
+type poolState struct {
+	token0, token1       *entities.Token
+	fee                  constants.FeeAmount
+	tickSpacing          int
+	deployment           *constants.Deployment
+	sqrtRatioX96         *utils.Uint160
+	liquidity            *utils.Uint128
+	tickCurrent          int
+	ticks                []Tick
+	feeGrowthGlobal0X128 *utils.Uint256
+	feeGrowthGlobal1X128 *utils.Uint256
+	feeProtocol          uint8
+	protocolFees0        *utils.Uint128
+	protocolFees1        *utils.Uint128
+}
+
+// pool reconstructs and validates the pool, nil ticks mean that the pool has no tick data provider
+func (s *poolState) pool() (*Pool, error) {
+	if s.sqrtRatioX96 == nil || s.liquidity == nil {
+		return nil, ErrInvalidEncoding
+	}
+	p := &Pool{Fee: s.fee, Deployment: s.deployment, spacing: s.tickSpacing}
+	var ticks TickDataProvider
+	if s.ticks != nil {
+		provider, err := NewTickListDataProvider(s.ticks, p.TickSpacing())
+		if err != nil {
+			return nil, err
+		}
+		ticks = provider
+	}
+
+	var (
+		pool *Pool
+		err  error
+	)
+	if s.tickSpacing != 0 {
+		pool, err = NewPoolWithTickSpacing(s.token0, s.token1, s.fee, s.tickSpacing, s.sqrtRatioX96, s.liquidity,
+			s.tickCurrent, ticks)
+	} else {
+		pool, err = NewPoolV2(s.token0, s.token1, s.fee, s.sqrtRatioX96, s.liquidity, s.tickCurrent, ticks)
+	}
+	if err != nil {
+		return nil, err
+	}
+	pool.Deployment = s.deployment
+	pool.FeeGrowthGlobal0X128 = s.feeGrowthGlobal0X128
+	pool.FeeGrowthGlobal1X128 = s.feeGrowthGlobal1X128
+	pool.FeeProtocol = s.feeProtocol
+	pool.ProtocolFees0 = s.protocolFees0
+	pool.ProtocolFees1 = s.protocolFees1
+	return pool, nil
+}
+
+type positionJSON struct {
+	Pool      *Pool  `json:"pool"`
+	TickLower int    `json:"tickLower"`
+	TickUpper int    `json:"tickUpper"`
+	Liquidity string `json:"liquidity"`
+}
+
+// MarshalJSON encodes the position with the state of its pool
+func (p *Position) MarshalJSON() ([]byte, error) {
+	return json.Marshal(positionJSON{
+		Pool:      p.Pool,
+		TickLower: p.TickLower,
+		TickUpper: p.TickUpper,
+		Liquidity: p.Liquidity.String(),
+	})
+}
+
+// UnmarshalJSON decodes a position encoded by MarshalJSON
+func (p *Position) UnmarshalJSON(data []byte) error {
+	var v positionJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if v.Pool == nil {
+		return ErrInvalidEncoding
+	}
+	liquidity, ok := new(big.Int).SetString(v.Liquidity, 10)
+	if !ok {
+		return ErrInvalidEncoding
+	}
+	position, err := NewPosition(v.Pool, liquidity, v.TickLower, v.TickUpper)
+	if err != nil {
+		return err
+	}
+	*p = *position
+	return nil
+}
+
+type routeJSON struct {
+	Pools  []*Pool      `json:"pools"`
+	Input  currencyJSON `json:"input"`
+	Output currencyJSON `json:"output"`
+}
+
+// MarshalJSON encodes the route with the state of its pools
+func (r *Route) MarshalJSON() ([]byte, error) {
+	return json.Marshal(routeJSON{
+		Pools:  r.Pools,
+		Input:  newCurrencyJSON(r.Input),
+		Output: newCurrencyJSON(r.Output),
+	})
+}
+
+// UnmarshalJSON decodes a route encoded by MarshalJSON
+func (r *Route) UnmarshalJSON(data []byte) error {
+	var v routeJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	input, err := v.Input.currency()
+	if err != nil {
+		return err
+	}
+	output, err := v.Output.currency()
+	if err != nil {
+		return err
+	}
+	for _, pool := range v.Pools {
+		if pool == nil {
+			return ErrInvalidEncoding
+		}
+	}
+	route, err := NewRoute(v.Pools, input, output)
+	if err != nil {
+		return err
+	}
+	*r = *route
+	return nil
+}
+
+type swapJSON struct {
+	Route              *Route             `json:"route"`
+	InputAmount        currencyAmountJSON `json:"inputAmount"`
+	OutputAmount       currencyAmountJSON `json:"outputAmount"`
+	CrossInitTickLoops int                `json:"crossInitTickLoops,omitempty"`
+}
+
+type tradeJSON struct {
+	Swaps     []swapJSON         `json:"swaps"`
+	TradeType entities.TradeType `json:"tradeType"`
+}
+
+// MarshalJSON encodes the trade with its routes and amounts
+func (t *Trade) MarshalJSON() ([]byte, error) {
+	v := tradeJSON{TradeType: t.TradeType, Swaps: make([]swapJSON, len(t.Swaps))}
+	for i, swap := range t.Swaps {
+		v.Swaps[i] = swapJSON{
+			Route:              swap.Route,
+			InputAmount:        newCurrencyAmountJSON(swap.InputAmount),
+			OutputAmount:       newCurrencyAmountJSON(swap.OutputAmount),
+			CrossInitTickLoops: swap.CrossInitTickLoops,
+		}
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON decodes a trade encoded by MarshalJSON, without simulating its swaps again
+func (t *Trade) UnmarshalJSON(data []byte) error {
+	var v tradeJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if len(v.Swaps) == 0 {
+		return ErrInvalidEncoding
+	}
+	swaps := make([]*Swap, len(v.Swaps))
+	for i, s := range v.Swaps {
+		if s.Route == nil {
+			return ErrInvalidEncoding
+		}
+		inputAmount, err := s.InputAmount.currencyAmount()
+		if err != nil {
+			return err
+		}
+		outputAmount, err := s.OutputAmount.currencyAmount()
+		if err != nil {
+			return err
+		}
+		swaps[i] = &Swap{
+			Route:              s.Route,
+			InputAmount:        inputAmount,
+			OutputAmount:       outputAmount,
+			CrossInitTickLoops: s.CrossInitTickLoops,
+		}
+	}
+	trade, err := CreateUncheckedTradeWithMultipleRoutes(swaps, v.TradeType)
+	if err != nil {
+		return err
+	}
+	*t = *trade
+	return nil
+}
+
+// MarshalBinary encodes the state of the pool, including all its ticks, in the compact binary format
+func (p *Pool) MarshalBinary() ([]byte, error) {
+	w := binaryWriter{buf: []byte{binaryVersion}}
+	if err := w.pool(p); err != nil {
+		return nil, err
+	}
+	return w.buf, nil
+}
+
+// UnmarshalBinary decodes a pool encoded by MarshalBinary, its deployment must be registered
+func (p *Pool) UnmarshalBinary(data []byte) error {
+	r, err := newBinaryReader(data)
+	if err != nil {
+		return err
+	}
+	pool, err := r.pool()
+	if err != nil {
+		return err
+	}
+	if err := r.end(); err != nil {
+		return err
+	}
+	*p = *pool
+	return nil
+}
+
+// MarshalBinary encodes the position with the state of its pool in the compact binary format
+func (p *Position) MarshalBinary() ([]byte, error) {
+	w := binaryWriter{buf: []byte{binaryVersion}}
+	if err := w.pool(p.Pool); err != nil {
+		return nil, err
+	}
+	w.varint(int64(p.TickLower))
+	w.varint(int64(p.TickUpper))
+	w.bigInt(p.Liquidity)
+	return w.buf, nil
+}
+
+// UnmarshalBinary decodes a position encoded by MarshalBinary
+func (p *Position) UnmarshalBinary(data []byte) error {
+	r, err := newBinaryReader(data)
+	if err != nil {
+		return err
+	}
+	pool, err := r.pool()
+	if err != nil {
+		return err
+	}
+	tickLower, tickUpper, liquidity := int(r.varint()), int(r.varint()), r.bigInt()
+	if err := r.end(); err != nil {
+		return err
+	}
+	if liquidity == nil {
+		return ErrInvalidEncoding
+	}
+	position, err := NewPosition(pool, liquidity, tickLower, tickUpper)
+	if err != nil {
+		return err
+	}
+	*p = *position
+	return nil
+}
+
+// MarshalBinary encodes the trade with its routes and amounts in the compact binary format
+func (t *Trade) MarshalBinary() ([]byte, error) {
+	w := binaryWriter{buf: []byte{binaryVersion}}
+	w.uvarint(uint64(t.TradeType))
+	w.uvarint(uint64(len(t.Swaps)))
+	for _, swap := range t.Swaps {
+		w.uvarint(uint64(len(swap.Route.Pools)))
+		for _, pool := range swap.Route.Pools {
+			if err := w.pool(pool); err != nil {
+				return nil, err
+			}
+		}
+		w.currency(swap.Route.Input)
+		w.currency(swap.Route.Output)
+		w.currencyAmount(swap.InputAmount)
+		w.currencyAmount(swap.OutputAmount)
+		w.uvarint(uint64(swap.CrossInitTickLoops))
+	}
+	return w.buf, nil
+}
+
+// UnmarshalBinary decodes a trade encoded by MarshalBinary, without simulating its swaps again
+func (t *Trade) UnmarshalBinary(data []byte) error {
+	r, err := newBinaryReader(data)
+	if err != nil {
+		return err
+	}
+	tradeType := entities.TradeType(r.uvarint())
+	swaps := make([]*Swap, r.length())
+	for i := range swaps {
+		pools := make([]*Pool, r.length())
+		for j := range pools {
+			if pools[j], err = r.pool(); err != nil {
+				return err
+			}
+		}
+		input, output := r.currency(), r.currency()
+		inputAmount, outputAmount := r.currencyAmount(), r.currencyAmount()
+		crossInitTickLoops := int(r.uvarint())
+		if r.err != nil {
+			return r.err
+		}
+		route, err := NewRoute(pools, input, output)
+		if err != nil {
+			return err
+		}
+		swaps[i] = &Swap{
+			Route:              route,
+			InputAmount:        inputAmount,
+			OutputAmount:       outputAmount,
+			CrossInitTickLoops: crossInitTickLoops,
+		}
+	}
+	if err := r.end(); err != nil {
+		return err
+	}
+	if len(swaps) == 0 {
+		return ErrInvalidEncoding
+	}
+	trade, err := CreateUncheckedTradeWithMultipleRoutes(swaps, tradeType)
+	if err != nil {
+		return err
+	}
+	*t = *trade
+	return nil
+}
+
+// binaryWriter appends the fields of the binary format: integers are varints, and numbers, strings and lists are
+// prefixed by their length
+type binaryWriter struct {
+	buf []byte
+}
+
+func (w *binaryWriter) uvarint(x uint64) {
+	w.buf = binary.AppendUvarint(w.buf, x)
+}
+
+func (w *binaryWriter) varint(x int64) {
+	w.buf = binary.AppendVarint(w.buf, x)
+}
+
+func (w *binaryWriter) bool(b bool) {
+	if b {
+		w.buf = append(w.buf, 1)
+	} else {
+		w.buf = append(w.buf, 0)
+	}
+}
+
+func (w *binaryWriter) string(s string) {
+	w.uvarint(uint64(len(s)))
+	w.buf = append(w.buf, s...)
+}
+
+func (w *binaryWriter) address(address common.Address) {
+	w.buf = append(w.buf, address[:]...)
+}
+
+// uint256 writes the length of the big endian bytes of the number plus one, zero for nil, and the bytes
+func (w *binaryWriter) uint256(x *uint256.Int) {
+	if x == nil {
+		w.uvarint(0)
+		return
+	}
+	b := x.Bytes()
+	w.uvarint(uint64(len(b)) + 1)
+	w.buf = append(w.buf, b...)
+}
+
+// bigInt writes the length of the sign and big endian bytes of the magnitude, zero for nil, and the bytes
+func (w *binaryWriter) bigInt(x *big.Int) {
+	if x == nil {
+		w.uvarint(0)
+		return
+	}
+	b := x.Bytes()
+	w.uvarint(uint64(len(b)) + 1)
+	w.bool(x.Sign() < 0)
+	w.buf = append(w.buf, b...)
+}
+
+func (w *binaryWriter) int256(x *utils.Int128) {
+	if x == nil {
+		w.bigInt(nil)
+		return
+	}
+	w.bigInt(x.ToBig())
+}
+
+func (w *binaryWriter) currency(currency entities.Currency) {
+	w.uvarint(uint64(currency.ChainId()))
+	w.bool(currency.IsNative())
+	if !currency.IsNative() {
+		w.address(currency.Wrapped().Address)
+	}
+	w.uvarint(uint64(currency.Decimals()))
+	w.string(currency.Symbol())
+	w.string(currency.Name())
+}
+
+func (w *binaryWriter) currencyAmount(amount *entities.CurrencyAmount) {
+	w.currency(amount.Currency)
+	w.bigInt(amount.Numerator)
+	w.bigInt(amount.Denominator)
+}
+
+func (w *binaryWriter) tick(t Tick) {
+	w.varint(int64(t.Index))
+	w.uint256(t.LiquidityGross)
+	w.int256(t.LiquidityNet)
+	w.uint256(t.FeeGrowthOutside0X128)
+	w.uint256(t.FeeGrowthOutside1X128)
+	w.varint(t.TickCumulativeOutside)
+	w.uint256(t.SecondsPerLiquidityOutsideX128)
+	w.uvarint(uint64(t.SecondsOutside))
+}
+
+func (w *binaryWriter) pool(p *Pool) error {
+	ticks, err := p.ticks()
+	if err != nil {
+		return err
+	}
+	w.currency(p.Token0)
+	w.currency(p.Token1)
+	w.uvarint(uint64(p.Fee))
+	w.varint(int64(p.spacing))
+	w.bool(p.Deployment != nil)
+	if p.Deployment != nil {
+		w.uvarint(uint64(p.Deployment.ChainID))
+		w.string(string(p.Deployment.Protocol))
+	}
+	w.uint256(p.SqrtRatioX96)
+	w.uint256(p.Liquidity)
+	w.varint(int64(p.TickCurrent))
+	w.uint256(p.FeeGrowthGlobal0X128)
+	w.uint256(p.FeeGrowthGlobal1X128)
+	w.buf = append(w.buf, p.FeeProtocol)
+	w.uint256(p.ProtocolFees0)
+	w.uint256(p.ProtocolFees1)
+	// the number of ticks plus one, zero if the pool has no tick data provider
+	if p.TickDataProvider == nil {
+		w.uvarint(0)
+		return nil
+	}
+	w.uvarint(uint64(len(ticks)) + 1)
+	for _, t := range ticks {
+		w.tick(t)
+	}
+	return nil
+}
+
+// binaryReader reads the fields written by binaryWriter, the first error is kept and the next reads return zero values
+type binaryReader struct {
+	buf []byte
+	err error
+}
+
+func newBinaryReader(data []byte) (*binaryReader, error) {
+	if len(data) == 0 || data[0] != binaryVersion {
+		return nil, ErrInvalidEncoding
+	}
+	return &binaryReader{buf: data[1:]}, nil
+}
+
+// end checks that all the data was read
+func (r *binaryReader) end() error {
+	if r.err == nil && len(r.buf) != 0 {
+		r.err = ErrInvalidEncoding
+	}
+	return r.err
+}
+
+func (r *binaryReader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(r.buf) {
+		r.err = ErrInvalidEncoding
+		return nil
+	}
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b
+}
+
+func (r *binaryReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	x, n := binary.Uvarint(r.buf)
+	if n <= 0 {
+		r.err = ErrInvalidEncoding
+		return 0
+	}
+	r.buf = r.buf[n:]
+	return x
+}
+
+func (r *binaryReader) varint() int64 {
+	if r.err != nil {
+		return 0
+	}
+	x, n := binary.Varint(r.buf)
+	if n <= 0 {
+		r.err = ErrInvalidEncoding
+		return 0
+	}
+	r.buf = r.buf[n:]
+	return x
+}
+
+// length reads the length of a list, which can't be more than the remaining bytes
+func (r *binaryReader) length() int {
+	n := r.uvarint()
+	if n > uint64(len(r.buf)) {
+		r.err = ErrInvalidEncoding
+		return 0
+	}
+	return int(n)
+}
+
+func (r *binaryReader) bool() bool {
+	b := r.next(1)
+	if b == nil {
+		return false
+	}
+	if b[0] > 1 {
+		r.err = ErrInvalidEncoding
+	}
+	return b[0] == 1
+}
+
+func (r *binaryReader) byte() byte {
+	b := r.next(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+func (r *binaryReader) string() string {
+	return string(r.next(r.length()))
+}
+
+func (r *binaryReader) address() common.Address {
+	return common.BytesToAddress(r.next(common.AddressLength))
+}
+
+func (r *binaryReader) uint256() *uint256.Int {
+	n := r.length()
+	if n == 0 {
+		return nil
+	}
+	if n-1 > 32 {
+		r.err = ErrInvalidEncoding
+		return nil
+	}
+	return new(uint256.Int).SetBytes(r.next(n - 1))
+}
+
+func (r *binaryReader) bigInt() *big.Int {
+	n := r.length()
+	if n == 0 {
+		return nil
+	}
+	negative := r.bool()
+	x := new(big.Int).SetBytes(r.next(n - 1))
+	if negative {
+		x.Neg(x)
+	}
+	return x
+}
+
+func (r *binaryReader) int256() *utils.Int128 {
+	b := r.bigInt()
+	if b == nil || r.err != nil {
+		return nil
+	}
+	x, err := int256.FromBig(b)
+	if err != nil {
+		r.err = ErrInvalidEncoding
+		return nil
+	}
+	return x
+}
+
+func (r *binaryReader) currencyJSON() currencyJSON {
+	c := currencyJSON{ChainID: uint(r.uvarint()), Native: r.bool()}
+	if !c.Native {
+		address := r.address()
+		c.Address = &address
+	}
+	c.Decimals = uint(r.uvarint())
+	c.Symbol = r.string()
+	c.Name = r.string()
+	return c
+}
+
+func (r *binaryReader) currency() entities.Currency {
+	c := r.currencyJSON()
+	if r.err != nil {
+		return nil
+	}
+	currency, err := c.currency()
+	if err != nil {
+		r.err = err
+	}
+	return currency
+}
+
+func (r *binaryReader) token() *entities.Token {
+	c := r.currencyJSON()
+	if r.err != nil {
+		return nil
+	}
+	token, err := c.token()
+	if err != nil {
+		r.err = err
+	}
+	return token
+}
+
+func (r *binaryReader) currencyAmount() *entities.CurrencyAmount {
+	currency := r.currency()
+	numerator, denominator := r.bigInt(), r.bigInt()
+	if r.err != nil {
+		return nil
+	}
+	if numerator == nil || denominator == nil || denominator.Sign() == 0 {
+		r.err = ErrInvalidEncoding
+		return nil
+	}
+	return entities.FromFractionalAmount(currency, numerator, denominator)
+}
+
+func (r *binaryReader) tick() Tick {
+	t := Tick{
+		Index:                          int(r.varint()),
+		LiquidityGross:                 r.uint256(),
+		LiquidityNet:                   r.int256(),
+		FeeGrowthOutside0X128:          r.uint256(),
+		FeeGrowthOutside1X128:          r.uint256(),
+		TickCumulativeOutside:          r.varint(),
+		SecondsPerLiquidityOutsideX128: r.uint256(),
+		SecondsOutside:                 uint32(r.uvarint()),
+	}
+	if r.err == nil && (t.LiquidityGross == nil || t.LiquidityNet == nil) {
+		r.err = ErrInvalidEncoding
+	}
+	return t
+}
+
+func (r *binaryReader) pool() (*Pool, error) {
+	s := poolState{
+		token0:      r.token(),
+		token1:      r.token(),
+		fee:         constants.FeeAmount(r.uvarint()),
+		tickSpacing: int(r.varint()),
+	}
+	if r.bool() {
+		d := &deploymentJSON{ChainID: uint(r.uvarint()), Protocol: constants.Protocol(r.string())}
+		if r.err != nil {
+			return nil, r.err
+		}
+		deployment, err := d.deployment()
+		if err != nil {
+			return nil, err
+		}
+		s.deployment = deployment
+	}
+	s.sqrtRatioX96 = r.uint256()
+	s.liquidity = r.uint256()
+	s.tickCurrent = int(r.varint())
+	s.feeGrowthGlobal0X128 = r.uint256()
+	s.feeGrowthGlobal1X128 = r.uint256()
+	s.feeProtocol = r.byte()
+	s.protocolFees0 = r.uint256()
+	s.protocolFees1 = r.uint256()
+	if n := r.length(); n > 0 {
+		s.ticks = make([]Tick, n-1)
+		for i := range s.ticks {
+			s.ticks[i] = r.tick()
+		}
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return s.pool()
+}
+
+func uint256ToDec(x *uint256.Int) string {
+	if x == nil {
+		return ""
+	}
+	return x.Dec()
+}
+
+// decToUint256 parses a decimal string, an empty string is a nil number
+func decToUint256(s string) (*uint256.Int, error) {
+	if s == "" {
+		return nil, nil
+	}
+	return uint256.FromDecimal(s)
+}
+
+func int256ToDec(x *utils.Int128) string {
+	if x == nil {
+		return ""
+	}
+	return x.Dec()
+}
+
+// decToInt256 parses a decimal string, an empty string is a nil number
+func decToInt256(s string) (*utils.Int128, error) {
+	if s == "" {
+		return nil, nil
+	}
+	return int256.FromDec(s)
+}
@@ -0,0 +1,223 @@
+package entities
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/KyberNetwork/int256"
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/constants"
+	"github.com/daoleno/uniswap-sdk-core/entities"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSerializedPool returns a pool with fee state, a deployment and ticks with fee and oracle values
+func newSerializedPool(t *testing.T) *Pool {
+	pool := newTestPool()
+	pool.Deployment = constants.UniswapV3Mainnet
+	pool.FeeGrowthGlobal0X128 = uint256.MustFromDecimal("340282366920938463463374607431768211456")
+	pool.FeeGrowthGlobal1X128 = uint256.NewInt(12345)
+	pool.FeeProtocol = 4<<4 | 4
+	pool.ProtocolFees0 = uint256.NewInt(7)
+	require.NoError(t, pool.Mint(-100, 200, uint256.NewInt(1_000_000), nil))
+	ticks := pool.TickDataProvider.(MutableTickDataProvider)
+	tick, err := ticks.GetTick(200)
+	require.NoError(t, err)
+	tick.TickCumulativeOutside = -42
+	tick.SecondsPerLiquidityOutsideX128 = uint256.NewInt(99)
+	tick.SecondsOutside = 1_700_000_000
+	require.NoError(t, ticks.SetTick(tick))
+	return pool
+}
+
+func TestPoolJSON(t *testing.T) {
+	pool := newSerializedPool(t)
+	data, err := json.Marshal(pool)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"feeGrowthGlobal0X128":"340282366920938463463374607431768211456"`,
+		"big numbers are decimal strings")
+	assert.Contains(t, string(data), `"liquidityNet":"-1000000"`)
+
+	var decoded Pool
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assertSamePool(t, pool, &decoded)
+	again, err := json.Marshal(&decoded)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(data), string(again))
+
+	// a pool without ticks, with an explicit tick spacing
+	empty, err := NewPoolWithTickSpacing(USDC, DAI, 250, 5, pool.SqrtRatioX96, pool.Liquidity, pool.TickCurrent, nil)
+	require.NoError(t, err)
+	data, err = json.Marshal(empty)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Nil(t, decoded.TickDataProvider)
+	assert.Equal(t, 5, decoded.TickSpacing())
+
+	_, err = json.Marshal(&Pool{TickDataProvider: struct{ TickDataProvider }{}})
+	assert.ErrorIs(t, err, ErrTickDataNotListable)
+	pool.Deployment = &constants.Deployment{ChainID: 1, Protocol: "unregistered"}
+	data, err = json.Marshal(pool)
+	require.NoError(t, err)
+	assert.ErrorIs(t, json.Unmarshal(data, &decoded), ErrUnknownDeployment)
+}
+
+func TestPoolBinary(t *testing.T) {
+	pool := newSerializedPool(t)
+	data, err := pool.MarshalBinary()
+	require.NoError(t, err)
+	jsonData, err := json.Marshal(pool)
+	require.NoError(t, err)
+	assert.Less(t, len(data), len(jsonData)/2, "the binary format is compact")
+
+	var decoded Pool
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	assertSamePool(t, pool, &decoded)
+	again, err := decoded.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, data, again)
+
+	// a bitmap provider is listed as well
+	bitmap, err := NewTickBitmapDataProvider(pool.TickDataProvider.(ListableTickDataProvider).Ticks(), pool.TickSpacing())
+	require.NoError(t, err)
+	pool.TickDataProvider = bitmap
+	again, err = pool.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, data, again)
+
+	// truncated, trailing or unknown data
+	for _, invalid := range [][]byte{nil, data[:len(data)-1], append(append([]byte(nil), data...), 0), append([]byte{2}, data[1:]...)} {
+		assert.ErrorIs(t, decoded.UnmarshalBinary(invalid), ErrInvalidEncoding)
+	}
+
+	// ticks without liquidity
+	for _, tick := range []Tick{
+		{Index: -100, LiquidityGross: uint256.NewInt(1)},
+		{Index: -100, LiquidityNet: int256.NewInt(1)},
+	} {
+		pool.TickDataProvider = &TickListDataProvider{ticks: []Tick{tick}, tickSpacing: pool.TickSpacing()}
+		invalid, err := pool.MarshalBinary()
+		require.NoError(t, err)
+		assert.ErrorIs(t, decoded.UnmarshalBinary(invalid), ErrInvalidEncoding)
+	}
+}
+
+// assertSamePool checks that the pools have the same state and quote the same amounts
+func assertSamePool(t *testing.T, expected, actual *Pool) {
+	assert.True(t, expected.Token0.Equal(actual.Token0))
+	assert.True(t, expected.Token1.Equal(actual.Token1))
+	assert.Equal(t, expected.Token1.Symbol(), actual.Token1.Symbol())
+	assert.Equal(t, expected.Fee, actual.Fee)
+	assert.Equal(t, expected.TickSpacing(), actual.TickSpacing())
+	assert.Equal(t, expected.Deployment, actual.Deployment)
+	assert.Equal(t, expected.SqrtRatioX96, actual.SqrtRatioX96)
+	assert.Equal(t, expected.Liquidity, actual.Liquidity)
+	assert.Equal(t, expected.TickCurrent, actual.TickCurrent)
+	assert.Equal(t, expected.FeeGrowthGlobal0X128, actual.FeeGrowthGlobal0X128)
+	assert.Equal(t, expected.FeeGrowthGlobal1X128, actual.FeeGrowthGlobal1X128)
+	assert.Equal(t, expected.FeeProtocol, actual.FeeProtocol)
+	assert.Equal(t, expected.ProtocolFees0, actual.ProtocolFees0)
+	assert.Equal(t, expected.ProtocolFees1, actual.ProtocolFees1)
+	assert.Equal(t, expected.TickDataProvider.(ListableTickDataProvider).Ticks(),
+		actual.TickDataProvider.(ListableTickDataProvider).Ticks())
+
+	amountIn := entities.FromRawAmount(expected.Token0, big.NewInt(1_000_000))
+	expectedResult, err := expected.GetOutputAmount(amountIn, nil)
+	require.NoError(t, err)
+	actualResult, err := actual.GetOutputAmount(amountIn, nil)
+	require.NoError(t, err)
+	assert.Equal(t, expectedResult.ReturnedAmount.Quotient(), actualResult.ReturnedAmount.Quotient())
+}
+
+func TestPositionSerialization(t *testing.T) {
+	position, err := NewPosition(newSerializedPool(t), big.NewInt(1_000_000), -100, 200)
+	require.NoError(t, err)
+	expected0, expected1, err := position.MintAmounts()
+	require.NoError(t, err)
+
+	data, err := json.Marshal(position)
+	require.NoError(t, err)
+	var decoded Position
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, position.TickLower, decoded.TickLower)
+	assert.Equal(t, position.TickUpper, decoded.TickUpper)
+	assert.Equal(t, position.Liquidity, decoded.Liquidity)
+	amount0, amount1, err := decoded.MintAmounts()
+	require.NoError(t, err)
+	assert.Equal(t, expected0, amount0)
+	assert.Equal(t, expected1, amount1)
+
+	data, err = position.MarshalBinary()
+	require.NoError(t, err)
+	decoded = Position{}
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	assert.Equal(t, position.Liquidity, decoded.Liquidity)
+	amount0, amount1, err = decoded.MintAmounts()
+	require.NoError(t, err)
+	assert.Equal(t, expected0, amount0)
+	assert.Equal(t, expected1, amount1)
+
+	// the ticks are validated against the pool
+	data, err = json.Marshal(positionJSON{Pool: position.Pool, TickLower: -101, TickUpper: 200, Liquidity: "1"})
+	require.NoError(t, err)
+	assert.ErrorIs(t, json.Unmarshal(data, &decoded), ErrTickLower)
+}
+
+func TestTradeSerialization(t *testing.T) {
+	route, err := NewRoute([]*Pool{pool_weth_0, pool_0_1}, Ether, token1)
+	require.NoError(t, err)
+	trade, err := FromRoute(route, entities.FromRawAmount(Ether, big.NewInt(10000)), entities.ExactInput)
+	require.NoError(t, err)
+
+	marshalers := []struct {
+		marshal   func(*Trade) ([]byte, error)
+		unmarshal func([]byte, *Trade) error
+	}{
+		{func(t *Trade) ([]byte, error) { return json.Marshal(t) }, func(data []byte, t *Trade) error { return json.Unmarshal(data, t) }},
+		{(*Trade).MarshalBinary, func(data []byte, t *Trade) error { return t.UnmarshalBinary(data) }},
+	}
+	for _, m := range marshalers {
+		data, err := m.marshal(trade)
+		require.NoError(t, err)
+		var decoded Trade
+		require.NoError(t, m.unmarshal(data, &decoded))
+
+		assert.Equal(t, trade.TradeType, decoded.TradeType)
+		assert.True(t, decoded.InputAmount().Currency.IsNative())
+		assert.True(t, trade.InputAmount().EqualTo(decoded.InputAmount().Fraction))
+		assert.True(t, trade.OutputAmount().EqualTo(decoded.OutputAmount().Fraction))
+		assert.True(t, decoded.OutputAmount().Currency.Equal(token1))
+		decodedRoute, err := decoded.Route()
+		require.NoError(t, err)
+		assert.Equal(t, route.TokenPath, decodedRoute.TokenPath)
+		for i, pool := range route.Pools {
+			assertSamePool(t, pool, decodedRoute.Pools[i])
+		}
+		assert.True(t, trade.ExecutionPrice().EqualTo(decoded.ExecutionPrice().Fraction))
+	}
+}
+
+func TestTickJSON(t *testing.T) {
+	tick := Tick{Index: -60, LiquidityGross: uint256.NewInt(10), LiquidityNet: int256.NewInt(-10)}
+	data, err := json.Marshal(tick)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"index":-60,"liquidityGross":"10","liquidityNet":"-10"}`, string(data))
+	var decoded Tick
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, tick, decoded)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"index":0,"liquidityGross":"-1","liquidityNet":"0"}`), &decoded))
+	assert.Error(t, json.Unmarshal([]byte(`{"index":0,"liquidityGross":"1","liquidityNet":"0x1"}`), &decoded))
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"index":0,"liquidityGross":"1"}`), &decoded), ErrInvalidEncoding)
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"index":0,"liquidityNet":"1"}`), &decoded), ErrInvalidEncoding)
+
+	// in a pool
+	pool := newSerializedPool(t)
+	pool.TickDataProvider = &TickListDataProvider{ticks: []Tick{{Index: -100, LiquidityGross: uint256.NewInt(1)}},
+		tickSpacing: pool.TickSpacing()}
+	data, err = json.Marshal(pool)
+	require.NoError(t, err)
+	assert.ErrorIs(t, json.Unmarshal(data, new(Pool)), ErrInvalidEncoding)
+}
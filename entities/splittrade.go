@@ -0,0 +1,185 @@
+package entities
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/daoleno/uniswap-sdk-core/entities"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	ErrNoRoutes              = errors.New("no routes")
+	ErrInvalidParts          = errors.New("invalid number of parts")
+	ErrInsufficientLiquidity = errors.New("insufficient liquidity in routes")
+	DefaultSplitTradeOptions = &SplitTradeOptions{Parts: 20}
+)
+
+type SplitTradeOptions struct {
+	Parts int // the number of equal parts the amount is split into, i.e. the granularity of the split
+}
+
+// splitState holds the simulated state of the pools used by the routes, keyed by pool address, so that pools shared
+// between routes are simulated on their state after the previous swaps
+type splitState struct {
+	addresses map[*Pool]common.Address
+	pools     map[common.Address]*Pool
+}
+
+func newSplitState() *splitState {
+	return &splitState{
+		addresses: make(map[*Pool]common.Address),
+		pools:     make(map[common.Address]*Pool),
+	}
+}
+
+func (s *splitState) address(pool *Pool) (common.Address, error) {
+	if addr, ok := s.addresses[pool]; ok {
+		return addr, nil
+	}
+	addr, err := pool.Address()
+	if err != nil {
+		return common.Address{}, err
+	}
+	s.addresses[pool] = addr
+	return addr, nil
+}
+
+/**
+ * Simulates an exact input swap through the route on the current state of its pools
+ * @param route The route to swap through
+ * @param amountIn The amount of the input currency of the route
+ * @returns The output amount, the number of initialized ticks crossed and the states of the pools of the route after
+ * the swap, by address
+ */
+func (s *splitState) simulate(route *Route, amountIn *entities.CurrencyAmount) (*entities.CurrencyAmount, int,
+	map[common.Address]*Pool, error) {
+	updated := make(map[common.Address]*Pool, len(route.Pools))
+	var crossInitTickLoops int
+	amount := entities.FromFractionalAmount(route.Input.Wrapped(), amountIn.Numerator, amountIn.Denominator)
+	for _, pool := range route.Pools {
+		addr, err := s.address(pool)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		current, ok := updated[addr]
+		if !ok {
+			if current, ok = s.pools[addr]; !ok {
+				current = pool
+			}
+		}
+		result, err := current.GetOutputAmount(amount, nil)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		if result.RemainingAmountIn.Quotient().Sign() != 0 {
+			return nil, 0, nil, ErrInsufficientLiquidity
+		}
+		updated[addr] = result.NewPoolState
+		amount = result.ReturnedAmount
+		crossInitTickLoops += result.CrossInitTickLoops
+	}
+	return entities.FromFractionalAmount(route.Output, amount.Numerator, amount.Denominator), crossInitTickLoops, updated, nil
+}
+
+func (s *splitState) apply(updated map[common.Address]*Pool) {
+	for addr, pool := range updated {
+		s.pools[addr] = pool
+	}
+}
+
+/**
+ * Splits an exact input amount across the given routes, by allocating it part by part to the route that gives the
+ * most output for that part. Routes may share pools, each part is simulated on the state of the pools after the
+ * parts allocated before it.
+ * The returned trade may use a pool in more than one of its swaps, its amounts are simulated by executing the swaps
+ * one after the other in order, the way a multicall of the swaps would.
+ * @param routes The candidate routes, all from the input currency of the amount to the same output currency
+ * @param amountIn The exact amount of input currency to spend
+ * @param opts The split options, if nil DefaultSplitTradeOptions are used
+ * @returns The exact in trade with a swap for each route that is allocated a part of the amount
+ */
+func BestSplitTradeExactIn(routes []*Route, amountIn *entities.CurrencyAmount, opts *SplitTradeOptions) (*Trade, error) {
+	if len(routes) == 0 {
+		return nil, ErrNoRoutes
+	}
+	if opts == nil {
+		opts = DefaultSplitTradeOptions
+	}
+	if opts.Parts <= 0 {
+		return nil, ErrInvalidParts
+	}
+	for _, route := range routes {
+		if !amountIn.Currency.Wrapped().Equal(route.Input.Wrapped()) {
+			return nil, ErrInvalidAmountForRoute
+		}
+	}
+
+	total := amountIn.Quotient()
+	part := new(big.Int).Quo(total, big.NewInt(int64(opts.Parts)))
+	allocations := make([]*big.Int, len(routes))
+	for i := range allocations {
+		allocations[i] = new(big.Int)
+	}
+
+	state := newSplitState()
+	remaining := new(big.Int).Set(total)
+	for remaining.Sign() > 0 {
+		chunk := part
+		// the last part takes the remainder of the division
+		if chunk.Sign() == 0 || remaining.Cmp(new(big.Int).Mul(part, big.NewInt(2))) < 0 {
+			chunk = remaining
+		}
+		chunkAmount := entities.FromRawAmount(amountIn.Currency, chunk)
+
+		best := -1
+		var bestOutput *entities.CurrencyAmount
+		var bestState map[common.Address]*Pool
+		for i, route := range routes {
+			output, _, updated, err := state.simulate(route, chunkAmount)
+			if err != nil {
+				// the route can't fill this part
+				continue
+			}
+			if best < 0 || output.GreaterThan(bestOutput.Fraction) {
+				best, bestOutput, bestState = i, output, updated
+			}
+		}
+		if best < 0 {
+			return nil, ErrInsufficientLiquidity
+		}
+
+		state.apply(bestState)
+		allocations[best].Add(allocations[best], chunk)
+		remaining = new(big.Int).Sub(remaining, chunk)
+	}
+
+	// simulate the swaps of the trade in order on the initial state of the pools
+	state = newSplitState()
+	var swaps []*Swap
+	for i, route := range routes {
+		if allocations[i].Sign() == 0 {
+			continue
+		}
+		input := entities.FromRawAmount(route.Input, allocations[i])
+		output, crossInitTickLoops, updated, err := state.simulate(route, input)
+		if err != nil {
+			return nil, err
+		}
+		state.apply(updated)
+		swaps = append(swaps, &Swap{
+			Route:              route,
+			InputAmount:        input,
+			OutputAmount:       output,
+			CrossInitTickLoops: crossInitTickLoops,
+		})
+	}
+
+	if err := checkRouteCurrencies(swaps); err != nil {
+		return nil, err
+	}
+	return &Trade{
+		Swaps:     swaps,
+		TradeType: entities.ExactInput,
+	}, nil
+}
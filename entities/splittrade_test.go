@@ -0,0 +1,129 @@
+package entities
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/KyberNetwork/int256"
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/constants"
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/utils"
+	"github.com/daoleno/uniswap-sdk-core/entities"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBestSplitTradeExactIn(t *testing.T) {
+	pool_0_1_high := v2StylePool(
+		token0,
+		token1,
+		entities.FromRawAmount(token0, big.NewInt(50000)),
+		entities.FromRawAmount(token1, big.NewInt(50000)),
+		constants.FeeHigh,
+	)
+	route0, err := NewRoute([]*Pool{pool_0_1}, token0, token1)
+	require.NoError(t, err)
+	route1, err := NewRoute([]*Pool{pool_0_1_high}, token0, token1)
+	require.NoError(t, err)
+
+	amountIn := entities.FromRawAmount(token0, big.NewInt(10000))
+	trade, err := BestSplitTradeExactIn([]*Route{route0, route1}, amountIn, nil)
+	require.NoError(t, err)
+	require.Len(t, trade.Swaps, 2)
+	assert.Equal(t, entities.ExactInput, trade.TradeType)
+	assert.True(t, trade.InputAmount().EqualTo(amountIn.Fraction))
+	// the cheaper and deeper pool takes the larger part
+	assert.True(t, trade.Swaps[0].InputAmount.GreaterThan(trade.Swaps[1].InputAmount.Fraction))
+
+	for _, route := range []*Route{route0, route1} {
+		single, err := ExactIn(route, amountIn)
+		require.NoError(t, err)
+		assert.True(t, trade.OutputAmount().GreaterThan(single.OutputAmount().Fraction), "splitting beats a single route")
+	}
+
+	// a single part uses only the best route
+	trade, err = BestSplitTradeExactIn([]*Route{route0, route1}, amountIn, &SplitTradeOptions{Parts: 1})
+	require.NoError(t, err)
+	require.Len(t, trade.Swaps, 1)
+	assert.Equal(t, route0, trade.Swaps[0].Route)
+
+	// the swaps count the initialized ticks they cross, as the trades of FromRoutes do
+	ticks, err := NewTickListDataProvider([]Tick{
+		{Index: NearestUsableTick(utils.MinTick, 60), LiquidityNet: int256.NewInt(50000), LiquidityGross: uint256.NewInt(50000)},
+		{Index: -60, LiquidityNet: int256.NewInt(50000), LiquidityGross: uint256.NewInt(50000)},
+		{Index: 60, LiquidityNet: int256.NewInt(-50000), LiquidityGross: uint256.NewInt(50000)},
+		{Index: NearestUsableTick(utils.MaxTick, 60), LiquidityNet: int256.NewInt(-50000), LiquidityGross: uint256.NewInt(50000)},
+	}, 60)
+	require.NoError(t, err)
+	pool_0_1_ticks, err := NewPoolV2(token0, token1, constants.FeeMedium, uint256.MustFromBig(utils.EncodeSqrtRatioX96(constants.One, constants.One)),
+		uint256.NewInt(100000), 0, ticks)
+	require.NoError(t, err)
+	route2, err := NewRoute([]*Pool{pool_0_1_ticks}, token0, token1)
+	require.NoError(t, err)
+	trade, err = BestSplitTradeExactIn([]*Route{route1, route2}, amountIn, nil)
+	require.NoError(t, err)
+	require.Len(t, trade.Swaps, 2)
+	for _, swap := range trade.Swaps {
+		independent, err := ExactIn(swap.Route, swap.InputAmount)
+		require.NoError(t, err)
+		assert.Equal(t, independent.Swaps[0].CrossInitTickLoops, swap.CrossInitTickLoops)
+	}
+	assert.Equal(t, 1, trade.Swaps[1].CrossInitTickLoops)
+
+	_, err = BestSplitTradeExactIn(nil, amountIn, nil)
+	assert.ErrorIs(t, err, ErrNoRoutes)
+	_, err = BestSplitTradeExactIn([]*Route{route0}, amountIn, &SplitTradeOptions{})
+	assert.ErrorIs(t, err, ErrInvalidParts)
+	_, err = BestSplitTradeExactIn([]*Route{route0}, entities.FromRawAmount(token1, big.NewInt(10000)), nil)
+	assert.ErrorIs(t, err, ErrInvalidAmountForRoute)
+}
+
+func TestBestSplitTradeExactInSharedPools(t *testing.T) {
+	// both routes end with pool_1_2
+	route0, err := NewRoute([]*Pool{pool_0_1, pool_1_2}, token0, token2)
+	require.NoError(t, err)
+	route1, err := NewRoute([]*Pool{pool_0_3, pool_1_3, pool_1_2}, token0, token2)
+	require.NoError(t, err)
+
+	amountIn := entities.FromRawAmount(token0, big.NewInt(20000))
+	trade, err := BestSplitTradeExactIn([]*Route{route0, route1}, amountIn, &SplitTradeOptions{Parts: 10})
+	require.NoError(t, err, "pools may be shared between the swaps")
+	require.Len(t, trade.Swaps, 2)
+	assert.True(t, trade.InputAmount().EqualTo(amountIn.Fraction))
+
+	for _, route := range []*Route{route0, route1} {
+		single, err := ExactIn(route, amountIn)
+		require.NoError(t, err)
+		assert.True(t, trade.OutputAmount().GreaterThan(single.OutputAmount().Fraction))
+	}
+
+	// the swaps are simulated one after the other, so the second swap gets less output from the shared pool
+	independent, err := ExactIn(route1, trade.Swaps[1].InputAmount)
+	require.NoError(t, err)
+	assert.True(t, trade.Swaps[1].OutputAmount.LessThan(independent.OutputAmount().Fraction))
+}
+
+func TestBestSplitTradeExactInDeployments(t *testing.T) {
+	// the same tokens and fee as pool_0_1, on another deployment
+	fork := *pool_0_1
+	fork.Deployment = &constants.Deployment{
+		ChainID:          1,
+		Protocol:         "fork",
+		FactoryAddress:   common.HexToAddress("0x1111111111111111111111111111111111111111"),
+		PoolInitCodeHash: "0x2222222222222222222222222222222222222222222222222222222222222222",
+	}
+	route0, err := NewRoute([]*Pool{pool_0_1}, token0, token1)
+	require.NoError(t, err)
+	route1, err := NewRoute([]*Pool{&fork}, token0, token1)
+	require.NoError(t, err)
+
+	trade, err := BestSplitTradeExactIn([]*Route{route0, route1}, entities.FromRawAmount(token0, big.NewInt(10000)), nil)
+	require.NoError(t, err)
+	require.Len(t, trade.Swaps, 2)
+	for _, swap := range trade.Swaps {
+		independent, err := ExactIn(swap.Route, swap.InputAmount)
+		require.NoError(t, err)
+		assert.True(t, swap.OutputAmount.EqualTo(independent.OutputAmount().Fraction), "the pools don't share their state")
+	}
+}
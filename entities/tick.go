@@ -0,0 +1,197 @@
+package entities
+
+import (
+	"errors"
+
+	"github.com/holiman/uint256"
+
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/utils"
+)
+
+// TickCrossOracleValues holds the oracle accumulators that the core contract reads once per swap or mint (at the
+// current tick and liquidity), and that are written into the outside values of every tick crossed by that swap, or
+// initialized at or below the current tick by that mint.
+type TickCrossOracleValues struct {
+	SecondsPerLiquidityCumulativeX128 *utils.Uint160
+	TickCumulative                    int64
+	BlockTimestamp                    uint32
+}
+
+/**
+ * Returns the updated copy of a tick after its liquidity is changed, see Tick.update in the core contract
+ * @param ticks The tick data of the pool
+ * @param tick The tick that will be updated
+ * @param tickCurrent The current tick of the pool
+ * @param liquidityDelta A new amount of liquidity to be added (subtracted) when tick is crossed from left to right (right to left)
+ * @param feeGrowthGlobal0X128 The all-time global fee growth, per unit of liquidity, in token0
+ * @param feeGrowthGlobal1X128 The all-time global fee growth, per unit of liquidity, in token1
+ * @param upper true for updating a position's upper tick, or false for updating a position's lower tick
+ * @param maxLiquidity The maximum liquidity allocation for a single tick
+ * @param oracle The oracle accumulators at the time of the update, the oracle outside values are left zero if nil
+ */
+func updateTick(ticks TickDataProvider, tick, tickCurrent int, liquidityDelta *utils.Int128,
+	feeGrowthGlobal0X128, feeGrowthGlobal1X128 *utils.Uint256, upper bool, maxLiquidity *utils.Uint128,
+	oracle *TickCrossOracleValues) (Tick, error) {
+	info, err := getTickOrEmpty(ticks, tick)
+	if err != nil {
+		return EmptyTick, err
+	}
+
+	liquidityGrossAfter := new(utils.Uint128).Set(info.LiquidityGross)
+	if err := utils.AddDeltaInPlace(liquidityGrossAfter, liquidityDelta); err != nil {
+		return EmptyTick, err
+	}
+	if liquidityGrossAfter.Cmp(maxLiquidity) > 0 {
+		return EmptyTick, ErrLiquidityOverflow
+	}
+
+	if info.LiquidityGross.IsZero() {
+		// by convention, we assume that all growth before a tick was initialized happened _below_ the tick
+		if tick <= tickCurrent {
+			info.FeeGrowthOutside0X128 = copyOrZero(feeGrowthGlobal0X128)
+			info.FeeGrowthOutside1X128 = copyOrZero(feeGrowthGlobal1X128)
+			if oracle != nil {
+				info.SecondsPerLiquidityOutsideX128 = copyOrZero(oracle.SecondsPerLiquidityCumulativeX128)
+				info.TickCumulativeOutside = oracle.TickCumulative
+				info.SecondsOutside = oracle.BlockTimestamp
+			}
+		} else {
+			info.FeeGrowthOutside0X128 = new(utils.Uint256)
+			info.FeeGrowthOutside1X128 = new(utils.Uint256)
+		}
+	}
+
+	// when the lower (upper) tick is crossed left to right (right to left), liquidity must be added (removed)
+	liquidityNetAfter := new(utils.Int128)
+	if upper {
+		liquidityNetAfter.Sub(info.LiquidityNet, liquidityDelta)
+	} else {
+		liquidityNetAfter.Add(info.LiquidityNet, liquidityDelta)
+	}
+
+	info.LiquidityGross = liquidityGrossAfter
+	info.LiquidityNet = liquidityNetAfter
+	return info, nil
+}
+
+/**
+ * Returns the copy of a tick after it's crossed by a swap, see Tick.cross in the core contract
+ * @param feeGrowthGlobal0X128 The all-time global fee growth, per unit of liquidity, in token0
+ * @param feeGrowthGlobal1X128 The all-time global fee growth, per unit of liquidity, in token1
+ * @param oracle The oracle accumulators at the time of the swap, the oracle outside values are left untouched if nil
+ */
+func (t Tick) cross(feeGrowthGlobal0X128, feeGrowthGlobal1X128 *utils.Uint256, oracle *TickCrossOracleValues) Tick {
+	t.FeeGrowthOutside0X128 = new(utils.Uint256).Sub(feeGrowthGlobal0X128, valueOrZero(t.FeeGrowthOutside0X128))
+	t.FeeGrowthOutside1X128 = new(utils.Uint256).Sub(feeGrowthGlobal1X128, valueOrZero(t.FeeGrowthOutside1X128))
+	if oracle != nil {
+		t.SecondsPerLiquidityOutsideX128 = new(utils.Uint160).Sub(
+			valueOrZero(oracle.SecondsPerLiquidityCumulativeX128), valueOrZero(t.SecondsPerLiquidityOutsideX128))
+		// the accumulator is a uint160 in the contract
+		t.SecondsPerLiquidityOutsideX128.And(t.SecondsPerLiquidityOutsideX128, utils.Uint160Max)
+		t.TickCumulativeOutside = oracle.TickCumulative - t.TickCumulativeOutside
+		t.SecondsOutside = oracle.BlockTimestamp - t.SecondsOutside
+	}
+	return t
+}
+
+/**
+ * Retrieves the all-time fee growth data inside a tick range, see Tick.getFeeGrowthInside in the core contract.
+ * As in the contract, the subtractions wrap around, only the differences between two results are meaningful.
+ * @param ticks The tick data of the pool
+ * @param tickLower The lower tick boundary of the position
+ * @param tickUpper The upper tick boundary of the position
+ * @param tickCurrent The current tick of the pool
+ * @param feeGrowthGlobal0X128 The all-time global fee growth, per unit of liquidity, in token0
+ * @param feeGrowthGlobal1X128 The all-time global fee growth, per unit of liquidity, in token1
+ * @returns feeGrowthInside0X128 The all-time fee growth in token0, per unit of liquidity, inside the position's tick boundaries
+ * @returns feeGrowthInside1X128 The all-time fee growth in token1, per unit of liquidity, inside the position's tick boundaries
+ */
+func GetFeeGrowthInside(ticks TickDataProvider, tickLower, tickUpper, tickCurrent int,
+	feeGrowthGlobal0X128, feeGrowthGlobal1X128 *utils.Uint256) (feeGrowthInside0X128, feeGrowthInside1X128 *utils.Uint256, err error) {
+	lower, err := getTickOrEmpty(ticks, tickLower)
+	if err != nil {
+		return nil, nil, err
+	}
+	upper, err := getTickOrEmpty(ticks, tickUpper)
+	if err != nil {
+		return nil, nil, err
+	}
+	feeGrowthGlobal0X128 = valueOrZero(feeGrowthGlobal0X128)
+	feeGrowthGlobal1X128 = valueOrZero(feeGrowthGlobal1X128)
+
+	// calculate fee growth below
+	var feeGrowthBelow0X128, feeGrowthBelow1X128 utils.Uint256
+	if tickCurrent >= tickLower {
+		feeGrowthBelow0X128.Set(valueOrZero(lower.FeeGrowthOutside0X128))
+		feeGrowthBelow1X128.Set(valueOrZero(lower.FeeGrowthOutside1X128))
+	} else {
+		feeGrowthBelow0X128.Sub(feeGrowthGlobal0X128, valueOrZero(lower.FeeGrowthOutside0X128))
+		feeGrowthBelow1X128.Sub(feeGrowthGlobal1X128, valueOrZero(lower.FeeGrowthOutside1X128))
+	}
+
+	// calculate fee growth above
+	var feeGrowthAbove0X128, feeGrowthAbove1X128 utils.Uint256
+	if tickCurrent < tickUpper {
+		feeGrowthAbove0X128.Set(valueOrZero(upper.FeeGrowthOutside0X128))
+		feeGrowthAbove1X128.Set(valueOrZero(upper.FeeGrowthOutside1X128))
+	} else {
+		feeGrowthAbove0X128.Sub(feeGrowthGlobal0X128, valueOrZero(upper.FeeGrowthOutside0X128))
+		feeGrowthAbove1X128.Sub(feeGrowthGlobal1X128, valueOrZero(upper.FeeGrowthOutside1X128))
+	}
+
+	feeGrowthInside0X128 = new(utils.Uint256).Sub(feeGrowthGlobal0X128, &feeGrowthBelow0X128)
+	feeGrowthInside0X128.Sub(feeGrowthInside0X128, &feeGrowthAbove0X128)
+	feeGrowthInside1X128 = new(utils.Uint256).Sub(feeGrowthGlobal1X128, &feeGrowthBelow1X128)
+	feeGrowthInside1X128.Sub(feeGrowthInside1X128, &feeGrowthAbove1X128)
+	return feeGrowthInside0X128, feeGrowthInside1X128, nil
+}
+
+// getTickOrEmpty returns the tick at the given index, or an uninitialized tick if there is none
+func getTickOrEmpty(ticks TickDataProvider, tick int) (Tick, error) {
+	// GetTick of a tick list returns the closest tick below, and fails above the largest tick
+	if list, ok := ticks.(*TickListDataProvider); ok {
+		if t, found := list.findTick(tick); found && t.LiquidityGross != nil && t.LiquidityNet != nil {
+			return t, nil
+		}
+		return Tick{
+			Index:          tick,
+			LiquidityGross: new(utils.Uint128),
+			LiquidityNet:   new(utils.Int128),
+		}, nil
+	}
+	t, err := ticks.GetTick(tick)
+	if err != nil && !errors.Is(err, ErrEmptyTickList) && !errors.Is(err, ErrBelowSmallest) {
+		return EmptyTick, err
+	}
+	if err != nil || t.Index != tick || t.LiquidityGross == nil || t.LiquidityNet == nil {
+		return Tick{
+			Index:          tick,
+			LiquidityGross: new(utils.Uint128),
+			LiquidityNet:   new(utils.Int128),
+		}, nil
+	}
+	return t, nil
+}
+
+// maxLiquidityPerTick ports Tick.tickSpacingToMaxLiquidityPerTick
+func maxLiquidityPerTick(tickSpacing int) *utils.Uint128 {
+	minTick := (utils.MinTick / tickSpacing) * tickSpacing
+	maxTick := (utils.MaxTick / tickSpacing) * tickSpacing
+	numTicks := uint64((maxTick-minTick)/tickSpacing) + 1
+	return new(utils.Uint128).Div(utils.Uint128Max, uint256.NewInt(numTicks))
+}
+
+var zeroUint256 = new(uint256.Int)
+
+// valueOrZero treats a nil value as zero, the result must not be modified
+func valueOrZero(x *uint256.Int) *uint256.Int {
+	if x == nil {
+		return zeroUint256
+	}
+	return x
+}
+
+// copyOrZero returns a copy of x, treating a nil value as zero
+func copyOrZero(x *uint256.Int) *uint256.Int {
+	return new(uint256.Int).Set(valueOrZero(x))
+}
@@ -0,0 +1,231 @@
+package entities
+
+import (
+	"sort"
+
+	"github.com/holiman/uint256"
+
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/utils"
+)
+
+// A mutable data provider for ticks that is backed by a word-indexed bitmap, the same way as TickBitmap.sol.
+// Each word holds the initialized state of 256 consecutive compressed ticks (tick / tickSpacing).
+type TickBitmapDataProvider struct {
+	tickSpacing int
+	bitmap      map[int16]*uint256.Int
+	ticks       map[int]Tick
+
+	// bounds of the words that have ever been set, used to stop searching for the next initialized tick early
+	minWord, maxWord int16
+}
+
+/**
+ * Constructs a bitmap backed tick data provider
+ * @param ticks The initial ticks, may be empty
+ * @param tickSpacing The tick spacing of the pool
+ */
+func NewTickBitmapDataProvider(ticks []Tick, tickSpacing int) (*TickBitmapDataProvider, error) {
+	if err := ValidateList(ticks, tickSpacing); err != nil {
+		return nil, err
+	}
+	p := &TickBitmapDataProvider{
+		tickSpacing: tickSpacing,
+		bitmap:      make(map[int16]*uint256.Int),
+		ticks:       make(map[int]Tick, len(ticks)),
+	}
+	for _, t := range ticks {
+		if err := p.SetTick(t); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// TickSpacing returns the tick spacing of the provider
+func (p *TickBitmapDataProvider) TickSpacing() int {
+	return p.tickSpacing
+}
+
+// Ticks returns a copy of all the initialized ticks, sorted by index
+func (p *TickBitmapDataProvider) Ticks() []Tick {
+	ticks := make([]Tick, 0, len(p.ticks))
+	for _, t := range p.ticks {
+		ticks = append(ticks, t)
+	}
+	sort.Slice(ticks, func(i, j int) bool {
+		return ticks[i].Index < ticks[j].Index
+	})
+	return ticks
+}
+
+// GetTick returns the tick at the given index, or an uninitialized tick if there is none (as the contract mapping would)
+func (p *TickBitmapDataProvider) GetTick(tick int) (Tick, error) {
+	if t, ok := p.ticks[tick]; ok {
+		return t, nil
+	}
+	return Tick{
+		Index:          tick,
+		LiquidityGross: new(uint256.Int),
+		LiquidityNet:   new(utils.Int128),
+	}, nil
+}
+
+/**
+ * Returns the next initialized tick contained in the same word (or adjacent word) as the tick that is either
+ * to the left (less than or equal to) or right (greater than) of the given tick, see TickBitmap.nextInitializedTickWithinOneWord
+ * @param tick The starting tick
+ * @param lte Whether to search for the next initialized tick to the left (less than or equal to the starting tick)
+ * @param tickSpacing The spacing between usable ticks, must match the spacing of the provider
+ */
+func (p *TickBitmapDataProvider) NextInitializedTickWithinOneWord(tick int, lte bool, tickSpacing int) (int, bool, error) {
+	if tickSpacing != p.tickSpacing {
+		return ZeroValueTickIndex, ZeroValueTickInitialized, ErrInvalidTickSpacing
+	}
+
+	compressed := tick / tickSpacing
+	if tick < 0 && tick%tickSpacing != 0 {
+		compressed-- // round towards negative infinity
+	}
+
+	var mask, masked uint256.Int
+	if lte {
+		wordPos, bitPos := bitmapPosition(compressed)
+		// all the 1s at or to the right of the current bitPos
+		mask.Lsh(uint256.NewInt(1), bitPos)
+		mask.Add(&mask, &mask).SubUint64(&mask, 1)
+		p.maskWord(wordPos, &mask, &masked)
+
+		// if there are no initialized ticks to the right of or at the current tick, return rightmost in the word
+		if masked.IsZero() {
+			return (compressed - int(bitPos)) * tickSpacing, false, nil
+		}
+		// overflow/underflow is possible, but prevented externally by limiting both tickSpacing and tick
+		msb, err := utils.MostSignificantBit(&masked)
+		if err != nil {
+			return ZeroValueTickIndex, ZeroValueTickInitialized, err
+		}
+		return (compressed - int(bitPos-msb)) * tickSpacing, true, nil
+	}
+
+	// start from the word of the next tick, since the current tick state doesn't matter
+	wordPos, bitPos := bitmapPosition(compressed + 1)
+	// all the 1s at or to the left of the bitPos
+	mask.Lsh(uint256.NewInt(1), bitPos)
+	mask.SubUint64(&mask, 1).Not(&mask)
+	p.maskWord(wordPos, &mask, &masked)
+
+	// if there are no initialized ticks to the left of the current tick, return leftmost in the word
+	if masked.IsZero() {
+		return (compressed + 1 + int(255-bitPos)) * tickSpacing, false, nil
+	}
+	lsb, err := utils.LeastSignificantBit(&masked)
+	if err != nil {
+		return ZeroValueTickIndex, ZeroValueTickInitialized, err
+	}
+	return (compressed + 1 + int(lsb-bitPos)) * tickSpacing, true, nil
+}
+
+// NextInitializedTickIndex walks the bitmap word by word until it finds an initialized tick, or returns the
+// min/max tick (uninitialized) if there is none in that direction
+func (p *TickBitmapDataProvider) NextInitializedTickIndex(tick int, lte bool) (int, bool, error) {
+	for {
+		next, initialized, err := p.NextInitializedTickWithinOneWord(tick, lte, p.tickSpacing)
+		if err != nil {
+			return ZeroValueTickIndex, ZeroValueTickInitialized, err
+		}
+		if initialized {
+			return next, true, nil
+		}
+
+		wordPos, _ := bitmapPosition(next / p.tickSpacing)
+		if lte {
+			if next <= utils.MinTick || len(p.bitmap) == 0 || wordPos <= p.minWord {
+				return utils.MinTick, false, nil
+			}
+			tick = next - 1
+		} else {
+			if next >= utils.MaxTick || len(p.bitmap) == 0 || wordPos >= p.maxWord {
+				return utils.MaxTick, false, nil
+			}
+			tick = next
+		}
+	}
+}
+
+// SetTick inserts or replaces a tick, flipping its bit in the bitmap when its initialized state changes.
+// A tick without gross liquidity is removed.
+func (p *TickBitmapDataProvider) SetTick(tick Tick) error {
+	if tick.LiquidityGross == nil || tick.LiquidityGross.IsZero() {
+		return p.RemoveTick(tick.Index)
+	}
+	if tick.Index%p.tickSpacing != 0 {
+		return ErrInvalidTickSpacing
+	}
+	if _, ok := p.ticks[tick.Index]; !ok {
+		p.flipTick(tick.Index)
+	}
+	p.ticks[tick.Index] = tick
+	return nil
+}
+
+// RemoveTick removes the tick at the given index and clears its bit in the bitmap
+func (p *TickBitmapDataProvider) RemoveTick(tick int) error {
+	if _, ok := p.ticks[tick]; !ok {
+		return nil
+	}
+	delete(p.ticks, tick)
+	p.flipTick(tick)
+	return nil
+}
+
+// flipTick flips the initialized state for a given tick from false to true, or vice versa, see TickBitmap.flipTick
+func (p *TickBitmapDataProvider) flipTick(tick int) {
+	wordPos, bitPos := bitmapPosition(tick / p.tickSpacing)
+	word, ok := p.bitmap[wordPos]
+	if !ok {
+		if len(p.bitmap) == 0 || wordPos < p.minWord {
+			p.minWord = wordPos
+		}
+		if len(p.bitmap) == 0 || wordPos > p.maxWord {
+			p.maxWord = wordPos
+		}
+		word = new(uint256.Int)
+		p.bitmap[wordPos] = word
+	}
+
+	var mask uint256.Int
+	mask.Lsh(uint256.NewInt(1), bitPos)
+	word.Xor(word, &mask)
+	if word.IsZero() {
+		delete(p.bitmap, wordPos)
+	}
+}
+
+// maskWord writes word & mask into result, treating missing words as zero
+func (p *TickBitmapDataProvider) maskWord(wordPos int16, mask, result *uint256.Int) {
+	if word, ok := p.bitmap[wordPos]; ok {
+		result.And(word, mask)
+		return
+	}
+	result.Clear()
+}
+
+/**
+ * Computes the position in the mapping where the initialized bit for a tick lives
+ * @param tick The compressed tick (tick / tickSpacing) for which to compute the position
+ * @returns wordPos The key in the mapping containing the word in which the bit is stored
+ * @returns bitPos The bit position in the word where the flag is stored
+ */
+func bitmapPosition(tick int) (wordPos int16, bitPos uint) {
+	return int16(tick >> 8), uint(uint8(tick))
+}
+
+// nextSwapTick returns the tick that the next step of a swap moves towards. Providers backed by a tick bitmap are
+// stepped word by word, as in the contract, so that the swaps round at the same steps as on chain. Other providers,
+// e.g. tick lists, which can't tell where the words of the pool bitmap end, step to the next initialized tick.
+func nextSwapTick(ticks TickDataProvider, tick int, lte bool) (int, bool, error) {
+	if bitmap, ok := ticks.(*TickBitmapDataProvider); ok {
+		return bitmap.NextInitializedTickWithinOneWord(tick, lte, bitmap.tickSpacing)
+	}
+	return ticks.NextInitializedTickIndex(tick, lte)
+}
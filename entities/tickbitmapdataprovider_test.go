@@ -0,0 +1,180 @@
+package entities
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/KyberNetwork/int256"
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/constants"
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/utils"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTickBitmapNextInitializedTickWithinOneWord(t *testing.T) {
+	p, err := NewTickBitmapDataProvider([]Tick{lowTick, midTick, highTick}, 1)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		tick  int
+		lte   bool
+		want0 int
+		want1 bool
+	}{
+		// words around 0, lte = true
+		{name: "lte = true  0", tick: -257, lte: true, want0: -512, want1: false},
+		{name: "lte = true  1", tick: -256, lte: true, want0: -256, want1: false},
+		{name: "lte = true  2", tick: -1, lte: true, want0: -256, want1: false},
+		{name: "lte = true  3", tick: 0, lte: true, want0: 0, want1: true},
+		{name: "lte = true  4", tick: 1, lte: true, want0: 0, want1: true},
+		{name: "lte = true  5", tick: 255, lte: true, want0: 0, want1: true},
+		{name: "lte = true  6", tick: 256, lte: true, want0: 256, want1: false},
+		{name: "lte = true  7", tick: 257, lte: true, want0: 256, want1: false},
+
+		// words around 0, lte = false
+		{name: "lte = false 0", tick: -258, lte: false, want0: -257, want1: false},
+		{name: "lte = false 1", tick: -257, lte: false, want0: -1, want1: false},
+		{name: "lte = false 2", tick: -256, lte: false, want0: -1, want1: false},
+		{name: "lte = false 3", tick: -2, lte: false, want0: -1, want1: false},
+		{name: "lte = false 4", tick: -1, lte: false, want0: 0, want1: true},
+		{name: "lte = false 5", tick: 0, lte: false, want0: 255, want1: false},
+		{name: "lte = false 6", tick: 1, lte: false, want0: 255, want1: false},
+		{name: "lte = false 7", tick: 254, lte: false, want0: 255, want1: false},
+		{name: "lte = false 8", tick: 255, lte: false, want0: 511, want1: false},
+		{name: "lte = false 9", tick: 256, lte: false, want0: 511, want1: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got0, got1, err := p.NextInitializedTickWithinOneWord(tt.tick, tt.lte, 1)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want0, got0)
+			assert.Equal(t, tt.want1, got1)
+		})
+	}
+
+	_, _, err = p.NextInitializedTickWithinOneWord(0, true, 10)
+	assert.ErrorIs(t, err, ErrInvalidTickSpacing)
+}
+
+func TestTickBitmapNextInitializedTickIndex(t *testing.T) {
+	p, err := NewTickBitmapDataProvider([]Tick{lowTick, midTick, highTick}, 1)
+	require.NoError(t, err)
+
+	next, initialized, err := p.NextInitializedTickIndex(-1, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, next)
+	assert.True(t, initialized)
+
+	next, initialized, _ = p.NextInitializedTickIndex(0, false)
+	assert.Equal(t, highTick.Index, next)
+	assert.True(t, initialized)
+
+	next, initialized, _ = p.NextInitializedTickIndex(-1, true)
+	assert.Equal(t, lowTick.Index, next)
+	assert.True(t, initialized)
+
+	next, initialized, _ = p.NextInitializedTickIndex(lowTick.Index-1, true)
+	assert.Equal(t, utils.MinTick, next, "stops at the min tick")
+	assert.False(t, initialized)
+
+	next, initialized, _ = p.NextInitializedTickIndex(highTick.Index, false)
+	assert.Equal(t, utils.MaxTick, next, "stops at the max tick")
+	assert.False(t, initialized)
+}
+
+func TestTickBitmapSetRemoveTick(t *testing.T) {
+	p, err := NewTickBitmapDataProvider(nil, 10)
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, p.SetTick(Tick{Index: 5, LiquidityGross: uint256.NewInt(1), LiquidityNet: int256.NewInt(1)}), ErrInvalidTickSpacing)
+
+	assert.NoError(t, p.SetTick(Tick{Index: -2560, LiquidityGross: uint256.NewInt(1), LiquidityNet: int256.NewInt(1)}))
+	assert.NoError(t, p.SetTick(Tick{Index: 2560, LiquidityGross: uint256.NewInt(1), LiquidityNet: int256.NewInt(-1)}))
+
+	next, initialized, _ := p.NextInitializedTickIndex(0, true)
+	assert.Equal(t, -2560, next)
+	assert.True(t, initialized)
+
+	assert.NoError(t, p.RemoveTick(-2560))
+	next, initialized, _ = p.NextInitializedTickIndex(0, true)
+	assert.Equal(t, utils.MinTick, next)
+	assert.False(t, initialized)
+
+	tick, err := p.GetTick(-2560)
+	assert.NoError(t, err)
+	assert.True(t, tick.LiquidityGross.IsZero(), "removed ticks are uninitialized")
+
+	// setting a tick without liquidity removes it
+	assert.NoError(t, p.SetTick(Tick{Index: 2560, LiquidityGross: uint256.NewInt(0), LiquidityNet: int256.NewInt(0)}))
+	next, initialized, _ = p.NextInitializedTickIndex(0, false)
+	assert.Equal(t, utils.MaxTick, next)
+	assert.False(t, initialized)
+}
+
+func TestTickBitmapSwap(t *testing.T) {
+	ticks := []Tick{
+		{Index: NearestUsableTick(utils.MinTick, 10), LiquidityNet: OneEtherI256, LiquidityGross: OneEtherUI256},
+		{Index: -1000, LiquidityNet: OneEtherI256, LiquidityGross: OneEtherUI256},
+		{Index: 3000, LiquidityNet: new(int256.Int).Neg(OneEtherI256), LiquidityGross: OneEtherUI256},
+		{Index: NearestUsableTick(utils.MaxTick, 10), LiquidityNet: new(int256.Int).Neg(OneEtherI256), LiquidityGross: OneEtherUI256},
+	}
+	listProvider, err := NewTickListDataProvider(ticks, 10)
+	require.NoError(t, err)
+	bitmapProvider, err := NewTickBitmapDataProvider(ticks, 10)
+	require.NoError(t, err)
+
+	sqrtRatioX96 := uint256.MustFromBig(utils.EncodeSqrtRatioX96(constants.One, constants.One))
+	listPool, err := NewPoolV2(USDC, DAI, constants.FeeLow, sqrtRatioX96, uint256.NewInt(2e18), 0, listProvider)
+	require.NoError(t, err)
+	bitmapPool, err := NewPoolV2(USDC, DAI, constants.FeeLow, sqrtRatioX96, uint256.NewInt(2e18), 0, bitmapProvider)
+	require.NoError(t, err)
+
+	// the bitmap pool stops at the end of every word, so only the rounding differs from the tick list pool
+	for _, zeroForOne := range []bool{true, false} {
+		amountIn := int256.MustFromDec("500000000000000000000")
+		expected, err := listPool.GetOutputAmountV2(amountIn, zeroForOne, nil)
+		require.NoError(t, err)
+		actual, err := bitmapPool.GetOutputAmountV2(amountIn, zeroForOne, nil)
+		require.NoError(t, err)
+		assert.Equal(t, expected.CurrentTick, actual.CurrentTick)
+		assert.Equal(t, expected.Liquidity, actual.Liquidity)
+		assert.Equal(t, 1, actual.CrossInitTickLoops)
+		assert.True(t, actual.ReturnedAmount.Cmp(expected.ReturnedAmount) <= 0, "each step rounds in favor of the pool")
+	}
+
+	// a full range pool quotes the same amount as the contract, which stops at every word of the range
+	spacing := constants.TickSpacings[constants.FeeMedium]
+	fullRange := []Tick{
+		{Index: NearestUsableTick(utils.MinTick, spacing), LiquidityNet: int256.NewInt(104880), LiquidityGross: uint256.NewInt(104880)},
+		{Index: NearestUsableTick(utils.MaxTick, spacing), LiquidityNet: int256.NewInt(-104880), LiquidityGross: uint256.NewInt(104880)},
+	}
+	fullRangeProvider, err := NewTickBitmapDataProvider(fullRange, spacing)
+	require.NoError(t, err)
+	fullRangeListProvider, err := NewTickListDataProvider(fullRange, spacing)
+	require.NoError(t, err)
+	sqrtRatioX96 = uint256.MustFromBig(utils.EncodeSqrtRatioX96(big.NewInt(110000), big.NewInt(100000)))
+	tick, err := utils.GetTickAtSqrtRatioV2(sqrtRatioX96)
+	require.NoError(t, err)
+	fullRangePool, err := NewPoolV2(DAI, USDC, constants.FeeMedium, sqrtRatioX96, uint256.NewInt(104880), tick, fullRangeProvider)
+	require.NoError(t, err)
+	fullRangeListPool, err := NewPoolV2(DAI, USDC, constants.FeeMedium, sqrtRatioX96, uint256.NewInt(104880), tick, fullRangeListProvider)
+	require.NoError(t, err)
+	output, err := fullRangePool.GetOutputAmountV2(int256.NewInt(10000), true, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "9971", output.ReturnedAmount.Dec())
+	output, err = fullRangeListPool.GetOutputAmountV2(int256.NewInt(10000), true, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "9972", output.ReturnedAmount.Dec(), "the tick list pool steps over the whole range at once")
+
+	// ticks can be updated incrementally through the pool
+	require.NoError(t, bitmapPool.Mint(-1000, 3000, OneEtherUI256, nil))
+	lower, _ := bitmapProvider.GetTick(-1000)
+	assert.Equal(t, uint256.NewInt(2e18), lower.LiquidityGross)
+	require.NoError(t, bitmapPool.Burn(-1000, 3000, uint256.NewInt(2e18)))
+	next, initialized, _ := bitmapProvider.NextInitializedTickIndex(0, true)
+	assert.Equal(t, NearestUsableTick(utils.MinTick, 10), next)
+	assert.True(t, initialized)
+}
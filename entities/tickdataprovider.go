@@ -9,6 +9,14 @@ type Tick struct {
 	Index          int
 	LiquidityGross *uint256.Int
 	LiquidityNet   *utils.Int128
+
+	// The values below are only needed for fee and oracle computations, a nil value is treated as zero.
+	// They are relative to the current tick, i.e. they only have relative meaning, not absolute.
+	FeeGrowthOutside0X128          *utils.Uint256 // fee growth per unit of liquidity of token0 on the other side of this tick
+	FeeGrowthOutside1X128          *utils.Uint256 // fee growth per unit of liquidity of token1 on the other side of this tick
+	TickCumulativeOutside          int64          // the cumulative tick value on the other side of the tick
+	SecondsPerLiquidityOutsideX128 *utils.Uint160 // the seconds per unit of liquidity on the other side of this tick
+	SecondsOutside                 uint32         // the seconds spent on the other side of the tick
 }
 
 // Provides information about ticks
@@ -30,3 +38,23 @@ type TickDataProvider interface {
 	// NextInitializedTickIndex return the next tick that is initialized
 	NextInitializedTickIndex(tick int, lte bool) (int, bool, error)
 }
+
+// MutableTickDataProvider is a TickDataProvider whose ticks can be modified in place, which is required to apply
+// Mint and Burn events to a Pool
+type MutableTickDataProvider interface {
+	TickDataProvider
+
+	// SetTick inserts the tick, or replaces the existing tick with the same index
+	SetTick(tick Tick) error
+
+	// RemoveTick removes the tick with the given index, it's a no-op if the tick does not exist
+	RemoveTick(tick int) error
+}
+
+// ListableTickDataProvider is a tick data provider which can list all its ticks, e.g. to serialize them
+type ListableTickDataProvider interface {
+	TickDataProvider
+
+	// Ticks returns a copy of all the initialized ticks, sorted by index
+	Ticks() []Tick
+}
@@ -1,21 +1,44 @@
 package entities
 
+import "sort"
+
 // A data provider for ticks that is backed by an in-memory array of ticks.
 type TickListDataProvider struct {
-	ticks []Tick
+	ticks       []Tick
+	tickSpacing int
 }
 
 func NewTickListDataProvider(ticks []Tick, tickSpacing int) (*TickListDataProvider, error) {
 	if err := ValidateList(ticks, tickSpacing); err != nil {
 		return nil, err
 	}
-	return &TickListDataProvider{ticks: ticks}, nil
+	return &TickListDataProvider{ticks: ticks, tickSpacing: tickSpacing}, nil
+}
+
+// TickSpacing returns the tick spacing the ticks were validated against
+func (p *TickListDataProvider) TickSpacing() int {
+	return p.tickSpacing
+}
+
+func (p *TickListDataProvider) Ticks() []Tick {
+	return append([]Tick(nil), p.ticks...)
 }
 
 func (p *TickListDataProvider) GetTick(tick int) (Tick, error) {
 	return GetTick(p.ticks, tick)
 }
 
+// findTick returns the tick with exactly the given index, if there is one
+func (p *TickListDataProvider) findTick(tick int) (Tick, bool) {
+	i := sort.Search(len(p.ticks), func(i int) bool {
+		return p.ticks[i].Index >= tick
+	})
+	if i < len(p.ticks) && p.ticks[i].Index == tick {
+		return p.ticks[i], true
+	}
+	return EmptyTick, false
+}
+
 func (p *TickListDataProvider) NextInitializedTickWithinOneWord(tick int, lte bool, tickSpacing int) (int, bool, error) {
 	return NextInitializedTickWithinOneWord(p.ticks, tick, lte, tickSpacing)
 }
@@ -23,3 +46,33 @@ func (p *TickListDataProvider) NextInitializedTickWithinOneWord(tick int, lte bo
 func (p *TickListDataProvider) NextInitializedTickIndex(tick int, lte bool) (int, bool, error) {
 	return NextInitializedTickIndex(p.ticks, tick, lte)
 }
+
+// SetTick inserts or replaces a tick. The ticks are copied rather than modified in place, as they may be shared with
+// the caller of NewTickListDataProvider and with the new pool states returned by swaps.
+func (p *TickListDataProvider) SetTick(tick Tick) error {
+	i := sort.Search(len(p.ticks), func(i int) bool {
+		return p.ticks[i].Index >= tick.Index
+	})
+	found := i < len(p.ticks) && p.ticks[i].Index == tick.Index
+	ticks := make([]Tick, 0, len(p.ticks)+1)
+	ticks = append(ticks, p.ticks[:i]...)
+	ticks = append(ticks, tick)
+	if found {
+		i++
+	}
+	p.ticks = append(ticks, p.ticks[i:]...)
+	return nil
+}
+
+// RemoveTick removes the tick at the given index, the ticks are copied as in SetTick
+func (p *TickListDataProvider) RemoveTick(tick int) error {
+	i := sort.Search(len(p.ticks), func(i int) bool {
+		return p.ticks[i].Index >= tick
+	})
+	if i < len(p.ticks) && p.ticks[i].Index == tick {
+		ticks := make([]Tick, 0, len(p.ticks)-1)
+		ticks = append(ticks, p.ticks[:i]...)
+		p.ticks = append(ticks, p.ticks[i+1:]...)
+	}
+	return nil
+}
@@ -85,12 +85,15 @@ type Trade struct {
 	outputAmount   *entities.CurrencyAmount // The cached result of the output amount computation
 	executionPrice *entities.Price          // The cached result of the computed execution price
 	priceImpact    *entities.Percent        // The cached result of the price impact computation
+
+	amountAfterGasCache *entities.Fraction // The amount net of the gas cost, computed when ranking by a gas model
 }
 
 type Swap struct {
-	Route        *Route
-	InputAmount  *entities.CurrencyAmount
-	OutputAmount *entities.CurrencyAmount
+	Route              *Route
+	InputAmount        *entities.CurrencyAmount
+	OutputAmount       *entities.CurrencyAmount
+	CrossInitTickLoops int // the number of initialized ticks crossed in the pools of the route, if simulated
 }
 
 /**
@@ -206,9 +209,11 @@ func ExactOut(route *Route, amountOut *entities.CurrencyAmount) (*Trade, error)
 func FromRoute(route *Route, amount *entities.CurrencyAmount, tradeType entities.TradeType) (*Trade, error) {
 	amounts := make([]*entities.CurrencyAmount, len(route.TokenPath))
 	var (
-		inputAmount  *entities.CurrencyAmount
-		outputAmount *entities.CurrencyAmount
-		err          error
+		inputAmount        *entities.CurrencyAmount
+		outputAmount       *entities.CurrencyAmount
+		crossInitTickLoops int
+		loops              int
+		err                error
 	)
 	if tradeType == entities.ExactInput {
 		if !amount.Currency.Equal(route.Input) {
@@ -222,6 +227,7 @@ func FromRoute(route *Route, amount *entities.CurrencyAmount, tradeType entities
 				return nil, err
 			}
 			amounts[i+1] = outputResult.ReturnedAmount
+			crossInitTickLoops += outputResult.CrossInitTickLoops
 		}
 		inputAmount = entities.FromFractionalAmount(route.Input, amount.Numerator, amount.Denominator)
 		outputAmount = entities.FromFractionalAmount(route.Output, amounts[len(amounts)-1].Numerator, amounts[len(amounts)-1].Denominator)
@@ -232,19 +238,21 @@ func FromRoute(route *Route, amount *entities.CurrencyAmount, tradeType entities
 		amounts[len(amounts)-1] = amount.Wrapped()
 		for i := len(route.TokenPath) - 1; i > 0; i-- {
 			pool := route.Pools[i-1]
-			inputAmount, _, err = pool.GetInputAmount(amounts[i], nil)
+			inputAmount, _, loops, err = pool.getInputAmount(amounts[i], nil)
 			if err != nil {
 				return nil, err
 			}
 			amounts[i-1] = inputAmount
+			crossInitTickLoops += loops
 		}
 		inputAmount = entities.FromFractionalAmount(route.Input, amounts[0].Numerator, amounts[0].Denominator)
 		outputAmount = entities.FromFractionalAmount(route.Output, amount.Numerator, amount.Denominator)
 	}
 	swaps := []*Swap{{
-		Route:        route,
-		InputAmount:  inputAmount,
-		OutputAmount: outputAmount}}
+		Route:              route,
+		InputAmount:        inputAmount,
+		OutputAmount:       outputAmount,
+		CrossInitTickLoops: crossInitTickLoops}}
 
 	return newTrade(swaps, tradeType)
 }
@@ -269,8 +277,9 @@ func FromRoutes(wrappedRoutes []*WrappedRoute, tradeType entities.TradeType) (*T
 	for _, wrappedRoute := range wrappedRoutes {
 		amounts := make([]*entities.CurrencyAmount, len(wrappedRoute.Route.TokenPath))
 		var (
-			inputAmount  *entities.CurrencyAmount
-			outputAmount *entities.CurrencyAmount
+			inputAmount        *entities.CurrencyAmount
+			outputAmount       *entities.CurrencyAmount
+			crossInitTickLoops int
 		)
 		amount := wrappedRoute.Amount
 		route := wrappedRoute.Route
@@ -286,6 +295,7 @@ func FromRoutes(wrappedRoutes []*WrappedRoute, tradeType entities.TradeType) (*T
 					return nil, err
 				}
 				amounts[i+1] = outputResult.ReturnedAmount
+				crossInitTickLoops += outputResult.CrossInitTickLoops
 			}
 			inputAmount = entities.FromFractionalAmount(route.Input, amount.Numerator, amount.Denominator)
 			outputAmount = entities.FromFractionalAmount(route.Output, amounts[len(amounts)-1].Numerator, amounts[len(amounts)-1].Denominator)
@@ -296,19 +306,21 @@ func FromRoutes(wrappedRoutes []*WrappedRoute, tradeType entities.TradeType) (*T
 			amounts[len(amounts)-1] = entities.FromFractionalAmount(route.Output.Wrapped(), amount.Numerator, amount.Denominator)
 			for i := len(route.TokenPath) - 1; i > 0; i-- {
 				pool := route.Pools[i-1]
-				inputAmount, _, err := pool.GetInputAmount(amounts[i], nil)
+				inputAmount, _, loops, err := pool.getInputAmount(amounts[i], nil)
 				if err != nil {
 					return nil, err
 				}
 				amounts[i-1] = inputAmount
+				crossInitTickLoops += loops
 			}
 			inputAmount = entities.FromFractionalAmount(route.Input, amounts[0].Numerator, amounts[0].Denominator)
 			outputAmount = entities.FromFractionalAmount(route.Output, amount.Numerator, amount.Denominator)
 		}
 		swaps = append(swaps, &Swap{
-			Route:        route,
-			InputAmount:  inputAmount,
-			OutputAmount: outputAmount})
+			Route:              route,
+			InputAmount:        inputAmount,
+			OutputAmount:       outputAmount,
+			CrossInitTickLoops: crossInitTickLoops})
 
 	}
 	return newTrade(swaps, tradeType)
@@ -350,17 +362,36 @@ func CreateUncheckedTradeWithMultipleRoutes(routes []*Swap, tradeType entities.T
  * @param tradeType The type of trade, exact input or exact output
  */
 func newTrade(routes []*Swap, tradeType entities.TradeType) (*Trade, error) {
+	if err := checkRouteCurrencies(routes); err != nil {
+		return nil, err
+	}
+	if err := checkDuplicatePools(routes); err != nil {
+		return nil, err
+	}
+
+	return &Trade{
+		Swaps:     routes,
+		TradeType: tradeType,
+	}, nil
+}
+
+// checkRouteCurrencies checks that all the routes have the same input and output currencies
+func checkRouteCurrencies(routes []*Swap) error {
 	inputCurrency := routes[0].InputAmount.Currency
 	outputCurrency := routes[0].OutputAmount.Currency
 	for _, route := range routes {
 		if !inputCurrency.Wrapped().Equal(route.Route.Input.Wrapped()) {
-			return nil, ErrInputCurrencyMismatch
+			return ErrInputCurrencyMismatch
 		}
 		if !outputCurrency.Wrapped().Equal(route.Route.Output.Wrapped()) {
-			return nil, ErrOutputCurrencyMismatch
+			return ErrOutputCurrencyMismatch
 		}
 	}
+	return nil
+}
 
+// checkDuplicatePools checks that no pool is used more than once across the routes
+func checkDuplicatePools(routes []*Swap) error {
 	var numPools int
 	for _, route := range routes {
 		numPools += len(route.Route.Pools)
@@ -369,22 +400,18 @@ func newTrade(routes []*Swap, tradeType entities.TradeType) (*Trade, error) {
 	var poolAddressSet = make(map[common.Address]bool)
 	for _, route := range routes {
 		for _, pool := range route.Route.Pools {
-			addr, err := GetAddress(pool.Token0, pool.Token1, pool.Fee, "")
+			addr, err := pool.Address()
 			if err != nil {
-				return nil, err
+				return err
 			}
 			poolAddressSet[addr] = true
 		}
 	}
 
 	if numPools != len(poolAddressSet) {
-		return nil, ErrDuplicatePools
+		return ErrDuplicatePools
 	}
-
-	return &Trade{
-		Swaps:     routes,
-		TradeType: tradeType,
-	}, nil
+	return nil
 }
 
 /**
@@ -450,8 +477,23 @@ func (t *Trade) WorstExecutionPrice(slippageTolerance *entities.Percent) (*entit
 }
 
 type BestTradeOptions struct {
-	MaxNumResults int // how many results to return
-	MaxHops       int // the maximum number of hops a trade should contain
+	MaxNumResults int       // how many results to return
+	MaxHops       int       // the maximum number of hops a trade should contain
+	GasModel      *GasModel // if set, trades are ranked by their amounts net of the estimated gas cost
+}
+
+// insert inserts the trade into the best trades, ranked by the gas model if any. The amount of the trade after gas
+// is computed once here, so that an error is returned rather than raised while sorting.
+func (opts *BestTradeOptions) insert(bestTrades []*Trade, trade *Trade) ([]*Trade, error) {
+	if opts.GasModel == nil {
+		return sortedInsert(bestTrades, trade, opts.MaxNumResults, tradeComparator)
+	}
+	amount, err := trade.amountAfterGas(opts.GasModel)
+	if err != nil {
+		return nil, err
+	}
+	trade.amountAfterGasCache = amount
+	return sortedInsert(bestTrades, trade, opts.MaxNumResults, tradeAfterGasComparator)
 }
 
 /**
@@ -483,6 +525,9 @@ func BestTradeExactIn(pools []*Pool, currencyAmountIn *entities.CurrencyAmount,
 	if opts.MaxHops <= 0 {
 		return nil, ErrInvalidMaxHops
 	}
+	if err := opts.GasModel.validate(currencyOut); err != nil {
+		return nil, err
+	}
 	if !(currencyAmountIn.EqualTo(nextAmountIn.Fraction) || len(currentPools) > 0) {
 		return nil, ErrInvalidRecursion
 	}
@@ -513,7 +558,7 @@ func BestTradeExactIn(pools []*Pool, currencyAmountIn *entities.CurrencyAmount,
 			if err != nil {
 				return nil, err
 			}
-			bestTrades, err = sortedInsert(bestTrades, trade, opts.MaxNumResults, tradeComparator)
+			bestTrades, err = opts.insert(bestTrades, trade)
 			if err != nil {
 				return nil, err
 			}
@@ -523,7 +568,7 @@ func BestTradeExactIn(pools []*Pool, currencyAmountIn *entities.CurrencyAmount,
 			poolsExcludingThisPool = append(poolsExcludingThisPool, pools[i+1:]...)
 
 			// otherwise, consider all the other paths that lead from this token as long as we have not exceeded maxHops
-			bestTrades, err = BestTradeExactIn(poolsExcludingThisPool, currencyAmountIn, currencyOut, &BestTradeOptions{MaxNumResults: opts.MaxNumResults, MaxHops: opts.MaxHops - 1}, append(currentPools, pool), amountOut.ReturnedAmount, bestTrades)
+			bestTrades, err = BestTradeExactIn(poolsExcludingThisPool, currencyAmountIn, currencyOut, &BestTradeOptions{MaxNumResults: opts.MaxNumResults, MaxHops: opts.MaxHops - 1, GasModel: opts.GasModel}, append(currentPools, pool), amountOut.ReturnedAmount, bestTrades)
 			if err != nil {
 				return nil, err
 			}
@@ -562,6 +607,9 @@ func BestTradeExactOut(pools []*Pool, currencyIn entities.Currency, currencyAmou
 	if opts.MaxHops <= 0 {
 		return nil, ErrInvalidMaxHops
 	}
+	if err := opts.GasModel.validate(currencyAmountOut.Currency); err != nil {
+		return nil, err
+	}
 	if !(currencyAmountOut.EqualTo(nextAmountOut.Fraction) || len(currentPools) > 0) {
 		return nil, ErrInvalidRecursion
 	}
@@ -592,7 +640,7 @@ func BestTradeExactOut(pools []*Pool, currencyIn entities.Currency, currencyAmou
 			if err != nil {
 				return nil, err
 			}
-			bestTrades, err = sortedInsert(bestTrades, trade, opts.MaxNumResults, tradeComparator)
+			bestTrades, err = opts.insert(bestTrades, trade)
 			if err != nil {
 				return nil, err
 			}
@@ -602,7 +650,7 @@ func BestTradeExactOut(pools []*Pool, currencyIn entities.Currency, currencyAmou
 			poolsExcludingThisPool = append(poolsExcludingThisPool, pools[i+1:]...)
 
 			// otherwise, consider all the other paths that arrive at this token as long as we have not exceeded maxHops
-			bestTrades, err = BestTradeExactOut(poolsExcludingThisPool, currencyIn, currencyAmountOut, &BestTradeOptions{MaxNumResults: opts.MaxNumResults, MaxHops: opts.MaxHops - 1}, append([]*Pool{pool}, currentPools...), amountIn, bestTrades)
+			bestTrades, err = BestTradeExactOut(poolsExcludingThisPool, currencyIn, currencyAmountOut, &BestTradeOptions{MaxNumResults: opts.MaxNumResults, MaxHops: opts.MaxHops - 1, GasModel: opts.GasModel}, append([]*Pool{pool}, currentPools...), amountIn, bestTrades)
 			if err != nil {
 				return nil, err
 			}
@@ -202,6 +202,22 @@ func TestFromRoutes(t *testing.T) {
 		{Amount: entities.FromRawAmount(token0, big.NewInt(5500)), Route: r1},
 	}, entities.ExactInput)
 	assert.ErrorIs(t, err, ErrDuplicatePools)
+
+	// pools of the same tokens and fee on different deployments are different pools
+	fork := *pool_0_1
+	fork.Deployment = &constants.Deployment{
+		ChainID:          1,
+		Protocol:         "fork",
+		FactoryAddress:   common.HexToAddress("0x1111111111111111111111111111111111111111"),
+		PoolInitCodeHash: "0x2222222222222222222222222222222222222222222222222222222222222222",
+	}
+	r0, _ = NewRoute([]*Pool{pool_0_1}, token0, token1)
+	r1, _ = NewRoute([]*Pool{&fork}, token0, token1)
+	_, err = FromRoutes([]*WrappedRoute{
+		{Amount: entities.FromRawAmount(token0, big.NewInt(4500)), Route: r0},
+		{Amount: entities.FromRawAmount(token0, big.NewInt(5500)), Route: r1},
+	}, entities.ExactInput)
+	assert.NoError(t, err)
 }
 
 func TestCreateUncheckedTrade(t *testing.T) {
@@ -21,6 +21,7 @@ const (
 	ContractV3SwapRouterV2       = "0x68b3465833fb72A70ecDF485E0e4C7bD8665Fc45"
 	ContractV3NFTPositionManager = "0xC36442b4a4522E871399CD717aBDD847Ab11FE88"
 	ContractV3Quoter             = "0xb27308f9F90D607463bb33eA1BeBb41C27CE5AB6"
+	ContractV3TickLens           = "0xbfd8137f7d1516D3ea5cA83523914859ec47F573"
 )
 
 var (
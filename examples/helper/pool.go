@@ -1,19 +1,20 @@
 package helper
 
 import (
+	"context"
 	"errors"
 	"math/big"
 
-	"github.com/KyberNetwork/int256"
 	"github.com/KyberNetwork/uniswapv3-sdk-uint256/examples/contract"
-	"github.com/holiman/uint256"
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/periphery"
 
 	"github.com/KyberNetwork/uniswapv3-sdk-uint256/constants"
 	"github.com/KyberNetwork/uniswapv3-sdk-uint256/entities"
-	sdkutils "github.com/KyberNetwork/uniswapv3-sdk-uint256/utils"
 	coreEntities "github.com/daoleno/uniswap-sdk-core/entities"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/holiman/uint256"
 )
 
 func GetPoolAddress(client *ethclient.Client, token0, token1 common.Address, fee *big.Int) (common.Address, error) {
@@ -32,7 +33,8 @@ func GetPoolAddress(client *ethclient.Client, token0, token1 common.Address, fee
 	return poolAddr, nil
 }
 
-func ConstructV3Pool(client *ethclient.Client, token0, token1 *coreEntities.Token, poolFee uint64) (*entities.Pool, error) {
+func ConstructV3Pool(rpcClient *rpc.Client, token0, token1 *coreEntities.Token, poolFee uint64) (*entities.Pool, error) {
+	client := ethclient.NewClient(rpcClient)
 	poolAddress, err := GetPoolAddress(client, token0.Address, token1.Address, new(big.Int).SetUint64(poolFee))
 	if err != nil {
 		return nil, err
@@ -53,33 +55,18 @@ func ConstructV3Pool(client *ethclient.Client, token0, token1 *coreEntities.Toke
 		return nil, err
 	}
 
-	pooltick, err := contractPool.Ticks(nil, big.NewInt(0))
+	tickSpacing, err := contractPool.TickSpacing(nil)
 	if err != nil {
 		return nil, err
 	}
 
-	feeAmount := constants.FeeAmount(poolFee)
-	ticks := []entities.Tick{
-		{
-			Index: entities.NearestUsableTick(sdkutils.MinTick,
-				constants.TickSpacings[feeAmount]),
-			LiquidityNet:   int256.MustFromBig(pooltick.LiquidityNet),
-			LiquidityGross: uint256.MustFromBig(pooltick.LiquidityGross),
-		},
-		{
-			Index: entities.NearestUsableTick(sdkutils.MaxTick,
-				constants.TickSpacings[feeAmount]),
-			LiquidityNet:   int256.MustFromBig(pooltick.LiquidityNet),
-			LiquidityGross: uint256.MustFromBig(pooltick.LiquidityGross),
-		},
-	}
-
-	// create tick data provider
-	p, err := entities.NewTickListDataProvider(ticks, constants.TickSpacings[feeAmount])
+	// read the populated ticks of the pool
+	lens := periphery.NewBatchTickLens(rpcClient, common.HexToAddress(ContractV3TickLens))
+	p, err := lens.NewTickListDataProvider(context.Background(), poolAddress, int(tickSpacing.Int64()))
 	if err != nil {
 		return nil, err
 	}
 
-	return entities.NewPool(token0, token1, constants.FeeAmount(poolFee),
-		slot0.SqrtPriceX96, liquidity, int(slot0.Tick.Int64()), p)
+	return entities.NewPoolWithTickSpacing(token0, token1, constants.FeeAmount(poolFee), int(tickSpacing.Int64()),
+		uint256.MustFromBig(slot0.SqrtPriceX96), uint256.MustFromBig(liquidity), int(slot0.Tick.Int64()), p)
 }
@@ -16,13 +16,15 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
 // mint a new liquidity
-func mintOrAdd(client *ethclient.Client, wallet *helper.Wallet, tokenID *big.Int) {
+func mintOrAdd(rpcClient *rpc.Client, wallet *helper.Wallet, tokenID *big.Int) {
 	log.SetFlags(log.Lshortfile | log.LstdFlags)
 
-	pool, err := helper.ConstructV3Pool(client, helper.WMATIC, helper.AMP, uint64(constants.FeeMedium))
+	client := ethclient.NewClient(rpcClient)
+	pool, err := helper.ConstructV3Pool(rpcClient, helper.WMATIC, helper.AMP, uint64(constants.FeeMedium))
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -84,9 +86,10 @@ func mintOrAdd(client *ethclient.Client, wallet *helper.Wallet, tokenID *big.Int
 	log.Println(tx.Hash().String())
 }
 
-func remove(client *ethclient.Client, wallet *helper.Wallet, tokenID *big.Int) {
+func remove(rpcClient *rpc.Client, wallet *helper.Wallet, tokenID *big.Int) {
+	client := ethclient.NewClient(rpcClient)
 	//our pool is the fee medium pool
-	pool, err := helper.ConstructV3Pool(client, helper.WMATIC, helper.AMP, uint64(constants.FeeMedium))
+	pool, err := helper.ConstructV3Pool(rpcClient, helper.WMATIC, helper.AMP, uint64(constants.FeeMedium))
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -156,17 +159,18 @@ func burn(client *ethclient.Client, wallet *helper.Wallet, tokenID *big.Int) {
 }
 
 func main() {
-	client, err := ethclient.Dial(helper.PolygonRPC)
+	rpcClient, err := rpc.Dial(helper.PolygonRPC)
 	if err != nil {
 		log.Fatal(err)
 	}
+	client := ethclient.NewClient(rpcClient)
 	wallet := helper.InitWallet(os.Getenv("MY_PRIVATE_KEY"))
 	if wallet == nil {
 		log.Fatal("init wallet failed")
 	}
 	_ = client
 	_ = wallet
-	//mintOrAdd(client, wallet)   //it will create a new NFT ID
-	//remove(client, wallet, nftTokenID)
+	//mintOrAdd(rpcClient, wallet)   //it will create a new NFT ID
+	//remove(rpcClient, wallet, nftTokenID)
 	//burn(client, wallet, nftTokenID) //remove the liquidity
 }
@@ -14,6 +14,7 @@ import (
 	coreEntities "github.com/daoleno/uniswap-sdk-core/entities"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
 func init() {
@@ -21,16 +22,17 @@ func init() {
 }
 
 func main() {
-	client, err := ethclient.Dial(helper.PolygonRPC)
+	rpcClient, err := rpc.Dial(helper.PolygonRPC)
 	if err != nil {
 		log.Fatal(err)
 	}
+	client := ethclient.NewClient(rpcClient)
 	wallet := helper.InitWallet(os.Getenv("MY_PRIVATE_KEY"))
 	if wallet == nil {
 		log.Fatal("init wallet failed")
 	}
 
-	pool, err := helper.ConstructV3Pool(client, helper.WMATIC, helper.AMP, uint64(constants.FeeMedium))
+	pool, err := helper.ConstructV3Pool(rpcClient, helper.WMATIC, helper.AMP, uint64(constants.FeeMedium))
 	if err != nil {
 		log.Fatal(err)
 	}
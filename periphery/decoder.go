@@ -0,0 +1,333 @@
+package periphery
+
+import (
+	"errors"
+	"math/big"
+	"reflect"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	ErrInvalidCalldata = errors.New("invalid calldata")
+	ErrUnknownSelector = errors.New("unknown selector")
+)
+
+// The params of the calls which aren't encoded as a struct
+
+type CreateAndInitializePoolParams struct {
+	Token0       common.Address
+	Token1       common.Address
+	Fee          *big.Int
+	SqrtPriceX96 *big.Int
+}
+
+// UnwrapWETH9Params are the params of unwrapWETH9 and unwrapWETH9WithFee. The recipient is the zero address for the
+// SwapRouter02 overloads without recipient, which pay the sender of the call.
+type UnwrapWETH9Params struct {
+	AmountMinimum *big.Int
+	Recipient     common.Address
+	FeeBips       *big.Int
+	FeeRecipient  common.Address
+}
+
+// SweepTokenParams are the params of sweepToken and sweepTokenWithFee. The recipient is the zero address for the
+// SwapRouter02 overloads without recipient, which pay the sender of the call.
+type SweepTokenParams struct {
+	Token         common.Address
+	AmountMinimum *big.Int
+	Recipient     common.Address
+	FeeBips       *big.Int
+	FeeRecipient  common.Address
+}
+
+// SelfPermitParams are the params of selfPermit and selfPermitIfNecessary
+type SelfPermitParams struct {
+	Token    common.Address
+	Value    *big.Int
+	Deadline *big.Int
+	V        uint8
+	R        [32]byte
+	S        [32]byte
+}
+
+// SelfPermitAllowedParams are the params of selfPermitAllowed and selfPermitAllowedIfNecessary
+type SelfPermitAllowedParams struct {
+	Token  common.Address
+	Nonce  *big.Int
+	Expiry *big.Int
+	V      uint8
+	R      [32]byte
+	S      [32]byte
+}
+
+// NFTPermitParams are the params of the permit of a position NFT
+type NFTPermitParams struct {
+	Spender  common.Address
+	TokenId  *big.Int
+	Deadline *big.Int
+	V        uint8
+	R        [32]byte
+	S        [32]byte
+}
+
+// StakeParams are the params of stakeToken and unstakeToken
+type StakeParams struct {
+	Key     IncentiveKeyParams
+	TokenId *big.Int
+}
+
+type ClaimRewardParams struct {
+	RewardToken     common.Address
+	To              common.Address
+	AmountRequested *big.Int
+}
+
+type WithdrawTokenParams struct {
+	TokenId *big.Int
+	To      common.Address
+	Data    []byte
+}
+
+// DecodedCall is a decoded call of a router, of the position manager or of the staker
+type DecodedCall struct {
+	Method    string       // The name of the method, e.g. exactInput
+	Signature string       // The signature of the method, which tells the overloads and the routers apart
+	Params    interface{}  // The typed params, e.g. *ExactInputParams, or a map of the params by name for the other methods
+	Path      *DecodedPath // The decoded path of exactInput and exactOutput
+}
+
+// DecodedCommand is a decoded command of a Universal Router execution
+type DecodedCommand struct {
+	Command     CommandType
+	AllowRevert bool          // Whether the execution continues if the command fails
+	Params      []interface{} // The values of the input of the command, in the order of its abi encoding
+	Path        *DecodedPath  // The decoded path of the V3 swaps
+}
+
+// DecodedCalldata is the decoded calldata of a call, of a multicall or of a Universal Router execution
+type DecodedCalldata struct {
+	Calls             []*DecodedCall
+	Commands          []*DecodedCommand // The commands of a Universal Router execution, which has no calls
+	Deadline          *big.Int          // The deadline of a SwapRouter02 multicall or of a Universal Router execution
+	PreviousBlockhash *common.Hash      // The previous blockhash of a SwapRouter02 multicall
+}
+
+type decoderMethod struct {
+	method abi.Method
+	params func() interface{} // the typed params of the method, if any
+}
+
+// the typed params of the methods, by signature
+var decoderParams = map[string]func() interface{}{
+	// SwapRouter
+	"exactInputSingle((address,address,uint24,address,uint256,uint256,uint256,uint160))":  func() interface{} { return &ExactInputSingleParams{} },
+	"exactOutputSingle((address,address,uint24,address,uint256,uint256,uint256,uint160))": func() interface{} { return &ExactOutputSingleParams{} },
+	"exactInput((bytes,address,uint256,uint256,uint256))":                                 func() interface{} { return &ExactInputParams{} },
+	"exactOutput((bytes,address,uint256,uint256,uint256))":                                func() interface{} { return &ExactOutputParams{} },
+	// SwapRouter02
+	"exactInputSingle((address,address,uint24,address,uint256,uint256,uint160))":  func() interface{} { return &V3ExactInputSingleParams{} },
+	"exactOutputSingle((address,address,uint24,address,uint256,uint256,uint160))": func() interface{} { return &V3ExactOutputSingleParams{} },
+	"exactInput((bytes,address,uint256,uint256))":                                 func() interface{} { return &V3ExactInputParams{} },
+	"exactOutput((bytes,address,uint256,uint256))":                                func() interface{} { return &V3ExactOutputParams{} },
+	// NonfungiblePositionManager
+	"createAndInitializePoolIfNecessary(address,address,uint24,uint160)":                         func() interface{} { return &CreateAndInitializePoolParams{} },
+	"mint((address,address,uint24,int24,int24,uint256,uint256,uint256,uint256,address,uint256))": func() interface{} { return &MintParams{} },
+	"increaseLiquidity((uint256,uint256,uint256,uint256,uint256,uint256))":                       func() interface{} { return &IncreaseLiquidityParams{} },
+	"decreaseLiquidity((uint256,uint128,uint256,uint256,uint256))":                               func() interface{} { return &DecreaseLiquidityParams{} },
+	"collect((uint256,address,uint128,uint128))":                                                 func() interface{} { return &CollectParams{} },
+	"permit(address,uint256,uint256,uint8,bytes32,bytes32)":                                      func() interface{} { return &NFTPermitParams{} },
+	// payments
+	"unwrapWETH9(uint256,address)":                                                func() interface{} { return &UnwrapWETH9Params{} },
+	"unwrapWETH9(uint256)":                                                        func() interface{} { return &UnwrapWETH9Params{} },
+	"unwrapWETH9WithFee(uint256,address,uint256,address)":                         func() interface{} { return &UnwrapWETH9Params{} },
+	"unwrapWETH9WithFee(uint256,uint256,address)":                                 func() interface{} { return &UnwrapWETH9Params{} },
+	"sweepToken(address,uint256,address)":                                         func() interface{} { return &SweepTokenParams{} },
+	"sweepToken(address,uint256)":                                                 func() interface{} { return &SweepTokenParams{} },
+	"sweepTokenWithFee(address,uint256,address,uint256,address)":                  func() interface{} { return &SweepTokenParams{} },
+	"sweepTokenWithFee(address,uint256,uint256,address)":                          func() interface{} { return &SweepTokenParams{} },
+	"selfPermit(address,uint256,uint256,uint8,bytes32,bytes32)":                   func() interface{} { return &SelfPermitParams{} },
+	"selfPermitIfNecessary(address,uint256,uint256,uint8,bytes32,bytes32)":        func() interface{} { return &SelfPermitParams{} },
+	"selfPermitAllowed(address,uint256,uint256,uint8,bytes32,bytes32)":            func() interface{} { return &SelfPermitAllowedParams{} },
+	"selfPermitAllowedIfNecessary(address,uint256,uint256,uint8,bytes32,bytes32)": func() interface{} { return &SelfPermitAllowedParams{} },
+	// UniswapV3Staker
+	"stakeToken((address,address,uint256,uint256,address),uint256)":   func() interface{} { return &StakeParams{} },
+	"unstakeToken((address,address,uint256,uint256,address),uint256)": func() interface{} { return &StakeParams{} },
+	"claimReward(address,address,uint256)":                            func() interface{} { return &ClaimRewardParams{} },
+	"withdrawToken(uint256,address,bytes)":                            func() interface{} { return &WithdrawTokenParams{} },
+}
+
+// the methods of the contracts whose calldata can be decoded, by selector
+var decoderMethods = func() map[[4]byte]*decoderMethod {
+	methods := make(map[[4]byte]*decoderMethod)
+	for _, contractABI := range [][]byte{
+		swapRouterABI, swapRouter02ABI, nonFungiblePositionManagerABI, stakerABI, paymentsABI, selfpermitABI, multicallABI,
+		universalRouterABI,
+	} {
+		for _, method := range GetABI(contractABI).Methods {
+			var selector [4]byte
+			copy(selector[:], method.ID)
+			if _, ok := methods[selector]; !ok {
+				methods[selector] = &decoderMethod{method: method, params: decoderParams[method.Sig]}
+			}
+		}
+	}
+	return methods
+}()
+
+/**
+ * Decodes the calldata of a call of a router, of the position manager or of the staker. The calls of a multicall are
+ * decoded one by one, as are the commands of a Universal Router execution.
+ * @param calldata The calldata to decode, e.g. the calldata of the MethodParameters of SwapCallParameters
+ */
+func DecodeCalldata(calldata []byte) (*DecodedCalldata, error) {
+	method, values, err := unpackCall(calldata)
+	if err != nil {
+		return nil, err
+	}
+	if method.method.RawName == "execute" {
+		return decodeExecute(values)
+	}
+	if method.method.RawName != "multicall" {
+		call, err := decodeCall(method, values)
+		if err != nil {
+			return nil, err
+		}
+		return &DecodedCalldata{Calls: []*DecodedCall{call}}, nil
+	}
+
+	decoded := &DecodedCalldata{}
+	switch method.method.Sig {
+	case "multicall(uint256,bytes[])":
+		decoded.Deadline = values[0].(*big.Int)
+	case "multicall(bytes32,bytes[])":
+		previousBlockhash := common.Hash(values[0].([32]byte))
+		decoded.PreviousBlockhash = &previousBlockhash
+	}
+	for _, data := range values[len(values)-1].([][]byte) {
+		call, err := DecodeCall(data)
+		if err != nil {
+			return nil, err
+		}
+		decoded.Calls = append(decoded.Calls, call)
+	}
+	return decoded, nil
+}
+
+/**
+ * Decodes the calldata of a single call, which isn't a multicall nor a Universal Router execution
+ * @param calldata The calldata to decode
+ */
+func DecodeCall(calldata []byte) (*DecodedCall, error) {
+	method, values, err := unpackCall(calldata)
+	if err != nil {
+		return nil, err
+	}
+	if method.method.RawName == "multicall" || method.method.RawName == "execute" {
+		return nil, ErrInvalidCalldata
+	}
+	return decodeCall(method, values)
+}
+
+// decodeExecute decodes the commands of execute(bytes,bytes[]) and execute(bytes,bytes[],uint256)
+func decodeExecute(values []interface{}) (*DecodedCalldata, error) {
+	commands, inputs := values[0].([]byte), values[1].([][]byte)
+	if len(commands) != len(inputs) {
+		return nil, ErrInvalidCalldata
+	}
+	decoded := &DecodedCalldata{}
+	if len(values) > 2 {
+		decoded.Deadline = values[2].(*big.Int)
+	}
+	for i, command := range commands {
+		decodedCommand, err := DecodeCommand(command, inputs[i])
+		if err != nil {
+			return nil, err
+		}
+		decoded.Commands = append(decoded.Commands, decodedCommand)
+	}
+	return decoded, nil
+}
+
+/**
+ * Decodes a command of a Universal Router execution
+ * @param command The command, with its allow revert flag
+ * @param input The input of the command
+ */
+func DecodeCommand(command byte, input []byte) (*DecodedCommand, error) {
+	decoded := &DecodedCommand{
+		Command:     CommandType(command &^ AllowRevertFlag),
+		AllowRevert: command&AllowRevertFlag != 0,
+	}
+	arguments, ok := commandArguments[decoded.Command]
+	if !ok {
+		return nil, ErrUnknownCommand
+	}
+	params, err := arguments.Unpack(input)
+	if err != nil {
+		return nil, err
+	}
+	decoded.Params = params
+
+	if decoded.Command == CommandV3SwapExactIn || decoded.Command == CommandV3SwapExactOut {
+		tokens, fees, err := DecodePath(params[3].([]byte))
+		if err != nil {
+			return nil, err
+		}
+		decoded.Path = &DecodedPath{Tokens: tokens, Fees: fees}
+	}
+	return decoded, nil
+}
+
+func unpackCall(calldata []byte) (*decoderMethod, []interface{}, error) {
+	if len(calldata) < 4 {
+		return nil, nil, ErrInvalidCalldata
+	}
+	var selector [4]byte
+	copy(selector[:], calldata[:4])
+	method, ok := decoderMethods[selector]
+	if !ok {
+		return nil, nil, ErrUnknownSelector
+	}
+	values, err := method.method.Inputs.Unpack(calldata[4:])
+	if err != nil {
+		return nil, nil, err
+	}
+	return method, values, nil
+}
+
+func decodeCall(method *decoderMethod, values []interface{}) (*DecodedCall, error) {
+	call := &DecodedCall{Method: method.method.RawName, Signature: method.method.Sig}
+	if method.params == nil {
+		params := make(map[string]interface{})
+		for i, input := range method.method.Inputs {
+			params[input.Name] = values[i]
+		}
+		call.Params = params
+		return call, nil
+	}
+
+	params := method.params()
+	inputs := method.method.Inputs
+	var err error
+	// a single struct param is copied into the params, not into their first field
+	if len(inputs) == 1 && inputs[0].Type.T == abi.TupleTy {
+		err = inputs.Copy(&params, values)
+	} else {
+		err = inputs.Copy(params, values)
+	}
+	if err != nil {
+		return nil, err
+	}
+	call.Params = params
+
+	if path := reflect.ValueOf(params).Elem().FieldByName("Path"); path.IsValid() {
+		tokens, fees, err := DecodePath(path.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		call.Path = &DecodedPath{Tokens: tokens, Fees: fees}
+	}
+	return call, nil
+}
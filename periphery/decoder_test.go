@@ -0,0 +1,240 @@
+package periphery
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/constants"
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/entities"
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/utils"
+	core "github.com/daoleno/uniswap-sdk-core/entities"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeSwapCalldata(t *testing.T) {
+	pool_0_1 := makePool(token0, token1)
+	pool_1_weth := makePool(token1, weth)
+	slippageTolerance := core.NewPercent(big.NewInt(1), big.NewInt(100))
+	recipient := common.HexToAddress("0x0000000000000000000000000000000000000003")
+	deadline := big.NewInt(123)
+
+	// single-hop exact input with a fee on the output
+	r, _ := entities.NewRoute([]*entities.Pool{pool_0_1}, token0, token1)
+	trade, _ := entities.FromRoute(r, core.FromRawAmount(token0, big.NewInt(100)), core.ExactInput)
+	params, err := SwapCallParameters([]*entities.Trade{trade}, &SwapOptions{
+		SlippageTolerance: slippageTolerance,
+		Recipient:         recipient,
+		Deadline:          deadline,
+		Fee:               &FeeOptions{Fee: core.NewPercent(big.NewInt(5), big.NewInt(1000)), Recipient: recipient},
+	})
+	require.NoError(t, err)
+	decoded, err := DecodeCalldata(params.Calldata)
+	require.NoError(t, err)
+	require.Len(t, decoded.Calls, 2)
+	assert.Equal(t, "exactInputSingle", decoded.Calls[0].Method)
+	single := decoded.Calls[0].Params.(*ExactInputSingleParams)
+	assert.Equal(t, token0.Address, single.TokenIn)
+	assert.Equal(t, token1.Address, single.TokenOut)
+	assert.Equal(t, big.NewInt(int64(constants.FeeMedium)), single.Fee)
+	// the router holds the output to take the fee
+	assert.Equal(t, common.Address{}, single.Recipient)
+	assert.Equal(t, deadline, single.Deadline)
+	assert.Equal(t, big.NewInt(100), single.AmountIn)
+	assert.Equal(t, big.NewInt(97), single.AmountOutMinimum)
+	assert.Zero(t, single.SqrtPriceLimitX96.Sign())
+	assert.Nil(t, decoded.Calls[0].Path)
+	assert.Equal(t, "sweepTokenWithFee", decoded.Calls[1].Method)
+	assert.Equal(t, &SweepTokenParams{
+		Token:         token1.Address,
+		AmountMinimum: big.NewInt(97),
+		Recipient:     recipient,
+		FeeBips:       big.NewInt(50),
+		FeeRecipient:  recipient,
+	}, decoded.Calls[1].Params)
+
+	// multi-hop exact output from ether, through SwapRouter02
+	r, _ = entities.NewRoute([]*entities.Pool{pool_1_weth, pool_0_1}, ether, token0)
+	trade, _ = entities.FromRoute(r, core.FromRawAmount(token0, big.NewInt(100)), core.ExactOutput)
+	params, err = SwapRouter02CallParameters([]*entities.Trade{trade}, &SwapOptions{
+		SlippageTolerance: slippageTolerance,
+		Recipient:         recipient,
+		Deadline:          deadline,
+	})
+	require.NoError(t, err)
+	decoded, err = DecodeCalldata(params.Calldata)
+	require.NoError(t, err)
+	assert.Equal(t, deadline, decoded.Deadline)
+	assert.Nil(t, decoded.PreviousBlockhash)
+	require.Len(t, decoded.Calls, 2)
+	assert.Equal(t, "exactOutput((bytes,address,uint256,uint256))", decoded.Calls[0].Signature)
+	swap := decoded.Calls[0].Params.(*V3ExactOutputParams)
+	assert.Equal(t, recipient, swap.Recipient)
+	assert.Equal(t, big.NewInt(100), swap.AmountOut)
+	assert.Equal(t, &DecodedPath{
+		Tokens: []common.Address{token0.Address, token1.Address, weth.Address},
+		Fees:   []constants.FeeAmount{constants.FeeMedium, constants.FeeMedium},
+	}, decoded.Calls[0].Path)
+	assert.Equal(t, "refundETH", decoded.Calls[1].Method)
+	assert.Equal(t, map[string]interface{}{}, decoded.Calls[1].Params)
+
+	// only the calldata of the known methods can be decoded, and multicalls only by DecodeCalldata
+	_, err = DecodeCall(swap.Path)
+	assert.ErrorIs(t, err, ErrUnknownSelector)
+	_, err = DecodeCall(params.Calldata)
+	assert.ErrorIs(t, err, ErrInvalidCalldata)
+	_, err = DecodeCalldata([]byte{0xac, 0x96})
+	assert.ErrorIs(t, err, ErrInvalidCalldata)
+}
+
+func TestDecodePositionCalldata(t *testing.T) {
+	// mint
+	pos, err := entities.NewPosition(pool01T, big.NewInt(1), -constants.TickSpacings[constants.FeeMedium], constants.TickSpacings[constants.FeeMedium])
+	require.NoError(t, err)
+	params, err := AddCallParameters(pos, &AddLiquidityOptions{
+		MintSpecificOptions: &MintSpecificOptions{
+			Recipient:  recipientT,
+			CreatePool: true,
+		},
+		CommonAddLiquidityOptions: &CommonAddLiquidityOptions{
+			SlippageTolerance: slippageToleranceT,
+			Deadline:          deadlineT,
+		},
+	})
+	require.NoError(t, err)
+	decoded, err := DecodeCalldata(params.Calldata)
+	require.NoError(t, err)
+	require.Len(t, decoded.Calls, 2)
+	assert.Equal(t, &CreateAndInitializePoolParams{
+		Token0:       token0T.Address,
+		Token1:       token1T.Address,
+		Fee:          big.NewInt(int64(constants.FeeMedium)),
+		SqrtPriceX96: utils.EncodeSqrtRatioX96(big.NewInt(1), big.NewInt(1)),
+	}, decoded.Calls[0].Params)
+	mint := decoded.Calls[1].Params.(*MintParams)
+	assert.Equal(t, big.NewInt(-60), mint.TickLower)
+	assert.Equal(t, big.NewInt(60), mint.TickUpper)
+	assert.Equal(t, recipientT, mint.Recipient)
+	assert.Equal(t, deadlineT, mint.Deadline)
+
+	// exit with ether
+	pos, err = entities.NewPosition(pool01T, big.NewInt(100), -constants.TickSpacings[constants.FeeMedium], constants.TickSpacings[constants.FeeMedium])
+	require.NoError(t, err)
+	params, err = RemoveCallParameters(pos, &RemoveLiquidityOptions{
+		TokenID:             tokenIDT,
+		LiquidityPercentage: core.NewPercent(big.NewInt(1), big.NewInt(1)),
+		SlippageTolerance:   slippageToleranceT,
+		Deadline:            deadlineT,
+		CollectOptions: &CollectOptions{
+			ExpectedCurrencyOwed0: core.FromRawAmount(token1T, big.NewInt(0)),
+			ExpectedCurrencyOwed1: core.FromRawAmount(core.EtherOnChain(1), big.NewInt(0)),
+			ExpectedTokenOwed0:    token1T,
+			ExpectedTokenOwed1:    core.EtherOnChain(1).Wrapped(),
+			Recipient:             recipientT,
+		},
+	})
+	require.NoError(t, err)
+	decoded, err = DecodeCalldata(params.Calldata)
+	require.NoError(t, err)
+	var methods []string
+	for _, call := range decoded.Calls {
+		methods = append(methods, call.Method)
+	}
+	assert.Equal(t, []string{"decreaseLiquidity", "collect", "unwrapWETH9", "sweepToken"}, methods)
+	decrease := decoded.Calls[0].Params.(*DecreaseLiquidityParams)
+	assert.Equal(t, tokenIDT, decrease.TokenId)
+	assert.Equal(t, big.NewInt(100), decrease.Liquidity)
+	assert.Equal(t, deadlineT, decrease.Deadline)
+	assert.Equal(t, &CollectParams{
+		TokenId:    tokenIDT,
+		Recipient:  common.Address{},
+		Amount0Max: MaxUint128,
+		Amount1Max: MaxUint128,
+	}, decoded.Calls[1].Params)
+	unwrap := decoded.Calls[2].Params.(*UnwrapWETH9Params)
+	assert.Zero(t, unwrap.AmountMinimum.Sign())
+	assert.Equal(t, recipientT, unwrap.Recipient)
+	assert.Nil(t, unwrap.FeeBips)
+}
+
+func TestDecodeStakerCalldata(t *testing.T) {
+	reward := core.NewToken(1, common.HexToAddress("0x1f9840a85d5aF5bf1D1762F925BDADdC4201F984"), 18, "r", "reward")
+	pool, _ := entities.NewPool(token0, token1, constants.FeeMedium, utils.EncodeSqrtRatioX96(constants.One, constants.One), big.NewInt(0), 0, nil)
+	key := &IncentiveKey{
+		RewardToken: reward,
+		Pool:        pool,
+		StartTime:   big.NewInt(100),
+		EndTime:     big.NewInt(200),
+		Refundee:    common.HexToAddress("0x0000000000000000000000000000000000000001"),
+	}
+	recipient := common.HexToAddress("0x0000000000000000000000000000000000000003")
+	params, err := CollectRewards([]*IncentiveKey{key}, &ClaimOptions{TokenID: big.NewInt(1), Recipient: recipient, Amount: big.NewInt(1)})
+	require.NoError(t, err)
+	decoded, err := DecodeCalldata(params.Calldata)
+	require.NoError(t, err)
+	require.Len(t, decoded.Calls, 3)
+	keyParams, err := encodeIncentiveKey(key)
+	require.NoError(t, err)
+	assert.Equal(t, &StakeParams{Key: *keyParams, TokenId: big.NewInt(1)}, decoded.Calls[0].Params)
+	assert.Equal(t, &ClaimRewardParams{RewardToken: reward.Address, To: recipient, AmountRequested: big.NewInt(1)}, decoded.Calls[1].Params)
+	assert.Equal(t, "stakeToken", decoded.Calls[2].Method)
+}
+
+func TestDecodeUniversalRouterCalldata(t *testing.T) {
+	pool_0_1 := makePool(token0, token1)
+	pool_1_weth := makePool(token1, weth)
+	recipient := common.HexToAddress("0x0000000000000000000000000000000000000003")
+	deadline := big.NewInt(123)
+
+	// multi-hop exact input to ether, the router unwraps the output
+	r, _ := entities.NewRoute([]*entities.Pool{pool_0_1, pool_1_weth}, token0, ether)
+	trade, _ := entities.FromRoute(r, core.FromRawAmount(token0, big.NewInt(100)), core.ExactInput)
+	params, err := UniversalRouterCallParameters([]*entities.Trade{trade}, &SwapOptions{
+		SlippageTolerance: core.NewPercent(big.NewInt(1), big.NewInt(100)),
+		Recipient:         recipient,
+		Deadline:          deadline,
+	})
+	require.NoError(t, err)
+	decoded, err := DecodeCalldata(params.Calldata)
+	require.NoError(t, err)
+	assert.Empty(t, decoded.Calls)
+	assert.Equal(t, deadline, decoded.Deadline)
+	require.Len(t, decoded.Commands, 2)
+	swap := decoded.Commands[0]
+	assert.Equal(t, CommandV3SwapExactIn, swap.Command)
+	assert.False(t, swap.AllowRevert)
+	assert.Equal(t, AddressThis, swap.Params[0])
+	assert.Equal(t, big.NewInt(100), swap.Params[1])
+	assert.Equal(t, true, swap.Params[4], "paid by the sender")
+	assert.Equal(t, &DecodedPath{
+		Tokens: []common.Address{token0.Address, token1.Address, weth.Address},
+		Fees:   []constants.FeeAmount{constants.FeeMedium, constants.FeeMedium},
+	}, swap.Path)
+	assert.Equal(t, CommandUnwrapWETH, decoded.Commands[1].Command)
+	assert.Equal(t, recipient, decoded.Commands[1].Params[0])
+	assert.Nil(t, decoded.Commands[1].Path)
+
+	// a command that may revert, without deadline
+	planner := NewRoutePlanner()
+	require.NoError(t, planner.AddCommand(CommandSweep, true, token0.Address, recipient, big.NewInt(1)))
+	calldata, err := planner.EncodeExecute(nil)
+	require.NoError(t, err)
+	decoded, err = DecodeCalldata(calldata)
+	require.NoError(t, err)
+	assert.Nil(t, decoded.Deadline)
+	assert.Equal(t, []*DecodedCommand{{
+		Command:     CommandSweep,
+		AllowRevert: true,
+		Params:      []interface{}{token0.Address, recipient, big.NewInt(1)},
+	}}, decoded.Commands)
+
+	// executions are only decoded by DecodeCalldata, and unknown commands aren't decoded
+	_, err = DecodeCall(calldata)
+	assert.ErrorIs(t, err, ErrInvalidCalldata)
+	planner.Commands[0] = 0x3f
+	calldata, err = planner.EncodeExecute(nil)
+	require.NoError(t, err)
+	_, err = DecodeCalldata(calldata)
+	assert.ErrorIs(t, err, ErrUnknownCommand)
+}
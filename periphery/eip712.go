@@ -0,0 +1,152 @@
+package periphery
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// The EIP-712 typed data of the permits is built with the go-ethereum apitypes, so that it can be sent as is to
+// eth_signTypedData_v4, but hashed here: the apitypes validation rejects the uint160 and uint48 fields of Permit2.
+
+/**
+ * Returns the EIP-712 digest of the typed data, i.e. the hash signed by the owner of the permit
+ * @param typedData The typed data to hash
+ */
+func HashTypedData(typedData *apitypes.TypedData) ([]byte, error) {
+	domainSeparator, err := hashStruct(typedData, "EIP712Domain", typedData.Domain.Map())
+	if err != nil {
+		return nil, err
+	}
+	message, err := hashStruct(typedData, typedData.PrimaryType, typedData.Message)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Keccak256([]byte("\x19\x01"), domainSeparator, message), nil
+}
+
+/**
+ * Signs the EIP-712 digest of the typed data
+ * @param typedData The typed data to sign
+ * @param privateKey The key of the signer
+ * @returns The 65 bytes signature r || s || v, with v 27 or 28
+ */
+func SignTypedData(typedData *apitypes.TypedData, privateKey *ecdsa.PrivateKey) ([]byte, error) {
+	digest, err := HashTypedData(typedData)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := crypto.Sign(digest, privateKey)
+	if err != nil {
+		return nil, err
+	}
+	signature[64] += 27
+	return signature, nil
+}
+
+func hashStruct(typedData *apitypes.TypedData, primaryType string, data map[string]interface{}) ([]byte, error) {
+	encoded, err := encodeData(typedData, primaryType, data)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Keccak256(encoded), nil
+}
+
+// encodeData is apitypes.TypedData.EncodeData without the validation of the primitive types
+func encodeData(typedData *apitypes.TypedData, primaryType string, data map[string]interface{}) ([]byte, error) {
+	fields, ok := typedData.Types[primaryType]
+	if !ok {
+		return nil, fmt.Errorf("unknown type %q", primaryType)
+	}
+	var buffer bytes.Buffer
+	buffer.Write(typedData.TypeHash(primaryType))
+	for _, field := range fields {
+		if strings.HasSuffix(field.Type, "[]") {
+			items, ok := data[field.Name].([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("provided data '%v' doesn't match type '%s'", data[field.Name], field.Type)
+			}
+			var arrayBuffer bytes.Buffer
+			for _, item := range items {
+				encoded, err := encodeValue(typedData, strings.TrimSuffix(field.Type, "[]"), item)
+				if err != nil {
+					return nil, err
+				}
+				arrayBuffer.Write(encoded)
+			}
+			buffer.Write(crypto.Keccak256(arrayBuffer.Bytes()))
+			continue
+		}
+		encoded, err := encodeValue(typedData, field.Type, data[field.Name])
+		if err != nil {
+			return nil, err
+		}
+		buffer.Write(encoded)
+	}
+	return buffer.Bytes(), nil
+}
+
+func encodeValue(typedData *apitypes.TypedData, encType string, value interface{}) ([]byte, error) {
+	if _, ok := typedData.Types[encType]; ok {
+		data, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("provided data '%v' doesn't match type '%s'", value, encType)
+		}
+		return hashStruct(typedData, encType, data)
+	}
+	return typedData.EncodePrimitiveValue(encType, value, 0)
+}
+
+// typedDataInt converts an integer to the type of the integer values of apitypes.TypedDataMessage
+func typedDataInt(value *big.Int) *math.HexOrDecimal256 {
+	return (*math.HexOrDecimal256)(new(big.Int).Set(value))
+}
+
+// PermitDomain is the EIP-712 domain of the permits of a token or of the position NFTs
+type PermitDomain struct {
+	Name              string
+	Version           string
+	ChainID           *big.Int
+	VerifyingContract common.Address // the contract the permits are for
+}
+
+var permitDomainType = []apitypes.Type{
+	{Name: "name", Type: "string"},
+	{Name: "version", Type: "string"},
+	{Name: "chainId", Type: "uint256"},
+	{Name: "verifyingContract", Type: "address"},
+}
+
+func (d *PermitDomain) typedDataDomain() apitypes.TypedDataDomain {
+	return apitypes.TypedDataDomain{
+		Name:              d.Name,
+		Version:           d.Version,
+		ChainId:           typedDataInt(d.ChainID),
+		VerifyingContract: d.VerifyingContract.Hex(),
+	}
+}
+
+/**
+ * Returns the domain separator of the domain, i.e. the DOMAIN_SEPARATOR of the contract
+ */
+func (d *PermitDomain) DomainSeparator() ([]byte, error) {
+	typedData := &apitypes.TypedData{
+		Types:  apitypes.Types{"EIP712Domain": permitDomainType},
+		Domain: d.typedDataDomain(),
+	}
+	return hashStruct(typedData, "EIP712Domain", typedData.Domain.Map())
+}
+
+// splitSignature splits a 65 bytes signature r || s || v into its v, r and s
+func splitSignature(signature []byte) (v uint8, r, s [32]byte) {
+	copy(r[:], signature[:32])
+	copy(s[:], signature[32:64])
+	return signature[64], r, s
+}
@@ -1,6 +1,7 @@
 package periphery
 
 import (
+	"crypto/ecdsa"
 	_ "embed"
 	"encoding/json"
 	"errors"
@@ -12,6 +13,7 @@ import (
 	core "github.com/daoleno/uniswap-sdk-core/entities"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
 )
 
 //go:embed contracts/interfaces/INonfungiblePositionManager.sol/INonfungiblePositionManager.json
@@ -370,7 +372,8 @@ func RemoveCallParameters(position *entities.Position, opts *RemoveLiquidityOpti
 
 	abi := getNonFungiblePositionManagerABI()
 	if opts.Permit != nil {
-		calldata, err := abi.Pack("permit", opts.Permit.Spender, opts.TokenID, opts.Permit.Deadline, opts.Permit.V, opts.Permit.R, opts.Permit.S)
+		calldata, err := abi.Pack("permit", common.HexToAddress(opts.Permit.Spender), opts.TokenID, opts.Permit.Deadline,
+			uint8(opts.Permit.V), common.HexToHash(opts.Permit.R), common.HexToHash(opts.Permit.S))
 		if err != nil {
 			return nil, err
 		}
@@ -451,3 +454,74 @@ func SafeTransferFromParameters(opts *SafeTransferOptions) (*utils.MethodParamet
 		Value:    constants.Zero,
 	}, nil
 }
+
+// NFTPermitData is the message of a permit of a position NFT
+type NFTPermitData struct {
+	Spender  common.Address
+	TokenID  *big.Int
+	Nonce    *big.Int // the nonce of the position
+	Deadline *big.Int
+}
+
+/**
+ * Returns the domain of the permits of the position NFTs
+ * @param chainId The chain of the position manager
+ * @param positionManager The address of the position manager
+ */
+func NewNFTPermitDomain(chainId *big.Int, positionManager common.Address) *PermitDomain {
+	return &PermitDomain{
+		Name:              "Uniswap V3 Positions NFT-V1",
+		Version:           "1",
+		ChainID:           chainId,
+		VerifyingContract: positionManager,
+	}
+}
+
+/**
+ * Returns the EIP-712 typed data of a permit of a position NFT
+ * @param domain The domain of the position manager, see NewNFTPermitDomain
+ * @param permit The permit
+ */
+func GetNFTPermitTypedData(domain *PermitDomain, permit *NFTPermitData) *apitypes.TypedData {
+	return &apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": permitDomainType,
+			"Permit": {
+				{Name: "spender", Type: "address"},
+				{Name: "tokenId", Type: "uint256"},
+				{Name: "nonce", Type: "uint256"},
+				{Name: "deadline", Type: "uint256"},
+			},
+		},
+		PrimaryType: "Permit",
+		Domain:      domain.typedDataDomain(),
+		Message: apitypes.TypedDataMessage{
+			"spender":  permit.Spender.Hex(),
+			"tokenId":  typedDataInt(permit.TokenID),
+			"nonce":    typedDataInt(permit.Nonce),
+			"deadline": typedDataInt(permit.Deadline),
+		},
+	}
+}
+
+/**
+ * Signs a permit of a position NFT
+ * @param domain The domain of the position manager, see NewNFTPermitDomain
+ * @param permit The permit
+ * @param privateKey The key of the owner of the position
+ * @returns The permit options to exit the position
+ */
+func SignNFTPermit(domain *PermitDomain, permit *NFTPermitData, privateKey *ecdsa.PrivateKey) (*NFTPermitOptions, error) {
+	signature, err := SignTypedData(GetNFTPermitTypedData(domain, permit), privateKey)
+	if err != nil {
+		return nil, err
+	}
+	v, r, s := splitSignature(signature)
+	return &NFTPermitOptions{
+		V:        uint(v),
+		R:        common.Hash(r).Hex(),
+		S:        common.Hash(s).Hex(),
+		Deadline: permit.Deadline,
+		Spender:  permit.Spender.Hex(),
+	}, nil
+}
@@ -10,7 +10,9 @@ import (
 	core "github.com/daoleno/uniswap-sdk-core/entities"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var (
@@ -299,6 +301,51 @@ func TestRemoveCallParameters(t *testing.T) {
 	assert.Equal(t, "0x00", utils.ToHex(params.Value))
 }
 
+func TestSignNFTPermit(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	positionManager := common.HexToAddress("0xC36442b4a4522E871399CD717aBDD847Ab11FE88")
+	domain := NewNFTPermitDomain(big.NewInt(1), positionManager)
+	permit := &NFTPermitData{
+		Spender:  recipientT,
+		TokenID:  tokenIDT,
+		Nonce:    big.NewInt(2),
+		Deadline: deadlineT,
+	}
+	typedData := GetNFTPermitTypedData(domain, permit)
+	digest, err := HashTypedData(typedData)
+	require.NoError(t, err)
+	assert.Equal(t, validatedDigest(t, typedData), digest)
+
+	opts, err := SignNFTPermit(domain, permit, key)
+	require.NoError(t, err)
+	r := common.HexToHash(opts.R)
+	s := common.HexToHash(opts.S)
+	assert.Equal(t, crypto.PubkeyToAddress(key.PublicKey), recoverSigner(t, digest, append(append(r[:], s[:]...), byte(opts.V))))
+
+	// the signed permit is the first call of the exit
+	pos, err := entities.NewPosition(pool01T, big.NewInt(100), -constants.TickSpacings[constants.FeeMedium], constants.TickSpacings[constants.FeeMedium])
+	require.NoError(t, err)
+	params, err := RemoveCallParameters(pos, &RemoveLiquidityOptions{
+		TokenID:             tokenIDT,
+		LiquidityPercentage: core.NewPercent(big.NewInt(1), big.NewInt(1)),
+		SlippageTolerance:   slippageToleranceT,
+		Deadline:            deadlineT,
+		Permit:              opts,
+		CollectOptions: &CollectOptions{
+			ExpectedCurrencyOwed0: core.FromRawAmount(token0T, big.NewInt(0)),
+			ExpectedCurrencyOwed1: core.FromRawAmount(token1T, big.NewInt(0)),
+			Recipient:             recipientT,
+		},
+	})
+	require.NoError(t, err)
+	calls, err := newArguments(mustNewType("bytes[]")).Unpack(params.Calldata[4:])
+	require.NoError(t, err)
+	permitCall, err := getNonFungiblePositionManagerABI().Pack("permit", recipientT, tokenIDT, deadlineT, uint8(opts.V), r, s)
+	require.NoError(t, err)
+	assert.Equal(t, permitCall, calls[0].([][]byte)[0])
+}
+
 func TestSafeTransferFromParameters(t *testing.T) {
 	// succeeds no data param
 	opts := &SafeTransferOptions{
@@ -0,0 +1,176 @@
+package periphery
+
+import (
+	"errors"
+
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/constants"
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/entities"
+	core "github.com/daoleno/uniswap-sdk-core/entities"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	ErrInvalidPath      = errors.New("invalid path")
+	ErrPoolNotFound     = errors.New("pool not found")
+	ErrPathPoolMismatch = errors.New("pool doesn't match the path")
+)
+
+// The offsets of Path.sol
+const (
+	// the length of the bytes encoded address
+	pathAddrSize = common.AddressLength
+	// the length of the bytes encoded fee
+	pathFeeSize = 3
+	// the offset of a single token address and pool fee
+	pathNextOffset = pathAddrSize + pathFeeSize
+	// the offset of an encoded pool key
+	pathPopOffset = pathNextOffset + pathAddrSize
+	// the minimum length of an encoding that contains 2 or more pools
+	pathMultiplePoolsMinLength = pathPopOffset + pathNextOffset
+)
+
+// DecodedPath is a path of pools, in the order it is encoded, i.e. from the output token for exact output swaps
+type DecodedPath struct {
+	Tokens []common.Address
+	Fees   []constants.FeeAmount
+}
+
+// PoolLookup returns the pool of the tokens and fee, in any order of the tokens
+type PoolLookup func(tokenA, tokenB common.Address, fee constants.FeeAmount) (*entities.Pool, error)
+
+/**
+ * Validates the length of a path, which must be a token followed by one or more fee and token
+ * @param path The encoded path
+ */
+func ValidatePath(path []byte) error {
+	if len(path) < pathPopOffset || (len(path)-pathAddrSize)%pathNextOffset != 0 {
+		return ErrInvalidPath
+	}
+	return nil
+}
+
+/**
+ * Decodes a path encoded by EncodeRouteToPath
+ * @param path The encoded path
+ * @returns The tokens of the path and the fees of the pools between them
+ */
+func DecodePath(path []byte) ([]common.Address, []constants.FeeAmount, error) {
+	if err := ValidatePath(path); err != nil {
+		return nil, nil, err
+	}
+	tokens := []common.Address{common.BytesToAddress(path[:pathAddrSize])}
+	var fees []constants.FeeAmount
+	for i := pathAddrSize; i < len(path); i += pathNextOffset {
+		fees = append(fees, constants.FeeAmount(toUint24(path[i:])))
+		tokens = append(tokens, common.BytesToAddress(path[i+pathFeeSize:i+pathNextOffset]))
+	}
+	return tokens, fees, nil
+}
+
+/**
+ * Returns true iff the path contains two or more pools
+ * @param path The encoded swap path
+ */
+func HasMultiplePools(path []byte) bool {
+	return len(path) >= pathMultiplePoolsMinLength
+}
+
+/**
+ * Returns the number of pools in the path
+ * @param path The encoded swap path
+ */
+func NumPools(path []byte) int {
+	if len(path) < pathAddrSize {
+		return 0
+	}
+	// ignore the first token address. From then on every fee and token offset indicates a pool.
+	return (len(path) - pathAddrSize) / pathNextOffset
+}
+
+/**
+ * Decodes the first pool in the path
+ * @param path The encoded swap path
+ * @returns The first token of the pool, the second token and the fee of the pool
+ */
+func DecodeFirstPool(path []byte) (tokenA, tokenB common.Address, fee constants.FeeAmount, err error) {
+	if len(path) < pathPopOffset {
+		return common.Address{}, common.Address{}, 0, ErrInvalidPath
+	}
+	tokenA = common.BytesToAddress(path[:pathAddrSize])
+	fee = constants.FeeAmount(toUint24(path[pathAddrSize:]))
+	tokenB = common.BytesToAddress(path[pathNextOffset:pathPopOffset])
+	return tokenA, tokenB, fee, nil
+}
+
+/**
+ * Gets the segment corresponding to the first pool in the path
+ * @param path The encoded swap path
+ */
+func GetFirstPool(path []byte) ([]byte, error) {
+	if len(path) < pathPopOffset {
+		return nil, ErrInvalidPath
+	}
+	return path[:pathPopOffset], nil
+}
+
+/**
+ * Skips a token and fee element from the path
+ * @param path The encoded swap path
+ * @returns The remaining token and fee elements in the path
+ */
+func SkipToken(path []byte) ([]byte, error) {
+	if len(path) < pathNextOffset {
+		return nil, ErrInvalidPath
+	}
+	return path[pathNextOffset:], nil
+}
+
+/**
+ * Rebuilds the route of a path
+ * @param path The encoded swap path
+ * @param exactOutput Whether the path is encoded in reverse, for exact output swaps
+ * @param lookup Returns the pools of the path
+ * @returns The route of the path, between the tokens at its ends, which are wrapped if native
+ */
+func RouteFromPath(path []byte, exactOutput bool, lookup PoolLookup) (*entities.Route, error) {
+	tokens, fees, err := DecodePath(path)
+	if err != nil {
+		return nil, err
+	}
+	if exactOutput {
+		reverse(tokens)
+		reverse(fees)
+	}
+
+	pools := make([]*entities.Pool, len(fees))
+	for i, fee := range fees {
+		pool, err := lookup(tokens[i], tokens[i+1], fee)
+		if err != nil {
+			return nil, err
+		}
+		if pool == nil {
+			return nil, ErrPoolNotFound
+		}
+		if pool.Fee != fee || !poolInvolves(pool, tokens[i]) || !poolInvolves(pool, tokens[i+1]) {
+			return nil, ErrPathPoolMismatch
+		}
+		pools[i] = pool
+	}
+	return entities.NewRoute(pools, poolToken(pools[0], tokens[0]), poolToken(pools[len(pools)-1], tokens[len(tokens)-1]))
+}
+
+func poolInvolves(pool *entities.Pool, token common.Address) bool {
+	return pool.Token0.Address == token || pool.Token1.Address == token
+}
+
+func poolToken(pool *entities.Pool, token common.Address) *core.Token {
+	if pool.Token0.Address == token {
+		return pool.Token0
+	}
+	return pool.Token1
+}
+
+// toUint24 reads the big endian uint24 at the start of the bytes, as BytesLib.toUint24
+func toUint24(b []byte) uint64 {
+	return uint64(b[0])<<16 | uint64(b[1])<<8 | uint64(b[2])
+}
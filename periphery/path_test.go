@@ -0,0 +1,106 @@
+package periphery
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/constants"
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/entities"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPath(t *testing.T) {
+	pool_0_1 := makePool(token0, token1)
+	pool_1_2 := makePool(token1, token2)
+	r, err := entities.NewRoute([]*entities.Pool{pool_0_1, pool_1_2}, token0, token2)
+	require.NoError(t, err)
+	path, err := EncodeRouteToPath(r, false)
+	require.NoError(t, err)
+
+	tokens, fees, err := DecodePath(path)
+	require.NoError(t, err)
+	assert.Equal(t, []common.Address{token0.Address, token1.Address, token2.Address}, tokens)
+	assert.Equal(t, []constants.FeeAmount{constants.FeeMedium, constants.FeeMedium}, fees)
+	assert.Equal(t, 2, NumPools(path))
+	assert.True(t, HasMultiplePools(path))
+
+	tokenA, tokenB, fee, err := DecodeFirstPool(path)
+	require.NoError(t, err)
+	assert.Equal(t, token0.Address, tokenA)
+	assert.Equal(t, token1.Address, tokenB)
+	assert.Equal(t, constants.FeeMedium, fee)
+	first, err := GetFirstPool(path)
+	require.NoError(t, err)
+	assert.Equal(t, path[:43], first)
+
+	// the rest of the path is the second pool
+	rest, err := SkipToken(path)
+	require.NoError(t, err)
+	assert.Equal(t, 1, NumPools(rest))
+	assert.False(t, HasMultiplePools(rest))
+	tokenA, tokenB, _, err = DecodeFirstPool(rest)
+	require.NoError(t, err)
+	assert.Equal(t, token1.Address, tokenA)
+	assert.Equal(t, token2.Address, tokenB)
+	rest, err = SkipToken(rest)
+	require.NoError(t, err)
+	assert.Equal(t, 0, NumPools(rest))
+	_, err = GetFirstPool(rest)
+	assert.ErrorIs(t, err, ErrInvalidPath)
+
+	// malformed lengths
+	for _, invalid := range [][]byte{nil, path[:20], path[:42], path[:len(path)-1], append(path, 0)} {
+		assert.ErrorIs(t, ValidatePath(invalid), ErrInvalidPath)
+		_, _, err = DecodePath(invalid)
+		assert.ErrorIs(t, err, ErrInvalidPath)
+	}
+	_, _, _, err = DecodeFirstPool(path[:42])
+	assert.ErrorIs(t, err, ErrInvalidPath)
+	_, err = SkipToken(path[:22])
+	assert.ErrorIs(t, err, ErrInvalidPath)
+}
+
+func TestRouteFromPath(t *testing.T) {
+	pool_0_1 := makePool(token0, token1)
+	pool_1_2 := makePool(token1, token2)
+	pools := []*entities.Pool{pool_0_1, pool_1_2}
+	lookup := func(tokenA, tokenB common.Address, fee constants.FeeAmount) (*entities.Pool, error) {
+		for _, pool := range pools {
+			if pool.Fee == fee && poolInvolves(pool, tokenA) && poolInvolves(pool, tokenB) {
+				return pool, nil
+			}
+		}
+		return nil, nil
+	}
+
+	r, err := entities.NewRoute(pools, token0, token2)
+	require.NoError(t, err)
+	for _, exactOutput := range []bool{false, true} {
+		path, err := EncodeRouteToPath(r, exactOutput)
+		require.NoError(t, err)
+		route, err := RouteFromPath(path, exactOutput, lookup)
+		require.NoError(t, err)
+		assert.Equal(t, r.Pools, route.Pools)
+		assert.Equal(t, r.TokenPath, route.TokenPath)
+		assert.True(t, route.Input.Equal(token0))
+		assert.True(t, route.Output.Equal(token2))
+	}
+
+	// the pools must be found and match the path
+	path, err := EncodeRouteToPath(r, false)
+	require.NoError(t, err)
+	pools = pools[:1]
+	_, err = RouteFromPath(path, false, lookup)
+	assert.ErrorIs(t, err, ErrPoolNotFound)
+	_, err = RouteFromPath(path, false, func(tokenA, tokenB common.Address, fee constants.FeeAmount) (*entities.Pool, error) {
+		return pool_0_1, nil
+	})
+	assert.ErrorIs(t, err, ErrPathPoolMismatch)
+	errLookup := errors.New("lookup")
+	_, err = RouteFromPath(path, false, func(tokenA, tokenB common.Address, fee constants.FeeAmount) (*entities.Pool, error) {
+		return nil, errLookup
+	})
+	assert.ErrorIs(t, err, errLookup)
+}
@@ -0,0 +1,53 @@
+package periphery
+
+import (
+	"math/big"
+
+	"github.com/daoleno/uniswap-sdk-core/entities"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// The payments of SwapRouter02 (IPeripheryPaymentsWithFeeExtended), the recipient is optional and defaults to the
+// sender of the call
+
+func EncodeUnwrapWETH9Extended(amountMinimum *big.Int, recipient *common.Address, feeOptions *FeeOptions) ([]byte, error) {
+	abi := GetABI(swapRouter02ABI)
+	if recipient != nil {
+		if feeOptions != nil {
+			return packMethod(abi, "unwrapWETH9WithFee(uint256,address,uint256,address)", amountMinimum, *recipient, encodeFeeBips(feeOptions.Fee), feeOptions.Recipient)
+		}
+		return packMethod(abi, "unwrapWETH9(uint256,address)", amountMinimum, *recipient)
+	}
+
+	if feeOptions != nil {
+		return packMethod(abi, "unwrapWETH9WithFee(uint256,uint256,address)", amountMinimum, encodeFeeBips(feeOptions.Fee), feeOptions.Recipient)
+	}
+	return packMethod(abi, "unwrapWETH9(uint256)", amountMinimum)
+}
+
+func EncodeSweepTokenExtended(token *entities.Token, amountMinimum *big.Int, recipient *common.Address, feeOptions *FeeOptions) ([]byte, error) {
+	abi := GetABI(swapRouter02ABI)
+	if recipient != nil {
+		if feeOptions != nil {
+			return packMethod(abi, "sweepTokenWithFee(address,uint256,address,uint256,address)", token.Address, amountMinimum, *recipient, encodeFeeBips(feeOptions.Fee), feeOptions.Recipient)
+		}
+		return packMethod(abi, "sweepToken(address,uint256,address)", token.Address, amountMinimum, *recipient)
+	}
+
+	if feeOptions != nil {
+		return packMethod(abi, "sweepTokenWithFee(address,uint256,uint256,address)", token.Address, amountMinimum, encodeFeeBips(feeOptions.Fee), feeOptions.Recipient)
+	}
+	return packMethod(abi, "sweepToken(address,uint256)", token.Address, amountMinimum)
+}
+
+// EncodePull transfers amount of token from the sender to the router
+func EncodePull(token *entities.Token, amount *big.Int) ([]byte, error) {
+	abi := GetABI(swapRouter02ABI)
+	return abi.Pack("pull", token.Address, amount)
+}
+
+// EncodeWrapETH wraps amount of the ETH held by the router to WETH
+func EncodeWrapETH(amount *big.Int) ([]byte, error) {
+	abi := GetABI(swapRouter02ABI)
+	return abi.Pack("wrapETH", amount)
+}
@@ -0,0 +1,63 @@
+package periphery
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeUnwrapWETH9Extended(t *testing.T) {
+	// with a recipient, the same as the original router
+	calldata, err := EncodeUnwrapWETH9Extended(amount, &recipient, nil)
+	assert.NoError(t, err)
+	expected, err := EncodeUnwrapWETH9(amount, recipient, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, calldata)
+
+	calldata, err = EncodeUnwrapWETH9Extended(amount, &recipient, feeOptions)
+	assert.NoError(t, err)
+	expected, err = EncodeUnwrapWETH9(amount, recipient, feeOptions)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, calldata)
+
+	// without a recipient
+	calldata, err = EncodeUnwrapWETH9Extended(amount, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "0x49616997000000000000000000000000000000000000000000000000000000000000007b", hexutil.Encode(calldata))
+
+	calldata, err = EncodeUnwrapWETH9Extended(amount, nil, feeOptions)
+	assert.NoError(t, err)
+	assert.Equal(t, selector("unwrapWETH9WithFee(uint256,uint256,address)"), calldata[:4])
+	assert.Equal(t, "0x000000000000000000000000000000000000000000000000000000000000007b000000000000000000000000000000000000000000000000000000000000000a0000000000000000000000000000000000000000000000000000000000000009", hexutil.Encode(calldata[4:]))
+}
+
+func TestEncodeSweepTokenExtended(t *testing.T) {
+	calldata, err := EncodeSweepTokenExtended(token, amount, &recipient, nil)
+	assert.NoError(t, err)
+	expected, err := EncodeSweepToken(token, amount, recipient, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, calldata)
+
+	calldata, err = EncodeSweepTokenExtended(token, amount, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, selector("sweepToken(address,uint256)"), calldata[:4])
+	assert.Equal(t, "0x0000000000000000000000000000000000000000000000000000000000000001000000000000000000000000000000000000000000000000000000000000007b", hexutil.Encode(calldata[4:]))
+
+	calldata, err = EncodeSweepTokenExtended(token, amount, nil, feeOptions)
+	assert.NoError(t, err)
+	assert.Equal(t, selector("sweepTokenWithFee(address,uint256,uint256,address)"), calldata[:4])
+}
+
+func TestEncodePull(t *testing.T) {
+	calldata, err := EncodePull(token, amount)
+	assert.NoError(t, err)
+	assert.Equal(t, "0xf2d5d56b0000000000000000000000000000000000000000000000000000000000000001000000000000000000000000000000000000000000000000000000000000007b", hexutil.Encode(calldata))
+}
+
+func TestEncodeWrapETH(t *testing.T) {
+	calldata, err := EncodeWrapETH(big.NewInt(123))
+	assert.NoError(t, err)
+	assert.Equal(t, "0x1c58db4f000000000000000000000000000000000000000000000000000000000000007b", hexutil.Encode(calldata))
+}
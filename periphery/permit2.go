@@ -0,0 +1,325 @@
+package periphery
+
+import (
+	"crypto/ecdsa"
+	_ "embed"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+//go:embed contracts/Permit2.sol/Permit2.json
+var permit2ABI []byte
+
+var (
+	ErrInvalidNonce = errors.New("invalid nonce")
+
+	// Permit2Address is the address Permit2 is deployed at on every chain
+	Permit2Address = common.HexToAddress("0x000000000022D473030F116dDEE9F6B43aC78BA3")
+)
+
+// The Permit2 allowance transfer permits (IAllowanceTransfer)
+
+type PermitDetails struct {
+	Token      common.Address // ERC20 token address
+	Amount     *big.Int       // the maximum amount allowed to spend, uint160
+	Expiration *big.Int       // timestamp at which a spender's token allowances become invalid, uint48
+	Nonce      *big.Int       // an incrementing value indexed per owner, token, and spender for each signature, uint48
+}
+
+type PermitSingle struct {
+	Details     PermitDetails  // the permit data for a single token allowance
+	Spender     common.Address // address permissioned on the allowed tokens
+	SigDeadline *big.Int       // deadline on the permit signature
+}
+
+type PermitBatch struct {
+	Details     []PermitDetails // the permit data for multiple token allowances
+	Spender     common.Address  // address permissioned on the allowed tokens
+	SigDeadline *big.Int        // deadline on the permit signature
+}
+
+// Permit2Permit is a signed permit of a single token allowance
+type Permit2Permit struct {
+	PermitSingle
+	Signature []byte
+}
+
+// Permit2PermitBatch is a signed permit of multiple token allowances
+type Permit2PermitBatch struct {
+	PermitBatch
+	Signature []byte
+}
+
+// The Permit2 signature transfer permits (ISignatureTransfer)
+
+type TokenPermissions struct {
+	Token  common.Address // ERC20 token address
+	Amount *big.Int       // the maximum amount that can be transferred
+}
+
+type PermitTransferFrom struct {
+	Permitted TokenPermissions
+	Spender   common.Address // the address allowed to transfer the tokens, the sender of the transfer, only signed
+	Nonce     *big.Int       // a unique value for every token owner's signature to prevent signature replays
+	Deadline  *big.Int       // deadline on the permit signature
+}
+
+type SignatureTransferDetails struct {
+	To              common.Address // recipient address
+	RequestedAmount *big.Int       // spender requested amount
+}
+
+var (
+	permit2DomainType = []apitypes.Type{
+		{Name: "name", Type: "string"},
+		{Name: "chainId", Type: "uint256"},
+		{Name: "verifyingContract", Type: "address"},
+	}
+	permitDetailsType = []apitypes.Type{
+		{Name: "token", Type: "address"},
+		{Name: "amount", Type: "uint160"},
+		{Name: "expiration", Type: "uint48"},
+		{Name: "nonce", Type: "uint48"},
+	}
+	tokenPermissionsType = []apitypes.Type{
+		{Name: "token", Type: "address"},
+		{Name: "amount", Type: "uint256"},
+	}
+)
+
+func permit2Domain(permit2 common.Address, chainId *big.Int) apitypes.TypedDataDomain {
+	return apitypes.TypedDataDomain{
+		Name:              "Permit2",
+		ChainId:           typedDataInt(chainId),
+		VerifyingContract: permit2.Hex(),
+	}
+}
+
+func (d *PermitDetails) typedDataMessage() map[string]interface{} {
+	return map[string]interface{}{
+		"token":      d.Token.Hex(),
+		"amount":     typedDataInt(d.Amount),
+		"expiration": typedDataInt(d.Expiration),
+		"nonce":      typedDataInt(d.Nonce),
+	}
+}
+
+/**
+ * Returns the EIP-712 typed data of a permit of a single token allowance
+ * @param permit The permit
+ * @param permit2 The address of Permit2, usually Permit2Address
+ * @param chainId The chain id
+ */
+func GetPermitSingleTypedData(permit *PermitSingle, permit2 common.Address, chainId *big.Int) *apitypes.TypedData {
+	return &apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": permit2DomainType,
+			"PermitSingle": {
+				{Name: "details", Type: "PermitDetails"},
+				{Name: "spender", Type: "address"},
+				{Name: "sigDeadline", Type: "uint256"},
+			},
+			"PermitDetails": permitDetailsType,
+		},
+		PrimaryType: "PermitSingle",
+		Domain:      permit2Domain(permit2, chainId),
+		Message: apitypes.TypedDataMessage{
+			"details":     permit.Details.typedDataMessage(),
+			"spender":     permit.Spender.Hex(),
+			"sigDeadline": typedDataInt(permit.SigDeadline),
+		},
+	}
+}
+
+/**
+ * Returns the EIP-712 typed data of a permit of multiple token allowances
+ * @param permit The permit
+ * @param permit2 The address of Permit2, usually Permit2Address
+ * @param chainId The chain id
+ */
+func GetPermitBatchTypedData(permit *PermitBatch, permit2 common.Address, chainId *big.Int) *apitypes.TypedData {
+	details := make([]interface{}, len(permit.Details))
+	for i := range permit.Details {
+		details[i] = permit.Details[i].typedDataMessage()
+	}
+	return &apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": permit2DomainType,
+			"PermitBatch": {
+				{Name: "details", Type: "PermitDetails[]"},
+				{Name: "spender", Type: "address"},
+				{Name: "sigDeadline", Type: "uint256"},
+			},
+			"PermitDetails": permitDetailsType,
+		},
+		PrimaryType: "PermitBatch",
+		Domain:      permit2Domain(permit2, chainId),
+		Message: apitypes.TypedDataMessage{
+			"details":     details,
+			"spender":     permit.Spender.Hex(),
+			"sigDeadline": typedDataInt(permit.SigDeadline),
+		},
+	}
+}
+
+/**
+ * Returns the EIP-712 typed data of a permit of a signature transfer
+ * @param permit The permit
+ * @param permit2 The address of Permit2, usually Permit2Address
+ * @param chainId The chain id
+ */
+func GetPermitTransferFromTypedData(permit *PermitTransferFrom, permit2 common.Address, chainId *big.Int) *apitypes.TypedData {
+	return &apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": permit2DomainType,
+			"PermitTransferFrom": {
+				{Name: "permitted", Type: "TokenPermissions"},
+				{Name: "spender", Type: "address"},
+				{Name: "nonce", Type: "uint256"},
+				{Name: "deadline", Type: "uint256"},
+			},
+			"TokenPermissions": tokenPermissionsType,
+		},
+		PrimaryType: "PermitTransferFrom",
+		Domain:      permit2Domain(permit2, chainId),
+		Message: apitypes.TypedDataMessage{
+			"permitted": map[string]interface{}{
+				"token":  permit.Permitted.Token.Hex(),
+				"amount": typedDataInt(permit.Permitted.Amount),
+			},
+			"spender":  permit.Spender.Hex(),
+			"nonce":    typedDataInt(permit.Nonce),
+			"deadline": typedDataInt(permit.Deadline),
+		},
+	}
+}
+
+/**
+ * Signs a permit of a single token allowance, e.g. for the InputTokenPermit of the Universal Router
+ * @param permit The permit
+ * @param permit2 The address of Permit2, usually Permit2Address
+ * @param chainId The chain id
+ * @param privateKey The key of the owner of the tokens
+ */
+func SignPermitSingle(permit *PermitSingle, permit2 common.Address, chainId *big.Int, privateKey *ecdsa.PrivateKey) (*Permit2Permit, error) {
+	signature, err := SignTypedData(GetPermitSingleTypedData(permit, permit2, chainId), privateKey)
+	if err != nil {
+		return nil, err
+	}
+	return &Permit2Permit{PermitSingle: *permit, Signature: signature}, nil
+}
+
+/**
+ * Signs a permit of multiple token allowances
+ * @param permit The permit
+ * @param permit2 The address of Permit2, usually Permit2Address
+ * @param chainId The chain id
+ * @param privateKey The key of the owner of the tokens
+ */
+func SignPermitBatch(permit *PermitBatch, permit2 common.Address, chainId *big.Int, privateKey *ecdsa.PrivateKey) (*Permit2PermitBatch, error) {
+	signature, err := SignTypedData(GetPermitBatchTypedData(permit, permit2, chainId), privateKey)
+	if err != nil {
+		return nil, err
+	}
+	return &Permit2PermitBatch{PermitBatch: *permit, Signature: signature}, nil
+}
+
+/**
+ * Signs a permit of a signature transfer
+ * @param permit The permit
+ * @param permit2 The address of Permit2, usually Permit2Address
+ * @param chainId The chain id
+ * @param privateKey The key of the owner of the tokens
+ */
+func SignPermitTransferFrom(permit *PermitTransferFrom, permit2 common.Address, chainId *big.Int, privateKey *ecdsa.PrivateKey) ([]byte, error) {
+	return SignTypedData(GetPermitTransferFromTypedData(permit, permit2, chainId), privateKey)
+}
+
+// The signature transfer nonces are unordered, each nonce is a bit of a bitmap of 256 nonces stored by word position
+
+/**
+ * Returns the position of a nonce in the nonce bitmaps of Permit2
+ * @param nonce The signature transfer nonce
+ * @returns The position of the bitmap word and of the bit in the word
+ */
+func NonceBitmapPosition(nonce *big.Int) (*big.Int, uint8) {
+	return new(big.Int).Rsh(nonce, 8), uint8(new(big.Int).And(nonce, big.NewInt(0xff)).Uint64())
+}
+
+/**
+ * Returns the nonce at a position in the nonce bitmaps of Permit2
+ * @param wordPos The position of the bitmap word, at most 248 bits
+ * @param bitPos The position of the bit in the word
+ */
+func BuildNonce(wordPos *big.Int, bitPos uint8) (*big.Int, error) {
+	if wordPos.Sign() < 0 || wordPos.BitLen() > 248 {
+		return nil, ErrInvalidNonce
+	}
+	nonce := new(big.Int).Lsh(wordPos, 8)
+	return nonce.Or(nonce, big.NewInt(int64(bitPos))), nil
+}
+
+/**
+ * Returns whether the nonce at the bit position of a bitmap word is used
+ * @param bitmap The bitmap word, as returned by Permit2.nonceBitmap
+ * @param bitPos The position of the bit in the word
+ */
+func IsNonceUsed(bitmap *big.Int, bitPos uint8) bool {
+	return bitmap.Bit(int(bitPos)) == 1
+}
+
+/**
+ * Returns the first unused nonce of a bitmap word
+ * @param wordPos The position of the bitmap word
+ * @param bitmap The bitmap word, as returned by Permit2.nonceBitmap
+ * @returns The nonce, or ErrInvalidNonce if all the nonces of the word are used
+ */
+func FirstUnusedNonce(wordPos, bitmap *big.Int) (*big.Int, error) {
+	for bitPos := 0; bitPos < 256; bitPos++ {
+		if !IsNonceUsed(bitmap, uint8(bitPos)) {
+			return BuildNonce(wordPos, uint8(bitPos))
+		}
+	}
+	return nil, ErrInvalidNonce
+}
+
+/**
+ * Encodes a call of Permit2.permit for a signed permit of a single token allowance
+ * @param owner The owner of the tokens
+ * @param permit The signed permit
+ */
+func EncodePermit2Permit(owner common.Address, permit *Permit2Permit) ([]byte, error) {
+	abi := GetABI(permit2ABI)
+	return packMethod(abi, "permit(address,((address,uint160,uint48,uint48),address,uint256),bytes)", owner, permit.PermitSingle, permit.Signature)
+}
+
+/**
+ * Encodes a call of Permit2.permit for a signed permit of multiple token allowances
+ * @param owner The owner of the tokens
+ * @param permit The signed permit
+ */
+func EncodePermit2PermitBatch(owner common.Address, permit *Permit2PermitBatch) ([]byte, error) {
+	abi := GetABI(permit2ABI)
+	return packMethod(abi, "permit(address,((address,uint160,uint48,uint48)[],address,uint256),bytes)", owner, permit.PermitBatch, permit.Signature)
+}
+
+/**
+ * Encodes a call of Permit2.permitTransferFrom, sent by the spender of the permit
+ * @param permit The permit
+ * @param transferDetails The recipient and amount of the transfer
+ * @param owner The owner of the tokens
+ * @param signature The signature of the permit by the owner
+ */
+func EncodePermitTransferFrom(permit *PermitTransferFrom, transferDetails *SignatureTransferDetails, owner common.Address, signature []byte) ([]byte, error) {
+	abi := GetABI(permit2ABI)
+	// the spender is the sender of the call, so it isn't part of the encoded permit
+	encodedPermit := struct {
+		Permitted TokenPermissions
+		Nonce     *big.Int
+		Deadline  *big.Int
+	}{permit.Permitted, permit.Nonce, permit.Deadline}
+	return abi.Pack("permitTransferFrom", encodedPermit, transferDetails, owner, signature)
+}
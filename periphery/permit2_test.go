@@ -0,0 +1,173 @@
+package periphery
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	permitDetails = PermitDetails{
+		Token:      token0.Address,
+		Amount:     new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 160), big.NewInt(1)),
+		Expiration: big.NewInt(1700000000),
+		Nonce:      big.NewInt(7),
+	}
+	permitSpender = common.HexToAddress("0x3fC91A3afd70395Cd496C647d5a6CC9D4B2b7FAD")
+)
+
+// recoverSigner returns the address that signed the typed data
+func recoverSigner(t *testing.T, digest, signature []byte) common.Address {
+	sig := append([]byte{}, signature...)
+	sig[64] -= 27
+	pub, err := crypto.SigToPub(digest, sig)
+	require.NoError(t, err)
+	return crypto.PubkeyToAddress(*pub)
+}
+
+func TestPermitSingleTypedData(t *testing.T) {
+	permit := &PermitSingle{Details: permitDetails, Spender: permitSpender, SigDeadline: big.NewInt(1700000000)}
+	typedData := GetPermitSingleTypedData(permit, Permit2Address, big.NewInt(1))
+	digest, err := HashTypedData(typedData)
+	require.NoError(t, err)
+
+	// the hashes of the Permit2 contract
+	domainArgs := newArguments(mustNewType("bytes32"), mustNewType("bytes32"), uint256Type, addressType)
+	domain, err := domainArgs.Pack(
+		crypto.Keccak256Hash([]byte("EIP712Domain(string name,uint256 chainId,address verifyingContract)")),
+		crypto.Keccak256Hash([]byte("Permit2")), big.NewInt(1), Permit2Address)
+	require.NoError(t, err)
+	detailsArgs := newArguments(mustNewType("bytes32"), addressType, uint160Type, uint256Type, uint256Type)
+	details, err := detailsArgs.Pack(
+		crypto.Keccak256Hash([]byte("PermitDetails(address token,uint160 amount,uint48 expiration,uint48 nonce)")),
+		permitDetails.Token, permitDetails.Amount, permitDetails.Expiration, permitDetails.Nonce)
+	require.NoError(t, err)
+	permitArgs := newArguments(mustNewType("bytes32"), mustNewType("bytes32"), addressType, uint256Type)
+	message, err := permitArgs.Pack(
+		crypto.Keccak256Hash([]byte("PermitSingle(PermitDetails details,address spender,uint256 sigDeadline)PermitDetails(address token,uint160 amount,uint48 expiration,uint48 nonce)")),
+		crypto.Keccak256Hash(details), permitSpender, big.NewInt(1700000000))
+	require.NoError(t, err)
+	assert.Equal(t, crypto.Keccak256([]byte("\x19\x01"), crypto.Keccak256(domain), crypto.Keccak256(message)), digest)
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	signed, err := SignPermitSingle(permit, Permit2Address, big.NewInt(1), key)
+	require.NoError(t, err)
+	assert.Equal(t, *permit, signed.PermitSingle)
+	assert.Len(t, signed.Signature, 65)
+	assert.Equal(t, crypto.PubkeyToAddress(key.PublicKey), recoverSigner(t, digest, signed.Signature))
+
+	// the digest depends on the chain
+	other, err := HashTypedData(GetPermitSingleTypedData(permit, Permit2Address, big.NewInt(5)))
+	require.NoError(t, err)
+	assert.NotEqual(t, digest, other)
+}
+
+func TestPermitBatchTypedData(t *testing.T) {
+	second := permitDetails
+	second.Token = token1.Address
+	permit := &PermitBatch{Details: []PermitDetails{permitDetails, second}, Spender: permitSpender, SigDeadline: big.NewInt(1700000000)}
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	signed, err := SignPermitBatch(permit, Permit2Address, big.NewInt(1), key)
+	require.NoError(t, err)
+	digest, err := HashTypedData(GetPermitBatchTypedData(permit, Permit2Address, big.NewInt(1)))
+	require.NoError(t, err)
+	assert.Equal(t, crypto.PubkeyToAddress(key.PublicKey), recoverSigner(t, digest, signed.Signature))
+
+	// a batch of one isn't a single permit
+	single, err := HashTypedData(GetPermitSingleTypedData(&PermitSingle{Details: permitDetails, Spender: permitSpender, SigDeadline: big.NewInt(1700000000)}, Permit2Address, big.NewInt(1)))
+	require.NoError(t, err)
+	batch, err := HashTypedData(GetPermitBatchTypedData(&PermitBatch{Details: []PermitDetails{permitDetails}, Spender: permitSpender, SigDeadline: big.NewInt(1700000000)}, Permit2Address, big.NewInt(1)))
+	require.NoError(t, err)
+	assert.NotEqual(t, single, batch)
+
+	calldata, err := EncodePermit2PermitBatch(recipient, signed)
+	require.NoError(t, err)
+	assert.Equal(t, selector("permit(address,((address,uint160,uint48,uint48)[],address,uint256),bytes)"), calldata[:4])
+
+	planner := NewRoutePlanner()
+	require.NoError(t, planner.AddCommand(CommandPermit2PermitBatch, false, signed.PermitBatch, signed.Signature))
+	assert.Equal(t, []byte{0x03}, planner.Commands)
+}
+
+func TestPermitTransferFromTypedData(t *testing.T) {
+	permit := &PermitTransferFrom{
+		Permitted: TokenPermissions{Token: token0.Address, Amount: big.NewInt(1000)},
+		Spender:   permitSpender,
+		Nonce:     big.NewInt(300),
+		Deadline:  big.NewInt(1700000000),
+	}
+	typedData := GetPermitTransferFromTypedData(permit, Permit2Address, big.NewInt(1))
+	digest, err := HashTypedData(typedData)
+	require.NoError(t, err)
+
+	// all the types of the signature transfer are supported by apitypes
+	domain, err := typedData.HashStruct("EIP712Domain", typedData.Domain.Map())
+	require.NoError(t, err)
+	message, err := typedData.HashStruct(typedData.PrimaryType, typedData.Message)
+	require.NoError(t, err)
+	assert.Equal(t, crypto.Keccak256([]byte("\x19\x01"), domain, message), digest)
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	signature, err := SignPermitTransferFrom(permit, Permit2Address, big.NewInt(1), key)
+	require.NoError(t, err)
+	owner := crypto.PubkeyToAddress(key.PublicKey)
+	assert.Equal(t, owner, recoverSigner(t, digest, signature))
+
+	calldata, err := EncodePermitTransferFrom(permit, &SignatureTransferDetails{To: recipient, RequestedAmount: big.NewInt(500)}, owner, signature)
+	require.NoError(t, err)
+	assert.Equal(t, selector("permitTransferFrom(((address,uint256),uint256,uint256),(address,uint256),address,bytes)"), calldata[:4])
+	permit2 := GetABI(permit2ABI)
+	args, err := permit2.Methods["permitTransferFrom"].Inputs.Unpack(calldata[4:])
+	require.NoError(t, err)
+	assert.Equal(t, owner, args[2])
+	assert.Equal(t, signature, args[3])
+}
+
+func TestEncodePermit2Permit(t *testing.T) {
+	permit := &Permit2Permit{
+		PermitSingle: PermitSingle{Details: permitDetails, Spender: permitSpender, SigDeadline: big.NewInt(1700000000)},
+		Signature:    []byte{0x01},
+	}
+	calldata, err := EncodePermit2Permit(recipient, permit)
+	require.NoError(t, err)
+	assert.Equal(t, selector("permit(address,((address,uint160,uint48,uint48),address,uint256),bytes)"), calldata[:4])
+	// the same input as the Universal Router command, after the owner
+	input, err := commandArguments[CommandPermit2Permit].Pack(permit.PermitSingle, permit.Signature)
+	require.NoError(t, err)
+	args, err := abi.Arguments{{Type: addressType}, {Type: permitSingleType}, {Type: bytesType}}.Unpack(calldata[4:])
+	require.NoError(t, err)
+	repacked, err := commandArguments[CommandPermit2Permit].Pack(args[1], args[2])
+	require.NoError(t, err)
+	assert.Equal(t, input, repacked)
+}
+
+func TestNonceBitmap(t *testing.T) {
+	nonce := new(big.Int).Add(new(big.Int).Lsh(big.NewInt(3), 8), big.NewInt(5))
+	wordPos, bitPos := NonceBitmapPosition(nonce)
+	assert.Equal(t, big.NewInt(3), wordPos)
+	assert.Equal(t, uint8(5), bitPos)
+	built, err := BuildNonce(wordPos, bitPos)
+	require.NoError(t, err)
+	assert.Equal(t, nonce, built)
+	_, err = BuildNonce(new(big.Int).Lsh(big.NewInt(1), 248), 0)
+	assert.ErrorIs(t, err, ErrInvalidNonce)
+
+	bitmap := big.NewInt(0b1011)
+	assert.True(t, IsNonceUsed(bitmap, 0))
+	assert.False(t, IsNonceUsed(bitmap, 2))
+	unused, err := FirstUnusedNonce(wordPos, bitmap)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(3<<8+2), unused)
+
+	full := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+	_, err = FirstUnusedNonce(wordPos, full)
+	assert.ErrorIs(t, err, ErrInvalidNonce)
+}
@@ -16,21 +16,51 @@ import (
 //go:embed contracts/lens/Quoter.sol/Quoter.json
 var quoterABI []byte
 
+//go:embed contracts/lens/QuoterV2.sol/QuoterV2.json
+var quoterV2ABI []byte
+
 var ErrMultihopPriceLimit = errors.New("MULTIHOP_PRICE_LIMIT")
 
 // Optional arguments to send to the quoter.
 type QuoteOptions struct {
 	SqrtPriceLimitX96 *big.Int // The optional price limit for the trade.
+	UseQuoterV2       bool     // Whether to call QuoterV2, which also returns the prices after the swaps, the initialized ticks crossed and a gas estimate
+}
+
+// The struct params of the single pool quotes of QuoterV2
+
+type QuoteExactInputSingleParams struct {
+	TokenIn           common.Address
+	TokenOut          common.Address
+	AmountIn          *big.Int
+	Fee               *big.Int
+	SqrtPriceLimitX96 *big.Int
+}
+
+type QuoteExactOutputSingleParams struct {
+	TokenIn           common.Address
+	TokenOut          common.Address
+	Amount            *big.Int
+	Fee               *big.Int
+	SqrtPriceLimitX96 *big.Int
+}
+
+// QuoteResult is the decoded result of a quote. Only the amount is returned by QuoterV1.
+type QuoteResult struct {
+	Amount                      *big.Int   // The amount out of an exact input quote, or the amount in of an exact output quote
+	SqrtPriceX96AfterList       []*big.Int // The price of each pool after the swap, in the order of the path
+	InitializedTicksCrossedList []uint32   // The number of initialized ticks crossed in each pool, in the order of the path
+	GasEstimate                 *big.Int   // The estimated gas of the swap
 }
 
 /**
- * Represents the Uniswap V3 QuoterV1 contract with a method for returning the formatted
+ * Represents the Uniswap V3 QuoterV1 and QuoterV2 contracts with a method for returning the formatted
  * calldata needed to call the quoter contract.
  */
 
 /**
- * Produces the on-chain method name of the appropriate function within QuoterV2,
- * and the relevant hex encoded parameters.
+ * Produces the on-chain method name of the appropriate function within QuoterV1, or QuoterV2 if
+ * `options.UseQuoterV2` is set, and the relevant hex encoded parameters.
  * @template TInput The input token, either Ether or an ERC-20
  * @template TOutput The output token, either Ether or an ERC-20
  * @param route The swap route, a list of pools through which a swap can occur
@@ -46,27 +76,51 @@ func QuoteCallParameters(
 ) (*utils.MethodParameters, error) {
 	singleHop := len(route.Pools) == 1
 	quoteAmount := amount.Quotient()
+	useQuoterV2 := options != nil && options.UseQuoterV2
 	abi := GetABI(quoterABI)
+	if useQuoterV2 {
+		abi = GetABI(quoterV2ABI)
+	}
 	var (
 		calldata []byte
 		err      error
 	)
 	sqrtPriceLimitX96 := big.NewInt(0)
-	if options != nil {
+	if options != nil && options.SqrtPriceLimitX96 != nil {
 		sqrtPriceLimitX96 = options.SqrtPriceLimitX96
 	}
 
 	if singleHop {
-		if tradeType == core.ExactInput {
-			calldata, err = abi.Pack("quoteExactInputSingle", route.TokenPath[0].Address, route.TokenPath[1].Address, big.NewInt(int64(route.Pools[0].Fee)), quoteAmount, sqrtPriceLimitX96)
-		} else {
-			calldata, err = abi.Pack("quoteExactOutputSingle", route.TokenPath[0].Address, route.TokenPath[1].Address, big.NewInt(int64(route.Pools[0].Fee)), quoteAmount, sqrtPriceLimitX96)
+		tokenIn := route.TokenPath[0].Address
+		tokenOut := route.TokenPath[1].Address
+		fee := big.NewInt(int64(route.Pools[0].Fee))
+		switch {
+		case useQuoterV2 && tradeType == core.ExactInput:
+			calldata, err = abi.Pack("quoteExactInputSingle", &QuoteExactInputSingleParams{
+				TokenIn:           tokenIn,
+				TokenOut:          tokenOut,
+				AmountIn:          quoteAmount,
+				Fee:               fee,
+				SqrtPriceLimitX96: sqrtPriceLimitX96,
+			})
+		case useQuoterV2:
+			calldata, err = abi.Pack("quoteExactOutputSingle", &QuoteExactOutputSingleParams{
+				TokenIn:           tokenIn,
+				TokenOut:          tokenOut,
+				Amount:            quoteAmount,
+				Fee:               fee,
+				SqrtPriceLimitX96: sqrtPriceLimitX96,
+			})
+		case tradeType == core.ExactInput:
+			calldata, err = abi.Pack("quoteExactInputSingle", tokenIn, tokenOut, fee, quoteAmount, sqrtPriceLimitX96)
+		default:
+			calldata, err = abi.Pack("quoteExactOutputSingle", tokenIn, tokenOut, fee, quoteAmount, sqrtPriceLimitX96)
 		}
 		if err != nil {
 			return nil, err
 		}
 	} else {
-		if options != nil && sqrtPriceLimitX96 != big.NewInt(0) {
+		if sqrtPriceLimitX96.Sign() != 0 {
 			return nil, ErrMultihopPriceLimit
 		}
 		path, err := EncodeRouteToPath(route, tradeType == core.ExactOutput)
@@ -88,6 +142,49 @@ func QuoteCallParameters(
 	}, nil
 }
 
+/**
+ * Decodes the result of a quote of QuoterV1 or QuoterV2. The quoter is told by the selector of the call, or by the
+ * length of the result for the path quotes, whose selectors are the same in both quoters.
+ * @param calldata The calldata of the quote, as produced by QuoteCallParameters
+ * @param output The data returned by the eth_call of the quote
+ */
+func DecodeQuoteResult(calldata []byte, output []byte) (*QuoteResult, error) {
+	if len(calldata) < 4 {
+		return nil, ErrInvalidCalldata
+	}
+	var err error
+	for _, quoter := range [][]byte{quoterV2ABI, quoterABI} {
+		abi := GetABI(quoter)
+		method, methodErr := abi.MethodById(calldata[:4])
+		if methodErr != nil {
+			continue
+		}
+		var values []interface{}
+		values, err = method.Outputs.Unpack(output)
+		if err != nil {
+			continue
+		}
+		result := &QuoteResult{Amount: values[0].(*big.Int)}
+		if len(values) == 1 {
+			return result, nil
+		}
+		switch sqrtPriceX96After := values[1].(type) {
+		case []*big.Int:
+			result.SqrtPriceX96AfterList = sqrtPriceX96After
+			result.InitializedTicksCrossedList = values[2].([]uint32)
+		case *big.Int:
+			result.SqrtPriceX96AfterList = []*big.Int{sqrtPriceX96After}
+			result.InitializedTicksCrossedList = []uint32{values[2].(uint32)}
+		}
+		result.GasEstimate = values[3].(*big.Int)
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return nil, ErrUnknownSelector
+}
+
 /**
  * Converts a route to a hex encoded path
  * @param route the v3 path to convert to an encoded path
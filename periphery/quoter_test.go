@@ -7,8 +7,10 @@ import (
 	"github.com/KyberNetwork/uniswapv3-sdk-uint256/entities"
 	"github.com/KyberNetwork/uniswapv3-sdk-uint256/utils"
 	core "github.com/daoleno/uniswap-sdk-core/entities"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestEncodeRouteToPath(t *testing.T) {
@@ -119,3 +121,72 @@ func TestQuoteCallParameters(t *testing.T) {
 	assert.Equal(t, "0xf7729d43000000000000000000000000000000000000000000000000000000000000000100000000000000000000000000000000000000000000000000000000000000020000000000000000000000000000000000000000000000000000000000000bb800000000000000000000000000000000000000000000000000000000000000640000000000000000000000000000000100000000000000000000000000000000", hexutil.Encode(params.Calldata))
 	assert.Equal(t, "0x00", utils.ToHex(params.Value))
 }
+
+func TestQuoteCallParametersV2(t *testing.T) {
+	pool_0_1 := makePool(token0, token1)
+	pool_1_weth := makePool(token1, weth)
+	quoterV2 := GetABI(quoterV2ABI)
+	options := &QuoteOptions{UseQuoterV2: true}
+
+	// single-hop exact input
+	r, _ := entities.NewRoute([]*entities.Pool{pool_0_1}, token0, token1)
+	amountIn := core.FromRawAmount(token0, big.NewInt(100))
+	params, err := QuoteCallParameters(r, amountIn, core.ExactInput, options)
+	require.NoError(t, err)
+	assert.Equal(t, selector("quoteExactInputSingle((address,address,uint256,uint24,uint160))"), params.Calldata[:4])
+	assert.Equal(t, "0x00", utils.ToHex(params.Value))
+
+	// the result of the quoter is the result of the pool
+	expected, err := pool_0_1.GetOutputAmount(amountIn, nil)
+	require.NoError(t, err)
+	sqrtPriceX96After := expected.NewPoolState.SqrtRatioX96.ToBig()
+	output, err := quoterV2.Methods["quoteExactInputSingle"].Outputs.Pack(
+		expected.ReturnedAmount.Quotient(), sqrtPriceX96After, uint32(expected.CrossInitTickLoops), big.NewInt(90000))
+	require.NoError(t, err)
+	result, err := DecodeQuoteResult(params.Calldata, output)
+	require.NoError(t, err)
+	assert.Equal(t, &QuoteResult{
+		Amount:                      expected.ReturnedAmount.Quotient(),
+		SqrtPriceX96AfterList:       []*big.Int{sqrtPriceX96After},
+		InitializedTicksCrossedList: []uint32{uint32(expected.CrossInitTickLoops)},
+		GasEstimate:                 big.NewInt(90000),
+	}, result)
+
+	// single-hop exact output
+	params, err = QuoteCallParameters(r, core.FromRawAmount(token1, big.NewInt(100)), core.ExactOutput, options)
+	require.NoError(t, err)
+	assert.Equal(t, selector("quoteExactOutputSingle((address,address,uint256,uint24,uint160))"), params.Calldata[:4])
+
+	// multi-hop
+	r, _ = entities.NewRoute([]*entities.Pool{pool_0_1, pool_1_weth}, token0, weth)
+	params, err = QuoteCallParameters(r, amountIn, core.ExactInput, options)
+	require.NoError(t, err)
+	assert.Equal(t, selector("quoteExactInput(bytes,uint256)"), params.Calldata[:4])
+	output, err = quoterV2.Methods["quoteExactInput"].Outputs.Pack(
+		big.NewInt(98), []*big.Int{big.NewInt(1), big.NewInt(2)}, []uint32{1, 0}, big.NewInt(150000))
+	require.NoError(t, err)
+	result, err = DecodeQuoteResult(params.Calldata, output)
+	require.NoError(t, err)
+	assert.Equal(t, &QuoteResult{
+		Amount:                      big.NewInt(98),
+		SqrtPriceX96AfterList:       []*big.Int{big.NewInt(1), big.NewInt(2)},
+		InitializedTicksCrossedList: []uint32{1, 0},
+		GasEstimate:                 big.NewInt(150000),
+	}, result)
+	params, err = QuoteCallParameters(r, core.FromRawAmount(weth, big.NewInt(100)), core.ExactOutput, options)
+	require.NoError(t, err)
+	assert.Equal(t, selector("quoteExactOutput(bytes,uint256)"), params.Calldata[:4])
+
+	// the same path quote of QuoterV1 only returns the amount
+	params, err = QuoteCallParameters(r, amountIn, core.ExactInput, &QuoteOptions{SqrtPriceLimitX96: big.NewInt(0)})
+	require.NoError(t, err)
+	result, err = DecodeQuoteResult(params.Calldata, common.LeftPadBytes([]byte{98}, 32))
+	require.NoError(t, err)
+	assert.Equal(t, &QuoteResult{Amount: big.NewInt(98)}, result)
+
+	// a multi-hop quote can't have a price limit
+	_, err = QuoteCallParameters(r, amountIn, core.ExactInput, &QuoteOptions{SqrtPriceLimitX96: big.NewInt(1), UseQuoterV2: true})
+	assert.ErrorIs(t, err, ErrMultihopPriceLimit)
+	_, err = DecodeQuoteResult(selector("exactInput((bytes,address,uint256,uint256,uint256))"), output)
+	assert.ErrorIs(t, err, ErrUnknownSelector)
+}
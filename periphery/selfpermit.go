@@ -1,6 +1,7 @@
 package periphery
 
 import (
+	"crypto/ecdsa"
 	_ "embed"
 	"encoding/json"
 	"errors"
@@ -8,6 +9,8 @@ import (
 
 	"github.com/daoleno/uniswap-sdk-core/entities"
 	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
 )
 
 //go:embed contracts/interfaces/ISelfPermit.sol/ISelfPermit.json
@@ -36,6 +39,7 @@ type AllowedPermitArguments struct {
 type PermitOptions struct {
 	*StandardPermitArguments
 	*AllowedPermitArguments
+	*Permit2Permit // the Permit2 permit, for the Universal Router only
 }
 
 func getSelfPermitABI() abi.ABI {
@@ -72,3 +76,127 @@ func EncodeAllowedPermit(token *entities.Token, options *AllowedPermitArguments)
 	abi := getSelfPermitABI()
 	return abi.Pack("selfPermitAllowed", token.Address, options.Nonce, options.Expiry, options.V, options.R, options.S)
 }
+
+// StandardPermitData is the message of an ERC-2612 permit
+type StandardPermitData struct {
+	Owner    common.Address
+	Spender  common.Address
+	Value    *big.Int
+	Nonce    *big.Int // the nonces(owner) of the token
+	Deadline *big.Int
+}
+
+// AllowedPermitData is the message of a DAI-style permit
+type AllowedPermitData struct {
+	Holder  common.Address
+	Spender common.Address
+	Nonce   *big.Int // the nonces(holder) of the token
+	Expiry  *big.Int
+	Allowed bool
+}
+
+/**
+ * Returns the EIP-712 typed data of an ERC-2612 permit
+ * @param domain The domain of the token, with the name and version of its DOMAIN_SEPARATOR
+ * @param permit The permit
+ */
+func GetStandardPermitTypedData(domain *PermitDomain, permit *StandardPermitData) *apitypes.TypedData {
+	return &apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": permitDomainType,
+			"Permit": {
+				{Name: "owner", Type: "address"},
+				{Name: "spender", Type: "address"},
+				{Name: "value", Type: "uint256"},
+				{Name: "nonce", Type: "uint256"},
+				{Name: "deadline", Type: "uint256"},
+			},
+		},
+		PrimaryType: "Permit",
+		Domain:      domain.typedDataDomain(),
+		Message: apitypes.TypedDataMessage{
+			"owner":    permit.Owner.Hex(),
+			"spender":  permit.Spender.Hex(),
+			"value":    typedDataInt(permit.Value),
+			"nonce":    typedDataInt(permit.Nonce),
+			"deadline": typedDataInt(permit.Deadline),
+		},
+	}
+}
+
+/**
+ * Returns the EIP-712 typed data of a DAI-style permit
+ * @param domain The domain of the token, with the name and version of its DOMAIN_SEPARATOR
+ * @param permit The permit
+ */
+func GetAllowedPermitTypedData(domain *PermitDomain, permit *AllowedPermitData) *apitypes.TypedData {
+	return &apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": permitDomainType,
+			"Permit": {
+				{Name: "holder", Type: "address"},
+				{Name: "spender", Type: "address"},
+				{Name: "nonce", Type: "uint256"},
+				{Name: "expiry", Type: "uint256"},
+				{Name: "allowed", Type: "bool"},
+			},
+		},
+		PrimaryType: "Permit",
+		Domain:      domain.typedDataDomain(),
+		Message: apitypes.TypedDataMessage{
+			"holder":  permit.Holder.Hex(),
+			"spender": permit.Spender.Hex(),
+			"nonce":   typedDataInt(permit.Nonce),
+			"expiry":  typedDataInt(permit.Expiry),
+			"allowed": permit.Allowed,
+		},
+	}
+}
+
+/**
+ * Signs an ERC-2612 permit
+ * @param domain The domain of the token
+ * @param permit The permit, its owner must be the address of the key
+ * @param privateKey The key of the owner
+ * @returns The permit options to spend the input of a swap or the tokens of a position
+ */
+func SignStandardPermit(domain *PermitDomain, permit *StandardPermitData, privateKey *ecdsa.PrivateKey) (*PermitOptions, error) {
+	signature, err := SignTypedData(GetStandardPermitTypedData(domain, permit), privateKey)
+	if err != nil {
+		return nil, err
+	}
+	v, r, s := splitSignature(signature)
+	return &PermitOptions{
+		StandardPermitArguments: &StandardPermitArguments{
+			V:        v,
+			R:        r,
+			S:        s,
+			Amount:   permit.Value,
+			Deadline: permit.Deadline,
+		},
+	}, nil
+}
+
+/**
+ * Signs a DAI-style permit
+ * @param domain The domain of the token
+ * @param permit The permit, its holder must be the address of the key
+ * @param privateKey The key of the holder
+ * @returns The permit options to spend the input of a swap or the tokens of a position
+ */
+func SignAllowedPermit(domain *PermitDomain, permit *AllowedPermitData, privateKey *ecdsa.PrivateKey) (*PermitOptions, error) {
+	signature, err := SignTypedData(GetAllowedPermitTypedData(domain, permit), privateKey)
+	if err != nil {
+		return nil, err
+	}
+	v, r, s := splitSignature(signature)
+	return &PermitOptions{
+		AllowedPermitArguments: &AllowedPermitArguments{
+			V:      v,
+			R:      r,
+			S:      s,
+			Nonce:  permit.Nonce,
+			Expiry: permit.Expiry,
+		},
+	}, nil
+}
@@ -4,8 +4,12 @@ import (
 	"math/big"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestEncodePermit(t *testing.T) {
@@ -52,3 +56,70 @@ func TestEncodePermit(t *testing.T) {
 	}
 	assert.Equal(t, "0x4659a4940000000000000000000000000000000000000000000000000000000000000001000000000000000000000000000000000000000000000000000000000000007b000000000000000000000000000000000000000000000000000000000000007b000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000010000000000000000000000000000000000000000000000000000000000000002", hexutil.Encode(encoded))
 }
+
+// validatedDigest hashes the typed data with the validation of apitypes
+func validatedDigest(t *testing.T, typedData *apitypes.TypedData) []byte {
+	domainSeparator, err := typedData.HashStruct("EIP712Domain", typedData.Domain.Map())
+	require.NoError(t, err)
+	message, err := typedData.HashStruct(typedData.PrimaryType, typedData.Message)
+	require.NoError(t, err)
+	return crypto.Keccak256([]byte("\x19\x01"), domainSeparator, message)
+}
+
+func TestSignPermit(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	owner := crypto.PubkeyToAddress(key.PublicKey)
+	domain := &PermitDomain{Name: "USD Coin", Version: "2", ChainID: big.NewInt(1), VerifyingContract: token0.Address}
+
+	separator, err := domain.DomainSeparator()
+	require.NoError(t, err)
+	domainArgs := newArguments(mustNewType("bytes32"), mustNewType("bytes32"), mustNewType("bytes32"), uint256Type, addressType)
+	encodedDomain, err := domainArgs.Pack(
+		crypto.Keccak256Hash([]byte("EIP712Domain(string name,string version,uint256 chainId,address verifyingContract)")),
+		crypto.Keccak256Hash([]byte("USD Coin")), crypto.Keccak256Hash([]byte("2")), big.NewInt(1), token0.Address)
+	require.NoError(t, err)
+	assert.Equal(t, crypto.Keccak256(encodedDomain), separator)
+
+	// standard permit
+	standard := &StandardPermitData{
+		Owner:    owner,
+		Spender:  common.HexToAddress("0x68b3465833fb72A70ecDF485E0e4C7bD8665Fc45"),
+		Value:    big.NewInt(123),
+		Nonce:    big.NewInt(0),
+		Deadline: big.NewInt(1700000000),
+	}
+	typedData := GetStandardPermitTypedData(domain, standard)
+	digest, err := HashTypedData(typedData)
+	require.NoError(t, err)
+	assert.Equal(t, validatedDigest(t, typedData), digest)
+
+	opts, err := SignStandardPermit(domain, standard, key)
+	require.NoError(t, err)
+	args := opts.StandardPermitArguments
+	assert.Equal(t, standard.Value, args.Amount)
+	assert.Equal(t, standard.Deadline, args.Deadline)
+	assert.Equal(t, owner, recoverSigner(t, digest, append(append(args.R[:], args.S[:]...), args.V)))
+	_, err = EncodePermit(token0, opts)
+	assert.NoError(t, err)
+
+	// allowed permit
+	allowed := &AllowedPermitData{
+		Holder:  owner,
+		Spender: standard.Spender,
+		Nonce:   big.NewInt(3),
+		Expiry:  big.NewInt(1700000000),
+		Allowed: true,
+	}
+	typedData = GetAllowedPermitTypedData(domain, allowed)
+	digest, err = HashTypedData(typedData)
+	require.NoError(t, err)
+	assert.Equal(t, validatedDigest(t, typedData), digest)
+
+	opts, err = SignAllowedPermit(domain, allowed, key)
+	require.NoError(t, err)
+	aargs := opts.AllowedPermitArguments
+	assert.Equal(t, allowed.Nonce, aargs.Nonce)
+	assert.Equal(t, allowed.Expiry, aargs.Expiry)
+	assert.Equal(t, owner, recoverSigner(t, digest, append(append(aargs.R[:], aargs.S[:]...), aargs.V)))
+}
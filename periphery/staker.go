@@ -237,7 +237,7 @@ func EncodeDeposit(incentiveKeys []*IncentiveKey) ([]byte, error) {
 */
 func encodeIncentiveKey(incentiveKey *IncentiveKey) (*IncentiveKeyParams, error) {
 	pool := incentiveKey.Pool
-	addr, err := entities.GetAddress(pool.Token0, pool.Token1, pool.Fee, "")
+	addr, err := pool.Address()
 	if err != nil {
 		return nil, err
 	}
@@ -20,13 +20,25 @@ var (
 	ErrTokenOutDiff       = errors.New("TOKEN_OUT_DIFF")
 	ErrNonTokenPermit     = errors.New("NON_TOKEN_PERMIT")
 	ErrMultiHopPriceLimit = errors.New("MULTIHOP_PRICE_LIMIT")
+	ErrUnsupportedOption  = errors.New("option not supported by the router")
+)
+
+// Router is the router contract the calls are encoded for
+type Router int
+
+const (
+	SwapRouter      Router = iota // the original SwapRouter
+	SwapRouter02                  // SwapRouter02, see SwapRouter02CallParameters
+	UniversalRouter               // the Universal Router, see UniversalRouterCallParameters
 )
 
 // Options for producing the arguments to send calls to the router.
 type SwapOptions struct {
+	Router            Router         // The router to encode the calls for, SwapRouter by default.
 	SlippageTolerance *core.Percent  // How much the execution price is allowed to move unfavorably from the trade execution price.
 	Recipient         common.Address // The account that should receive the output.
 	Deadline          *big.Int       // When the transaction expires, in epoch seconds.
+	PreviousBlockhash *common.Hash   // The optional parent block hash the transaction must be mined on, SwapRouter02 only, instead of the deadline.
 	InputTokenPermit  *PermitOptions // The optional permit parameters for spending the input.
 	SqrtPriceLimitX96 *big.Int       // The optional price limit for the trade.
 	Fee               *FeeOptions    // Optional information for taking a fee on output.
@@ -78,6 +90,16 @@ type ExactOutputParams struct {
  * @param options options for the call parameters
  */
 func SwapCallParameters(trades []*entities.Trade, options *SwapOptions) (*utils.MethodParameters, error) {
+	switch options.Router {
+	case SwapRouter02:
+		return SwapRouter02CallParameters(trades, options)
+	case UniversalRouter:
+		return UniversalRouterCallParameters(trades, options)
+	}
+	if options.PreviousBlockhash != nil {
+		return nil, ErrUnsupportedOption
+	}
+
 	abi := GetABI(swapRouterABI)
 	sampleTrade := trades[0]
 	tokenIn := sampleTrade.InputAmount().Currency.Wrapped()
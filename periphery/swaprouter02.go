@@ -0,0 +1,281 @@
+package periphery
+
+import (
+	_ "embed"
+	"errors"
+	"math/big"
+
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/entities"
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/utils"
+	core "github.com/daoleno/uniswap-sdk-core/entities"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+//go:embed contracts/SwapRouter02.sol/SwapRouter02.json
+var swapRouter02ABI []byte
+
+var (
+	ErrMethodNotFound = errors.New("method not found")
+
+	// MsgSender and AddressThis are the placeholder recipients of SwapRouter02 for the sender of the call and the
+	// router itself
+	MsgSender   = common.HexToAddress("0x0000000000000000000000000000000000000001")
+	AddressThis = common.HexToAddress("0x0000000000000000000000000000000000000002")
+)
+
+// The swap params of SwapRouter02 (IV3SwapRouter), the deadline is validated by the multicall instead
+
+type V3ExactInputSingleParams struct {
+	TokenIn           common.Address
+	TokenOut          common.Address
+	Fee               *big.Int
+	Recipient         common.Address
+	AmountIn          *big.Int
+	AmountOutMinimum  *big.Int
+	SqrtPriceLimitX96 *big.Int
+}
+
+type V3ExactOutputSingleParams struct {
+	TokenIn           common.Address
+	TokenOut          common.Address
+	Fee               *big.Int
+	Recipient         common.Address
+	AmountOut         *big.Int
+	AmountInMaximum   *big.Int
+	SqrtPriceLimitX96 *big.Int
+}
+
+type V3ExactInputParams struct {
+	Path             []byte
+	Recipient        common.Address
+	AmountIn         *big.Int
+	AmountOutMinimum *big.Int
+}
+
+type V3ExactOutputParams struct {
+	Path            []byte
+	Recipient       common.Address
+	AmountOut       *big.Int
+	AmountInMaximum *big.Int
+}
+
+// packMethod packs a call of the method with the given signature, e.g. "multicall(uint256,bytes[])". Overloaded
+// methods are renamed by the abi package in the order they appear in the ABI, so they are looked up by signature.
+func packMethod(contractABI abi.ABI, sig string, args ...interface{}) ([]byte, error) {
+	for _, method := range contractABI.Methods {
+		if method.Sig != sig {
+			continue
+		}
+		arguments, err := method.Inputs.Pack(args...)
+		if err != nil {
+			return nil, err
+		}
+		return append(append([]byte{}, method.ID...), arguments...), nil
+	}
+	return nil, ErrMethodNotFound
+}
+
+/**
+ * Encodes the calls in a multicall of SwapRouter02. If a previous blockhash is given, the call reverts unless it is
+ * mined on top of that block, otherwise if a deadline is given, the call reverts after the deadline.
+ * @param calldatas The calls to batch
+ * @param deadline The optional deadline, in epoch seconds
+ * @param previousBlockhash The optional hash of the parent block of the block the call must be mined in
+ */
+func EncodeMulticallExtended(calldatas [][]byte, deadline *big.Int, previousBlockhash *common.Hash) ([]byte, error) {
+	abi := GetABI(swapRouter02ABI)
+	if previousBlockhash != nil {
+		return packMethod(abi, "multicall(bytes32,bytes[])", *previousBlockhash, calldatas)
+	}
+	if deadline != nil {
+		return packMethod(abi, "multicall(uint256,bytes[])", deadline, calldatas)
+	}
+	return EncodeMulticall(calldatas)
+}
+
+// Represents the Uniswap V3 SwapRouter02
+
+/**
+ * Produces the on-chain method name to call and the hex encoded parameters to pass as arguments for a given trade,
+ * for SwapRouter02. The calls are validated by `options.PreviousBlockhash` if set, else by `options.Deadline`.
+ * If `options.Recipient` is the zero address, the output is sent to the sender of the call.
+ * @param trades to produce call parameters for
+ * @param options options for the call parameters
+ */
+func SwapRouter02CallParameters(trades []*entities.Trade, options *SwapOptions) (*utils.MethodParameters, error) {
+	abi := GetABI(swapRouter02ABI)
+	sampleTrade := trades[0]
+	tokenIn := sampleTrade.InputAmount().Currency.Wrapped()
+	tokenOut := sampleTrade.OutputAmount().Currency.Wrapped()
+
+	// All trades should have the same starting and ending token.
+	for _, trade := range trades {
+		if !trade.InputAmount().Currency.Wrapped().Equal(tokenIn) {
+			return nil, ErrTokenInDiff
+		}
+		if !trade.OutputAmount().Currency.Wrapped().Equal(tokenOut) {
+			return nil, ErrTokenOutDiff
+		}
+	}
+
+	var calldatas [][]byte
+
+	ZeroIn := core.FromRawAmount(trades[0].InputAmount().Currency, big.NewInt(0))
+	ZeroOut := core.FromRawAmount(trades[0].OutputAmount().Currency, big.NewInt(0))
+
+	totalAmountOut := ZeroOut
+	for _, trade := range trades {
+		minOut, err := trade.MinimumAmountOut(options.SlippageTolerance, nil)
+		if err != nil {
+			return nil, err
+		}
+		totalAmountOut = totalAmountOut.Add(minOut)
+	}
+
+	// flag for whether a refund needs to happen
+	mustRefund := sampleTrade.InputAmount().Currency.IsNative() && sampleTrade.TradeType == core.ExactOutput
+	inputIsNative := sampleTrade.InputAmount().Currency.IsNative()
+	// flags for whether funds should be send first to the router
+	outputIsNative := sampleTrade.OutputAmount().Currency.IsNative()
+	routerMustCustody := outputIsNative || options.Fee != nil
+
+	totalValue := ZeroIn
+	if inputIsNative {
+		for _, trade := range trades {
+			maxIn, err := trade.MaximumAmountIn(options.SlippageTolerance, nil)
+			if err != nil {
+				return nil, err
+			}
+			totalValue = totalValue.Add(maxIn)
+		}
+	}
+
+	// encode permit if necessary
+	if options.InputTokenPermit != nil {
+		if !sampleTrade.InputAmount().Currency.IsToken() {
+			return nil, ErrNonTokenPermit
+		}
+
+		permit, err := EncodePermit(tokenIn, options.InputTokenPermit)
+		if err != nil {
+			return nil, err
+		}
+		calldatas = append(calldatas, permit)
+	}
+
+	// the payments are sent to the sender when no recipient is given
+	var paymentRecipient *common.Address
+	recipient := MsgSender
+	if options.Recipient != (common.Address{}) {
+		paymentRecipient = &options.Recipient
+		recipient = options.Recipient
+	}
+	if routerMustCustody {
+		recipient = AddressThis
+	}
+
+	sqrtPriceLimitX96 := big.NewInt(0)
+	if options.SqrtPriceLimitX96 != nil {
+		sqrtPriceLimitX96 = options.SqrtPriceLimitX96
+	}
+
+	for _, trade := range trades {
+		for _, swap := range trade.Swaps {
+			amountIn, err := trade.MaximumAmountIn(options.SlippageTolerance, swap.InputAmount)
+			if err != nil {
+				return nil, err
+			}
+			amountOut, err := trade.MinimumAmountOut(options.SlippageTolerance, swap.OutputAmount)
+			if err != nil {
+				return nil, err
+			}
+
+			var calldata []byte
+			// single hop swaps don't need a path
+			if len(swap.Route.Pools) == 1 {
+				if trade.TradeType == core.ExactInput {
+					calldata, err = abi.Pack("exactInputSingle", &V3ExactInputSingleParams{
+						TokenIn:           swap.Route.TokenPath[0].Address,
+						TokenOut:          swap.Route.TokenPath[1].Address,
+						Fee:               big.NewInt(int64(swap.Route.Pools[0].Fee)),
+						Recipient:         recipient,
+						AmountIn:          amountIn.Quotient(),
+						AmountOutMinimum:  amountOut.Quotient(),
+						SqrtPriceLimitX96: sqrtPriceLimitX96,
+					})
+				} else {
+					calldata, err = abi.Pack("exactOutputSingle", &V3ExactOutputSingleParams{
+						TokenIn:           swap.Route.TokenPath[0].Address,
+						TokenOut:          swap.Route.TokenPath[1].Address,
+						Fee:               big.NewInt(int64(swap.Route.Pools[0].Fee)),
+						Recipient:         recipient,
+						AmountOut:         amountOut.Quotient(),
+						AmountInMaximum:   amountIn.Quotient(),
+						SqrtPriceLimitX96: sqrtPriceLimitX96,
+					})
+				}
+			} else {
+				if options.SqrtPriceLimitX96 != nil {
+					return nil, ErrMultiHopPriceLimit
+				}
+
+				var path []byte
+				path, err = EncodeRouteToPath(swap.Route, trade.TradeType == core.ExactOutput)
+				if err != nil {
+					return nil, err
+				}
+
+				if trade.TradeType == core.ExactInput {
+					calldata, err = abi.Pack("exactInput", &V3ExactInputParams{
+						Path:             path,
+						Recipient:        recipient,
+						AmountIn:         amountIn.Quotient(),
+						AmountOutMinimum: amountOut.Quotient(),
+					})
+				} else {
+					calldata, err = abi.Pack("exactOutput", &V3ExactOutputParams{
+						Path:            path,
+						Recipient:       recipient,
+						AmountOut:       amountOut.Quotient(),
+						AmountInMaximum: amountIn.Quotient(),
+					})
+				}
+			}
+			if err != nil {
+				return nil, err
+			}
+			calldatas = append(calldatas, calldata)
+		}
+	}
+
+	// unwrap or sweep the output held by the router
+	if routerMustCustody {
+		var (
+			calldata []byte
+			err      error
+		)
+		if outputIsNative {
+			calldata, err = EncodeUnwrapWETH9Extended(totalAmountOut.Quotient(), paymentRecipient, options.Fee)
+		} else {
+			calldata, err = EncodeSweepTokenExtended(tokenOut, totalAmountOut.Quotient(), paymentRecipient, options.Fee)
+		}
+		if err != nil {
+			return nil, err
+		}
+		calldatas = append(calldatas, calldata)
+	}
+
+	// refund
+	if mustRefund {
+		calldatas = append(calldatas, EncodeRefundETH())
+	}
+	call, err := EncodeMulticallExtended(calldatas, options.Deadline, options.PreviousBlockhash)
+	if err != nil {
+		return nil, err
+	}
+	return &utils.MethodParameters{
+		Calldata: call,
+		Value:    totalValue.Quotient(),
+	}, nil
+}
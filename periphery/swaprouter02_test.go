@@ -0,0 +1,176 @@
+package periphery
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/entities"
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/utils"
+	core "github.com/daoleno/uniswap-sdk-core/entities"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// exactInputSingleTuple is the type the abi package unpacks the exactInputSingle params to
+type exactInputSingleTuple = struct {
+	TokenIn           common.Address `json:"tokenIn"`
+	TokenOut          common.Address `json:"tokenOut"`
+	Fee               *big.Int       `json:"fee"`
+	Recipient         common.Address `json:"recipient"`
+	AmountIn          *big.Int       `json:"amountIn"`
+	AmountOutMinimum  *big.Int       `json:"amountOutMinimum"`
+	SqrtPriceLimitX96 *big.Int       `json:"sqrtPriceLimitX96"`
+}
+
+func selector(sig string) []byte {
+	return crypto.Keccak256([]byte(sig))[:4]
+}
+
+// unpackSwapRouter02 returns the selector and arguments of a SwapRouter02 call
+func unpackSwapRouter02(t *testing.T, calldata []byte) ([]byte, []interface{}) {
+	abi := GetABI(swapRouter02ABI)
+	method, err := abi.MethodById(calldata[:4])
+	require.NoError(t, err)
+	args, err := method.Inputs.Unpack(calldata[4:])
+	require.NoError(t, err)
+	return calldata[:4], args
+}
+
+func TestSwapRouter02CallParameters(t *testing.T) {
+	pool_0_1 := makePool(token0, token1)
+	pool_1_weth := makePool(token1, weth)
+
+	slippageTolerance := core.NewPercent(big.NewInt(1), big.NewInt(100))
+	recipient := common.HexToAddress("0x0000000000000000000000000000000000000003")
+	deadline := big.NewInt(123)
+	previousBlockhash := common.HexToHash("0x01")
+
+	// single-hop exact input, validated by the deadline
+	r, _ := entities.NewRoute([]*entities.Pool{pool_0_1}, token0, token1)
+	trade, _ := entities.FromRoute(r, core.FromRawAmount(token0, big.NewInt(100)), core.ExactInput)
+	params, err := SwapRouter02CallParameters([]*entities.Trade{trade}, &SwapOptions{
+		SlippageTolerance: slippageTolerance,
+		Recipient:         recipient,
+		Deadline:          deadline,
+	})
+	require.NoError(t, err)
+	sel, args := unpackSwapRouter02(t, params.Calldata)
+	assert.Equal(t, selector("multicall(uint256,bytes[])"), sel)
+	assert.Equal(t, deadline, args[0])
+	calls := args[1].([][]byte)
+	require.Len(t, calls, 1)
+	sel, args = unpackSwapRouter02(t, calls[0])
+	assert.Equal(t, selector("exactInputSingle((address,address,uint24,address,uint256,uint256,uint160))"), sel)
+	swap := args[0].(exactInputSingleTuple)
+	assert.Equal(t, token0.Address, swap.TokenIn)
+	assert.Equal(t, token1.Address, swap.TokenOut)
+	assert.Equal(t, recipient, swap.Recipient)
+	assert.Equal(t, big.NewInt(100), swap.AmountIn)
+	assert.Equal(t, big.NewInt(97), swap.AmountOutMinimum)
+	assert.Equal(t, "0x00", utils.ToHex(params.Value))
+
+	// validated by the previous blockhash
+	params, err = SwapRouter02CallParameters([]*entities.Trade{trade}, &SwapOptions{
+		SlippageTolerance: slippageTolerance,
+		Recipient:         recipient,
+		Deadline:          deadline,
+		PreviousBlockhash: &previousBlockhash,
+	})
+	require.NoError(t, err)
+	sel, args = unpackSwapRouter02(t, params.Calldata)
+	assert.Equal(t, selector("multicall(bytes32,bytes[])"), sel)
+	assert.Equal(t, [32]byte(previousBlockhash), args[0])
+	assert.Equal(t, calls, args[1])
+
+	// not validated, a single call isn't wrapped in a multicall
+	params, err = SwapRouter02CallParameters([]*entities.Trade{trade}, &SwapOptions{
+		SlippageTolerance: slippageTolerance,
+		Recipient:         recipient,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, calls[0], params.Calldata)
+
+	// multi-hop exact output
+	r, _ = entities.NewRoute([]*entities.Pool{pool_0_1, pool_1_weth}, token0, weth)
+	trade, _ = entities.FromRoute(r, core.FromRawAmount(weth, big.NewInt(100)), core.ExactOutput)
+	params, err = SwapRouter02CallParameters([]*entities.Trade{trade}, &SwapOptions{
+		SlippageTolerance: slippageTolerance,
+		Recipient:         recipient,
+		Deadline:          deadline,
+	})
+	require.NoError(t, err)
+	_, args = unpackSwapRouter02(t, params.Calldata)
+	calls = args[1].([][]byte)
+	require.Len(t, calls, 1)
+	sel, args = unpackSwapRouter02(t, calls[0])
+	assert.Equal(t, selector("exactOutput((bytes,address,uint256,uint256))"), sel)
+	path, err := EncodeRouteToPath(r, true)
+	require.NoError(t, err)
+	assert.Equal(t, struct {
+		Path            []byte         `json:"path"`
+		Recipient       common.Address `json:"recipient"`
+		AmountOut       *big.Int       `json:"amountOut"`
+		AmountInMaximum *big.Int       `json:"amountInMaximum"`
+	}{path, recipient, big.NewInt(100), big.NewInt(105)}, args[0])
+
+	// ETH in exact output refunds the ETH left
+	r, _ = entities.NewRoute([]*entities.Pool{pool_1_weth}, ether, token1)
+	trade, _ = entities.FromRoute(r, core.FromRawAmount(token1, big.NewInt(100)), core.ExactOutput)
+	params, err = SwapRouter02CallParameters([]*entities.Trade{trade}, &SwapOptions{
+		SlippageTolerance: slippageTolerance,
+		Recipient:         recipient,
+		Deadline:          deadline,
+	})
+	require.NoError(t, err)
+	_, args = unpackSwapRouter02(t, params.Calldata)
+	calls = args[1].([][]byte)
+	require.Len(t, calls, 2)
+	assert.Equal(t, selector("exactOutputSingle((address,address,uint24,address,uint256,uint256,uint160))"), calls[0][:4])
+	assert.Equal(t, selector("refundETH()"), calls[1])
+	assert.Equal(t, "0x67", utils.ToHex(params.Value))
+
+	// ETH out exact input without a recipient, the router unwraps to the sender
+	r, _ = entities.NewRoute([]*entities.Pool{pool_1_weth}, token1, ether)
+	trade, _ = entities.FromRoute(r, core.FromRawAmount(token1, big.NewInt(100)), core.ExactInput)
+	params, err = SwapRouter02CallParameters([]*entities.Trade{trade}, &SwapOptions{
+		SlippageTolerance: slippageTolerance,
+		Deadline:          deadline,
+	})
+	require.NoError(t, err)
+	_, args = unpackSwapRouter02(t, params.Calldata)
+	calls = args[1].([][]byte)
+	require.Len(t, calls, 2)
+	_, args = unpackSwapRouter02(t, calls[0])
+	assert.Equal(t, AddressThis, args[0].(exactInputSingleTuple).Recipient)
+	sel, args = unpackSwapRouter02(t, calls[1])
+	assert.Equal(t, selector("unwrapWETH9(uint256)"), sel)
+	assert.Equal(t, big.NewInt(97), args[0])
+
+	// token out with a fee, the router sweeps to the recipient
+	r, _ = entities.NewRoute([]*entities.Pool{pool_0_1}, token0, token1)
+	trade, _ = entities.FromRoute(r, core.FromRawAmount(token0, big.NewInt(100)), core.ExactInput)
+	params, err = SwapRouter02CallParameters([]*entities.Trade{trade}, &SwapOptions{
+		SlippageTolerance: slippageTolerance,
+		Recipient:         recipient,
+		Deadline:          deadline,
+		Fee:               feeOptions,
+	})
+	require.NoError(t, err)
+	_, args = unpackSwapRouter02(t, params.Calldata)
+	calls = args[1].([][]byte)
+	require.Len(t, calls, 2)
+	sel, args = unpackSwapRouter02(t, calls[1])
+	assert.Equal(t, selector("sweepTokenWithFee(address,uint256,address,uint256,address)"), sel)
+	assert.Equal(t, []interface{}{token1.Address, big.NewInt(97), recipient, big.NewInt(10), feeOptions.Recipient}, args)
+
+	// the price limit only applies to single hop swaps
+	r, _ = entities.NewRoute([]*entities.Pool{pool_0_1, pool_1_weth}, token0, weth)
+	trade, _ = entities.FromRoute(r, core.FromRawAmount(token0, big.NewInt(100)), core.ExactInput)
+	_, err = SwapRouter02CallParameters([]*entities.Trade{trade}, &SwapOptions{
+		SlippageTolerance: slippageTolerance,
+		SqrtPriceLimitX96: big.NewInt(1),
+	})
+	assert.ErrorIs(t, err, ErrMultiHopPriceLimit)
+}
@@ -0,0 +1,413 @@
+package periphery
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/KyberNetwork/int256"
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/entities"
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/utils"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/holiman/uint256"
+)
+
+//go:embed contracts/lens/TickLens.sol/TickLens.json
+var tickLensABI []byte
+
+// The tickBitmap getter of UniswapV3Pool
+const tickBitmapABI = `[{"inputs":[{"internalType":"int16","name":"wordPosition","type":"int16"}],"name":"tickBitmap","outputs":[{"internalType":"uint256","name":"","type":"uint256"}],"stateMutability":"view","type":"function"}]`
+
+// The number of calls sent in a batch request when the BatchSize of a TickLens is zero
+const DefaultTickLensBatchSize = 500
+
+var (
+	ErrDuplicateTick        = errors.New("duplicate tick")
+	ErrInvalidTickLiquidity = errors.New("invalid tick liquidity")
+	ErrInvalidTickBitmap    = errors.New("invalid tick bitmap")
+	ErrUnknownBlockNumber   = errors.New("unknown block number")
+)
+
+// PopulatedTick is a populated tick of a pool, as returned by the TickLens
+type PopulatedTick struct {
+	Tick           *big.Int
+	LiquidityNet   *big.Int
+	LiquidityGross *big.Int
+}
+
+/**
+ * Encodes the call to get the populated ticks of a word of the tick bitmap of a pool
+ * @param pool The address of the pool
+ * @param tickBitmapIndex The index of the word in the tick bitmap
+ */
+func EncodeGetPopulatedTicksInWord(pool common.Address, tickBitmapIndex int16) ([]byte, error) {
+	abi := GetABI(tickLensABI)
+	return abi.Pack("getPopulatedTicksInWord", pool, tickBitmapIndex)
+}
+
+/**
+ * Decodes the result of getPopulatedTicksInWord
+ * @param output The data returned by the call
+ */
+func DecodePopulatedTicks(output []byte) ([]PopulatedTick, error) {
+	abi := GetABI(tickLensABI)
+	values, err := abi.Methods["getPopulatedTicksInWord"].Outputs.Unpack(output)
+	if err != nil {
+		return nil, err
+	}
+	var ticks []PopulatedTick
+	if err := abi.Methods["getPopulatedTicksInWord"].Outputs.Copy(&ticks, values); err != nil {
+		return nil, err
+	}
+	return ticks, nil
+}
+
+/**
+ * Encodes the call to get a word of the tick bitmap of a pool, see UniswapV3Pool.tickBitmap
+ * @param wordPosition The index of the word in the tick bitmap
+ */
+func EncodeTickBitmap(wordPosition int16) ([]byte, error) {
+	return getTickBitmapABI().Pack("tickBitmap", wordPosition)
+}
+
+/**
+ * Decodes the result of tickBitmap
+ * @param output The data returned by the call
+ */
+func DecodeTickBitmap(output []byte) (*uint256.Int, error) {
+	values, err := getTickBitmapABI().Methods["tickBitmap"].Outputs.Unpack(output)
+	if err != nil {
+		return nil, err
+	}
+	word, overflow := uint256.FromBig(values[0].(*big.Int))
+	if overflow {
+		return nil, ErrInvalidTickBitmap
+	}
+	return word, nil
+}
+
+func getTickBitmapABI() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(tickBitmapABI))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+/**
+ * Returns the indexes of the words of the tick bitmap which hold the ticks between tickLower and tickUpper, see
+ * TickBitmap.position
+ * @param tickLower The lower tick
+ * @param tickUpper The upper tick
+ * @param tickSpacing The tick spacing of the pool
+ */
+func TickBitmapWords(tickLower, tickUpper, tickSpacing int) []int16 {
+	wordLower := compressTick(tickLower, tickSpacing) >> 8
+	wordUpper := compressTick(tickUpper, tickSpacing) >> 8
+	words := make([]int16, 0, wordUpper-wordLower+1)
+	for word := wordLower; word <= wordUpper; word++ {
+		words = append(words, int16(word))
+	}
+	return words
+}
+
+// compressTick divides the tick by the tick spacing, rounding towards negative infinity
+func compressTick(tick, tickSpacing int) int {
+	compressed := tick / tickSpacing
+	if tick < 0 && tick%tickSpacing != 0 {
+		compressed--
+	}
+	return compressed
+}
+
+/**
+ * Merges the populated ticks of the words of a pool into a sorted and validated list of ticks
+ * @param words The populated ticks of each word, which must include all the populated ticks of the pool
+ * @param tickSpacing The tick spacing of the pool
+ */
+func MergePopulatedTicks(words [][]PopulatedTick, tickSpacing int) ([]entities.Tick, error) {
+	var ticks []entities.Tick
+	for _, word := range words {
+		for _, populated := range word {
+			liquidityNet, err := int256.FromBig(populated.LiquidityNet)
+			if err != nil {
+				return nil, err
+			}
+			liquidityGross, overflow := uint256.FromBig(populated.LiquidityGross)
+			if overflow || populated.LiquidityGross.Sign() < 0 {
+				return nil, ErrInvalidTickLiquidity
+			}
+			ticks = append(ticks, entities.Tick{
+				Index:          int(populated.Tick.Int64()),
+				LiquidityNet:   liquidityNet,
+				LiquidityGross: liquidityGross,
+			})
+		}
+	}
+	// the ticks of a word are returned from the highest
+	sort.Slice(ticks, func(i, j int) bool {
+		return ticks[i].Index < ticks[j].Index
+	})
+	for i := 1; i < len(ticks); i++ {
+		if ticks[i].Index == ticks[i-1].Index {
+			return nil, ErrDuplicateTick
+		}
+	}
+	if err := entities.ValidateList(ticks, tickSpacing); err != nil {
+		return nil, err
+	}
+	return ticks, nil
+}
+
+// BatchCaller sends several JSON-RPC requests at once, e.g. an rpc.Client
+type BatchCaller interface {
+	BatchCallContext(ctx context.Context, b []rpc.BatchElem) error
+}
+
+// BlockNumberReader returns the number of the latest block, e.g. an ethclient.Client
+type BlockNumberReader interface {
+	BlockNumber(ctx context.Context) (uint64, error)
+}
+
+// TickLens reads the populated ticks of pools through the TickLens contract
+type TickLens struct {
+	caller  ethereum.ContractCaller
+	batcher BatchCaller
+	address common.Address
+
+	BlockNumber *big.Int // The block of the calls, the latest block if nil
+	BatchSize   int      // The maximum number of calls in a batch request, DefaultTickLensBatchSize if zero
+}
+
+/**
+ * Constructs a TickLens which sends its calls one by one
+ * @param caller The client of the eth_calls, e.g. an ethclient.Client
+ * @param address The address of the TickLens contract
+ */
+func NewTickLens(caller ethereum.ContractCaller, address common.Address) *TickLens {
+	return &TickLens{caller: caller, address: address}
+}
+
+/**
+ * Constructs a TickLens which sends its calls in batch requests
+ * @param client The RPC client, e.g. from rpc.DialContext or ethclient.Client.Client
+ * @param address The address of the TickLens contract
+ */
+func NewBatchTickLens(client *rpc.Client, address common.Address) *TickLens {
+	return &TickLens{caller: ethclient.NewClient(client), batcher: client, address: address}
+}
+
+/**
+ * Returns the populated ticks of a word of the tick bitmap of a pool
+ * @param pool The address of the pool
+ * @param tickBitmapIndex The index of the word in the tick bitmap
+ */
+func (l *TickLens) GetPopulatedTicksInWord(ctx context.Context, pool common.Address, tickBitmapIndex int16) ([]PopulatedTick, error) {
+	words, err := l.GetPopulatedTicksInWords(ctx, pool, []int16{tickBitmapIndex})
+	if err != nil {
+		return nil, err
+	}
+	return words[0], nil
+}
+
+/**
+ * Returns the populated ticks of words of the tick bitmap of a pool, in batch requests if the lens has a batch caller
+ * @param pool The address of the pool
+ * @param tickBitmapIndexes The indexes of the words in the tick bitmap
+ */
+func (l *TickLens) GetPopulatedTicksInWords(ctx context.Context, pool common.Address, tickBitmapIndexes []int16) ([][]PopulatedTick, error) {
+	return l.getPopulatedTicksInWords(ctx, pool, tickBitmapIndexes, l.BlockNumber)
+}
+
+func (l *TickLens) getPopulatedTicksInWords(ctx context.Context, pool common.Address, tickBitmapIndexes []int16,
+	blockNumber *big.Int) ([][]PopulatedTick, error) {
+	calls := make([]ethereum.CallMsg, len(tickBitmapIndexes))
+	for i, word := range tickBitmapIndexes {
+		calldata, err := EncodeGetPopulatedTicksInWord(pool, word)
+		if err != nil {
+			return nil, err
+		}
+		calls[i] = ethereum.CallMsg{To: &l.address, Data: calldata}
+	}
+	outputs, err := l.callContracts(ctx, calls, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	words := make([][]PopulatedTick, len(outputs))
+	for i, output := range outputs {
+		if words[i], err = DecodePopulatedTicks(output); err != nil {
+			return nil, err
+		}
+	}
+	return words, nil
+}
+
+/**
+ * Returns words of the tick bitmap of a pool, in batch requests if the lens has a batch caller
+ * @param pool The address of the pool
+ * @param wordPositions The indexes of the words in the tick bitmap
+ */
+func (l *TickLens) GetTickBitmap(ctx context.Context, pool common.Address, wordPositions []int16) ([]*uint256.Int, error) {
+	return l.getTickBitmap(ctx, pool, wordPositions, l.BlockNumber)
+}
+
+func (l *TickLens) getTickBitmap(ctx context.Context, pool common.Address, wordPositions []int16,
+	blockNumber *big.Int) ([]*uint256.Int, error) {
+	calls := make([]ethereum.CallMsg, len(wordPositions))
+	for i, word := range wordPositions {
+		calldata, err := EncodeTickBitmap(word)
+		if err != nil {
+			return nil, err
+		}
+		calls[i] = ethereum.CallMsg{To: &pool, Data: calldata}
+	}
+	outputs, err := l.callContracts(ctx, calls, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	words := make([]*uint256.Int, len(outputs))
+	for i, output := range outputs {
+		if words[i], err = DecodeTickBitmap(output); err != nil {
+			return nil, err
+		}
+	}
+	return words, nil
+}
+
+// callContracts sends the eth_calls at the block in batches of BatchSize if the lens has a batch caller, else one by one
+func (l *TickLens) callContracts(ctx context.Context, calls []ethereum.CallMsg, blockNumber *big.Int) ([][]byte, error) {
+	outputs := make([][]byte, len(calls))
+	if l.batcher == nil {
+		for i, call := range calls {
+			output, err := l.caller.CallContract(ctx, call, blockNumber)
+			if err != nil {
+				return nil, err
+			}
+			outputs[i] = output
+		}
+		return outputs, nil
+	}
+
+	batchSize := l.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultTickLensBatchSize
+	}
+	block := "latest"
+	if blockNumber != nil {
+		block = hexutil.EncodeBig(blockNumber)
+	}
+	for start := 0; start < len(calls); start += batchSize {
+		end := start + batchSize
+		if end > len(calls) {
+			end = len(calls)
+		}
+		batch := make([]rpc.BatchElem, end-start)
+		results := make([]hexutil.Bytes, end-start)
+		for i, call := range calls[start:end] {
+			batch[i] = rpc.BatchElem{
+				Method: "eth_call",
+				Args:   []interface{}{map[string]interface{}{"to": call.To, "data": hexutil.Bytes(call.Data)}, block},
+				Result: &results[i],
+			}
+		}
+		if err := l.batcher.BatchCallContext(ctx, batch); err != nil {
+			return nil, err
+		}
+		for i, elem := range batch {
+			if elem.Error != nil {
+				return nil, elem.Error
+			}
+			outputs[start+i] = results[i]
+		}
+	}
+	return outputs, nil
+}
+
+// blockNumber returns the block of the calls, which is the latest block when the BlockNumber of the lens is nil
+func (l *TickLens) blockNumber(ctx context.Context) (*big.Int, error) {
+	if l.BlockNumber != nil {
+		return l.BlockNumber, nil
+	}
+	reader, ok := l.caller.(BlockNumberReader)
+	if !ok {
+		return nil, ErrUnknownBlockNumber
+	}
+	blockNumber, err := reader.BlockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetUint64(blockNumber), nil
+}
+
+/**
+ * Returns all the populated ticks of a pool. The words of its tick bitmap are read first, then the populated ticks of
+ * the words which aren't empty. All the calls are made at the same block, so when the BlockNumber of the lens is nil,
+ * the caller must be a BlockNumberReader to resolve the latest block.
+ * @param pool The address of the pool
+ * @param tickSpacing The tick spacing of the pool
+ */
+func (l *TickLens) GetTicks(ctx context.Context, pool common.Address, tickSpacing int) ([]entities.Tick, error) {
+	if tickSpacing <= 0 {
+		return nil, entities.ErrZeroTickSpacing
+	}
+	blockNumber, err := l.blockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+	words := TickBitmapWords(utils.MinTick, utils.MaxTick, tickSpacing)
+	bitmap, err := l.getTickBitmap(ctx, pool, words, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	var populatedWords []int16
+	for i, word := range words {
+		if !bitmap[i].IsZero() {
+			populatedWords = append(populatedWords, word)
+		}
+	}
+	populated, err := l.getPopulatedTicksInWords(ctx, pool, populatedWords, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	return MergePopulatedTicks(populated, tickSpacing)
+}
+
+/**
+ * Returns a tick data provider with all the populated ticks of a pool
+ * @param pool The address of the pool
+ * @param tickSpacing The tick spacing of the pool
+ */
+func (l *TickLens) NewTickListDataProvider(ctx context.Context, pool common.Address, tickSpacing int) (*entities.TickListDataProvider, error) {
+	ticks, err := l.GetTicks(ctx, pool, tickSpacing)
+	if err != nil {
+		return nil, err
+	}
+	return entities.NewTickListDataProvider(ticks, tickSpacing)
+}
+
+/**
+ * Returns a tick data provider of any kind with all the populated ticks of a pool
+ * @param pool The address of the pool
+ * @param tickSpacing The tick spacing of the pool
+ * @param newProvider Constructs the provider from the sorted and validated ticks
+ */
+func (l *TickLens) NewTickDataProvider(
+	ctx context.Context,
+	pool common.Address,
+	tickSpacing int,
+	newProvider func(ticks []entities.Tick, tickSpacing int) (entities.TickDataProvider, error),
+) (entities.TickDataProvider, error) {
+	ticks, err := l.GetTicks(ctx, pool, tickSpacing)
+	if err != nil {
+		return nil, err
+	}
+	return newProvider(ticks, tickSpacing)
+}
@@ -0,0 +1,208 @@
+package periphery
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"testing"
+
+	"github.com/KyberNetwork/int256"
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/constants"
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/entities"
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/utils"
+	core "github.com/daoleno/uniswap-sdk-core/entities"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTickLens answers the getPopulatedTicksInWord and tickBitmap calls with the ticks of the pools
+type fakeTickLens struct {
+	t           *testing.T
+	tickSpacing int
+	ticks       map[common.Address][]entities.Tick
+	calls       int
+	batches     int
+
+	blockNumber uint64   // the latest block
+	blocks      []string // the blocks of the calls
+}
+
+func (f *fakeTickLens) BlockNumber(ctx context.Context) (uint64, error) {
+	return f.blockNumber, nil
+}
+
+func (f *fakeTickLens) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	f.calls++
+	block := "latest"
+	if blockNumber != nil {
+		block = hexutil.EncodeBig(blockNumber)
+	}
+	f.blocks = append(f.blocks, block)
+	if _, ok := f.ticks[*call.To]; ok {
+		method := getTickBitmapABI().Methods["tickBitmap"]
+		args, err := method.Inputs.Unpack(call.Data[4:])
+		require.NoError(f.t, err)
+		word := new(big.Int)
+		for _, tick := range f.ticks[*call.To] {
+			compressed := compressTick(tick.Index, f.tickSpacing)
+			if compressed>>8 == int(args[0].(int16)) {
+				word.SetBit(word, compressed&0xff, 1)
+			}
+		}
+		return method.Outputs.Pack(word)
+	}
+
+	method := GetABI(tickLensABI).Methods["getPopulatedTicksInWord"]
+	args, err := method.Inputs.Unpack(call.Data[4:])
+	require.NoError(f.t, err)
+	pool, word := args[0].(common.Address), args[1].(int16)
+
+	var populated []PopulatedTick
+	for _, tick := range f.ticks[pool] {
+		if compressTick(tick.Index, f.tickSpacing)>>8 == int(word) {
+			populated = append(populated, PopulatedTick{
+				Tick:           big.NewInt(int64(tick.Index)),
+				LiquidityNet:   tick.LiquidityNet.ToBig(),
+				LiquidityGross: tick.LiquidityGross.ToBig(),
+			})
+		}
+	}
+	// the TickLens returns the ticks of a word from the highest
+	sort.Slice(populated, func(i, j int) bool {
+		return populated[i].Tick.Cmp(populated[j].Tick) > 0
+	})
+	return method.Outputs.Pack(populated)
+}
+
+func (f *fakeTickLens) BatchCallContext(ctx context.Context, batch []rpc.BatchElem) error {
+	f.batches++
+	for _, elem := range batch {
+		require.Equal(f.t, "eth_call", elem.Method)
+		arg := elem.Args[0].(map[string]interface{})
+		var blockNumber *big.Int
+		if block := elem.Args[1].(string); block != "latest" {
+			var err error
+			blockNumber, err = hexutil.DecodeBig(block)
+			require.NoError(f.t, err)
+		}
+		output, err := f.CallContract(ctx, ethereum.CallMsg{To: arg["to"].(*common.Address), Data: arg["data"].(hexutil.Bytes)}, blockNumber)
+		require.NoError(f.t, err)
+		*elem.Result.(*hexutil.Bytes) = output
+	}
+	return nil
+}
+
+func TestTickBitmapWords(t *testing.T) {
+	assert.Equal(t, []int16{-1, 0}, TickBitmapWords(-1, 0, 1))
+	assert.Equal(t, []int16{0}, TickBitmapWords(0, 255, 1))
+	assert.Equal(t, []int16{-1}, TickBitmapWords(-256, -1, 1))
+	assert.Equal(t, []int16{-58, -57}, TickBitmapWords(-887220, -869401, 60))
+	words := TickBitmapWords(utils.MinTick, utils.MaxTick, 60)
+	assert.Equal(t, int16(-58), words[0])
+	assert.Equal(t, int16(57), words[len(words)-1])
+}
+
+func TestTickLens(t *testing.T) {
+	tickSpacing := constants.TickSpacings[constants.FeeMedium]
+	poolAddress := common.HexToAddress("0x8ad599c3A0ff1De082011EFDDc58f1908eb6e6D8")
+	ticks := []entities.Tick{
+		{Index: entities.NearestUsableTick(utils.MinTick, tickSpacing), LiquidityNet: int256.NewInt(1_000_000), LiquidityGross: uint256.NewInt(1_000_000)},
+		{Index: -120, LiquidityNet: int256.NewInt(500_000), LiquidityGross: uint256.NewInt(500_000)},
+		{Index: -60, LiquidityNet: int256.NewInt(250_000), LiquidityGross: uint256.NewInt(250_000)},
+		{Index: 60, LiquidityNet: int256.NewInt(-250_000), LiquidityGross: uint256.NewInt(250_000)},
+		{Index: 15360, LiquidityNet: int256.NewInt(-500_000), LiquidityGross: uint256.NewInt(500_000)},
+		{Index: entities.NearestUsableTick(utils.MaxTick, tickSpacing), LiquidityNet: int256.NewInt(-1_000_000), LiquidityGross: uint256.NewInt(1_000_000)},
+	}
+	caller := &fakeTickLens{t: t, tickSpacing: tickSpacing, ticks: map[common.Address][]entities.Tick{poolAddress: ticks}, blockNumber: 100}
+	lens := NewTickLens(caller, common.HexToAddress("0xbfd8137f7d1516D3ea5cA83523914859ec47F573"))
+
+	populated, err := lens.GetPopulatedTicksInWord(context.Background(), poolAddress, -1)
+	require.NoError(t, err)
+	assert.Equal(t, []PopulatedTick{
+		{Tick: big.NewInt(-60), LiquidityNet: big.NewInt(250_000), LiquidityGross: big.NewInt(250_000)},
+		{Tick: big.NewInt(-120), LiquidityNet: big.NewInt(500_000), LiquidityGross: big.NewInt(500_000)},
+	}, populated)
+
+	// every word of the bitmap is read, then only the populated words, all at the latest block when the ticks are read
+	words := len(TickBitmapWords(utils.MinTick, utils.MaxTick, tickSpacing))
+	caller.calls, caller.blocks = 0, nil
+	fetched, err := lens.GetTicks(context.Background(), poolAddress, tickSpacing)
+	require.NoError(t, err)
+	assert.Equal(t, words+5, caller.calls)
+	assert.Equal(t, ticks, fetched)
+	for _, block := range caller.blocks {
+		require.Equal(t, "0x64", block)
+	}
+
+	// in batches
+	lens.batcher = caller
+	lens.BatchSize = 1000
+	caller.calls, caller.blocks = 0, nil
+	fetched, err = lens.GetTicks(context.Background(), poolAddress, tickSpacing)
+	require.NoError(t, err)
+	assert.Equal(t, (words+999)/1000+1, caller.batches)
+	assert.Equal(t, words+5, caller.calls)
+	assert.Equal(t, ticks, fetched)
+	for _, block := range caller.blocks {
+		require.Equal(t, "0x64", block)
+	}
+
+	// or at the block of the lens
+	lens.BlockNumber = big.NewInt(90)
+	caller.blocks = nil
+	_, err = lens.GetTicks(context.Background(), poolAddress, tickSpacing)
+	require.NoError(t, err)
+	for _, block := range caller.blocks {
+		require.Equal(t, "0x5a", block)
+	}
+	lens.BlockNumber = nil
+
+	// the latest block can't be resolved without a block number reader
+	_, err = NewTickLens(struct{ ethereum.ContractCaller }{caller}, lens.address).GetTicks(context.Background(), poolAddress, tickSpacing)
+	assert.ErrorIs(t, err, ErrUnknownBlockNumber)
+
+	// the pool quotes as with the ticks themselves
+	list, err := lens.NewTickListDataProvider(context.Background(), poolAddress, tickSpacing)
+	require.NoError(t, err)
+	bitmap, err := lens.NewTickDataProvider(context.Background(), poolAddress, tickSpacing,
+		func(ticks []entities.Tick, tickSpacing int) (entities.TickDataProvider, error) {
+			return entities.NewTickBitmapDataProvider(ticks, tickSpacing)
+		})
+	require.NoError(t, err)
+	amountIn := core.FromRawAmount(token0, big.NewInt(100_000))
+	var outputs []*big.Int
+	for _, provider := range []entities.TickDataProvider{list, bitmap} {
+		pool, err := entities.NewPool(token0, token1, constants.FeeMedium, utils.EncodeSqrtRatioX96(big.NewInt(1), big.NewInt(1)), big.NewInt(1_750_000), 0, provider)
+		require.NoError(t, err)
+		result, err := pool.GetOutputAmount(amountIn, nil)
+		require.NoError(t, err)
+		outputs = append(outputs, result.ReturnedAmount.Quotient())
+	}
+	assert.Equal(t, outputs[0], outputs[1])
+}
+
+func TestMergePopulatedTicks(t *testing.T) {
+	lower := PopulatedTick{Tick: big.NewInt(-60), LiquidityNet: big.NewInt(10), LiquidityGross: big.NewInt(10)}
+	upper := PopulatedTick{Tick: big.NewInt(60), LiquidityNet: big.NewInt(-10), LiquidityGross: big.NewInt(10)}
+
+	ticks, err := MergePopulatedTicks([][]PopulatedTick{{upper}, nil, {lower}}, 60)
+	require.NoError(t, err)
+	require.Len(t, ticks, 2)
+	assert.Equal(t, -60, ticks[0].Index)
+	assert.Equal(t, 60, ticks[1].Index)
+
+	_, err = MergePopulatedTicks([][]PopulatedTick{{upper, lower}, {lower}}, 60)
+	assert.ErrorIs(t, err, ErrDuplicateTick)
+	// a missing word leaves the liquidity unbalanced
+	_, err = MergePopulatedTicks([][]PopulatedTick{{lower}}, 60)
+	assert.ErrorIs(t, err, entities.ErrZeroNet)
+	_, err = MergePopulatedTicks([][]PopulatedTick{{upper, lower}}, 7)
+	assert.ErrorIs(t, err, entities.ErrInvalidTickSpacing)
+	_, err = MergePopulatedTicks([][]PopulatedTick{{{Tick: big.NewInt(0), LiquidityNet: big.NewInt(0), LiquidityGross: big.NewInt(-1)}}}, 60)
+	assert.ErrorIs(t, err, ErrInvalidTickLiquidity)
+}
@@ -0,0 +1,313 @@
+package periphery
+
+import (
+	_ "embed"
+	"errors"
+	"math/big"
+
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/entities"
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/utils"
+	core "github.com/daoleno/uniswap-sdk-core/entities"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+//go:embed contracts/UniversalRouter.sol/UniversalRouter.json
+var universalRouterABI []byte
+
+var (
+	ErrUnknownCommand = errors.New("unknown command")
+)
+
+// CommandType is a command of the Universal Router, executed with the input at the same index
+type CommandType byte
+
+const (
+	CommandV3SwapExactIn       CommandType = 0x00
+	CommandV3SwapExactOut      CommandType = 0x01
+	CommandPermit2TransferFrom CommandType = 0x02
+	CommandPermit2PermitBatch  CommandType = 0x03
+	CommandSweep               CommandType = 0x04
+	CommandTransfer            CommandType = 0x05
+	CommandPayPortion          CommandType = 0x06
+	CommandPermit2Permit       CommandType = 0x0a
+	CommandWrapETH             CommandType = 0x0b
+	CommandUnwrapWETH          CommandType = 0x0c
+
+	// AllowRevertFlag is set on a command whose failure doesn't revert the whole execution
+	AllowRevertFlag = 0x80
+)
+
+func mustNewType(t string, components ...abi.ArgumentMarshaling) abi.Type {
+	typ, err := abi.NewType(t, "", components)
+	if err != nil {
+		panic(err)
+	}
+	return typ
+}
+
+func newArguments(types ...abi.Type) abi.Arguments {
+	args := make(abi.Arguments, len(types))
+	for i, typ := range types {
+		args[i] = abi.Argument{Type: typ}
+	}
+	return args
+}
+
+var (
+	addressType = mustNewType("address")
+	uint160Type = mustNewType("uint160")
+	uint256Type = mustNewType("uint256")
+	bytesType   = mustNewType("bytes")
+	boolType    = mustNewType("bool")
+
+	permitSingleType = mustNewType("tuple",
+		abi.ArgumentMarshaling{Name: "details", Type: "tuple", Components: []abi.ArgumentMarshaling{
+			{Name: "token", Type: "address"},
+			{Name: "amount", Type: "uint160"},
+			{Name: "expiration", Type: "uint48"},
+			{Name: "nonce", Type: "uint48"},
+		}},
+		abi.ArgumentMarshaling{Name: "spender", Type: "address"},
+		abi.ArgumentMarshaling{Name: "sigDeadline", Type: "uint256"},
+	)
+	permitBatchType = mustNewType("tuple",
+		abi.ArgumentMarshaling{Name: "details", Type: "tuple[]", Components: []abi.ArgumentMarshaling{
+			{Name: "token", Type: "address"},
+			{Name: "amount", Type: "uint160"},
+			{Name: "expiration", Type: "uint48"},
+			{Name: "nonce", Type: "uint48"},
+		}},
+		abi.ArgumentMarshaling{Name: "spender", Type: "address"},
+		abi.ArgumentMarshaling{Name: "sigDeadline", Type: "uint256"},
+	)
+
+	// the abi encoding of the input of each command
+	commandArguments = map[CommandType]abi.Arguments{
+		// recipient, amountIn, amountOutMin, path, payerIsUser
+		CommandV3SwapExactIn: newArguments(addressType, uint256Type, uint256Type, bytesType, boolType),
+		// recipient, amountOut, amountInMax, path, payerIsUser
+		CommandV3SwapExactOut: newArguments(addressType, uint256Type, uint256Type, bytesType, boolType),
+		// token, recipient, amount
+		CommandPermit2TransferFrom: newArguments(addressType, addressType, uint160Type),
+		// permitBatch, signature
+		CommandPermit2PermitBatch: newArguments(permitBatchType, bytesType),
+		// token, recipient, amountMin
+		CommandSweep: newArguments(addressType, addressType, uint256Type),
+		// token, recipient, value
+		CommandTransfer: newArguments(addressType, addressType, uint256Type),
+		// token, recipient, bips
+		CommandPayPortion: newArguments(addressType, addressType, uint256Type),
+		// permitSingle, signature
+		CommandPermit2Permit: newArguments(permitSingleType, bytesType),
+		// recipient, amountMin
+		CommandWrapETH: newArguments(addressType, uint256Type),
+		// recipient, amountMin
+		CommandUnwrapWETH: newArguments(addressType, uint256Type),
+	}
+)
+
+// RoutePlanner builds the commands and inputs of a Universal Router execution
+type RoutePlanner struct {
+	Commands []byte
+	Inputs   [][]byte
+}
+
+func NewRoutePlanner() *RoutePlanner {
+	return &RoutePlanner{}
+}
+
+/**
+ * Appends a command to the plan
+ * @param command The command to add
+ * @param allowRevert Whether the execution continues if the command fails
+ * @param args The parameters of the command, in the order of the abi encoding of its input
+ */
+func (p *RoutePlanner) AddCommand(command CommandType, allowRevert bool, args ...interface{}) error {
+	arguments, ok := commandArguments[command]
+	if !ok {
+		return ErrUnknownCommand
+	}
+	input, err := arguments.Pack(args...)
+	if err != nil {
+		return err
+	}
+	commandByte := byte(command)
+	if allowRevert {
+		commandByte |= AllowRevertFlag
+	}
+	p.Commands = append(p.Commands, commandByte)
+	p.Inputs = append(p.Inputs, input)
+	return nil
+}
+
+/**
+ * Encodes the execution of the planned commands
+ * @param deadline The optional deadline, in epoch seconds
+ */
+func (p *RoutePlanner) EncodeExecute(deadline *big.Int) ([]byte, error) {
+	abi := GetABI(universalRouterABI)
+	if deadline != nil {
+		return packMethod(abi, "execute(bytes,bytes[],uint256)", p.Commands, p.Inputs, deadline)
+	}
+	return packMethod(abi, "execute(bytes,bytes[])", p.Commands, p.Inputs)
+}
+
+// Represents the Uniswap Universal Router
+
+/**
+ * Produces the on-chain method name to call and the hex encoded parameters to pass as arguments for a given trade,
+ * for the Universal Router. The input is paid by the sender through Permit2, with the Permit2 permit of
+ * `options.InputTokenPermit` if set, unless it is native. If `options.Recipient` is the zero address, the output is sent to the sender of the call.
+ * @param trades to produce call parameters for
+ * @param options options for the call parameters
+ */
+func UniversalRouterCallParameters(trades []*entities.Trade, options *SwapOptions) (*utils.MethodParameters, error) {
+	if options.PreviousBlockhash != nil || options.SqrtPriceLimitX96 != nil {
+		return nil, ErrUnsupportedOption
+	}
+	// only Permit2 permits can be used
+	if options.InputTokenPermit != nil && options.InputTokenPermit.Permit2Permit == nil {
+		return nil, ErrUnsupportedOption
+	}
+	sampleTrade := trades[0]
+	tokenIn := sampleTrade.InputAmount().Currency.Wrapped()
+	tokenOut := sampleTrade.OutputAmount().Currency.Wrapped()
+
+	// All trades should have the same starting and ending token.
+	for _, trade := range trades {
+		if !trade.InputAmount().Currency.Wrapped().Equal(tokenIn) {
+			return nil, ErrTokenInDiff
+		}
+		if !trade.OutputAmount().Currency.Wrapped().Equal(tokenOut) {
+			return nil, ErrTokenOutDiff
+		}
+	}
+
+	planner := NewRoutePlanner()
+
+	ZeroIn := core.FromRawAmount(trades[0].InputAmount().Currency, big.NewInt(0))
+	ZeroOut := core.FromRawAmount(trades[0].OutputAmount().Currency, big.NewInt(0))
+
+	totalAmountOut := ZeroOut
+	for _, trade := range trades {
+		minOut, err := trade.MinimumAmountOut(options.SlippageTolerance, nil)
+		if err != nil {
+			return nil, err
+		}
+		totalAmountOut = totalAmountOut.Add(minOut)
+	}
+
+	// flag for whether a refund needs to happen
+	mustRefund := sampleTrade.InputAmount().Currency.IsNative() && sampleTrade.TradeType == core.ExactOutput
+	inputIsNative := sampleTrade.InputAmount().Currency.IsNative()
+	// flags for whether funds should be send first to the router
+	outputIsNative := sampleTrade.OutputAmount().Currency.IsNative()
+	routerMustCustody := outputIsNative || options.Fee != nil
+
+	totalValue := ZeroIn
+	if inputIsNative {
+		for _, trade := range trades {
+			maxIn, err := trade.MaximumAmountIn(options.SlippageTolerance, nil)
+			if err != nil {
+				return nil, err
+			}
+			totalValue = totalValue.Add(maxIn)
+		}
+	}
+
+	// encode permit if necessary
+	if options.InputTokenPermit != nil {
+		if !sampleTrade.InputAmount().Currency.IsToken() {
+			return nil, ErrNonTokenPermit
+		}
+		permit := options.InputTokenPermit.Permit2Permit
+		if err := planner.AddCommand(CommandPermit2Permit, false, permit.PermitSingle, permit.Signature); err != nil {
+			return nil, err
+		}
+	}
+
+	// the native input is wrapped by the router, which then pays the swaps
+	if inputIsNative {
+		if err := planner.AddCommand(CommandWrapETH, false, AddressThis, totalValue.Quotient()); err != nil {
+			return nil, err
+		}
+	}
+	payerIsUser := !inputIsNative
+
+	recipient := MsgSender
+	if options.Recipient != (common.Address{}) {
+		recipient = options.Recipient
+	}
+	swapRecipient := recipient
+	if routerMustCustody {
+		swapRecipient = AddressThis
+	}
+
+	for _, trade := range trades {
+		for _, swap := range trade.Swaps {
+			amountIn, err := trade.MaximumAmountIn(options.SlippageTolerance, swap.InputAmount)
+			if err != nil {
+				return nil, err
+			}
+			amountOut, err := trade.MinimumAmountOut(options.SlippageTolerance, swap.OutputAmount)
+			if err != nil {
+				return nil, err
+			}
+			path, err := EncodeRouteToPath(swap.Route, trade.TradeType == core.ExactOutput)
+			if err != nil {
+				return nil, err
+			}
+
+			if trade.TradeType == core.ExactInput {
+				err = planner.AddCommand(CommandV3SwapExactIn, false, swapRecipient, amountIn.Quotient(), amountOut.Quotient(), path, payerIsUser)
+			} else {
+				err = planner.AddCommand(CommandV3SwapExactOut, false, swapRecipient, amountOut.Quotient(), amountIn.Quotient(), path, payerIsUser)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// pay the fee, then unwrap or sweep the rest of the output held by the router
+	if routerMustCustody {
+		minimumAmountOut := totalAmountOut.Quotient()
+		if options.Fee != nil {
+			feeBips := encodeFeeBips(options.Fee.Fee)
+			if err := planner.AddCommand(CommandPayPortion, false, tokenOut.Address, options.Fee.Recipient, feeBips); err != nil {
+				return nil, err
+			}
+			// the exact output is paid the fee, so only the output less the fee is left
+			if sampleTrade.TradeType == core.ExactOutput {
+				fee := new(big.Int).Mul(minimumAmountOut, feeBips)
+				minimumAmountOut = new(big.Int).Sub(minimumAmountOut, fee.Quo(fee, big.NewInt(10000)))
+			}
+		}
+		var err error
+		if outputIsNative {
+			err = planner.AddCommand(CommandUnwrapWETH, false, recipient, minimumAmountOut)
+		} else {
+			err = planner.AddCommand(CommandSweep, false, tokenOut.Address, recipient, minimumAmountOut)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// refund the native input left
+	if mustRefund {
+		if err := planner.AddCommand(CommandUnwrapWETH, false, recipient, big.NewInt(0)); err != nil {
+			return nil, err
+		}
+	}
+
+	call, err := planner.EncodeExecute(options.Deadline)
+	if err != nil {
+		return nil, err
+	}
+	return &utils.MethodParameters{
+		Calldata: call,
+		Value:    totalValue.Quotient(),
+	}, nil
+}
@@ -0,0 +1,182 @@
+package periphery
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/entities"
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/utils"
+	core "github.com/daoleno/uniswap-sdk-core/entities"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unpackExecute returns the commands and the unpacked inputs of a Universal Router execution, and its deadline if any
+func unpackExecute(t *testing.T, calldata []byte) ([]byte, [][]interface{}, *big.Int) {
+	abi := GetABI(universalRouterABI)
+	method, err := abi.MethodById(calldata[:4])
+	require.NoError(t, err)
+	args, err := method.Inputs.Unpack(calldata[4:])
+	require.NoError(t, err)
+	commands, inputs := args[0].([]byte), args[1].([][]byte)
+	require.Equal(t, len(commands), len(inputs))
+	unpacked := make([][]interface{}, len(inputs))
+	for i, input := range inputs {
+		unpacked[i], err = commandArguments[CommandType(commands[i]&^AllowRevertFlag)].Unpack(input)
+		require.NoError(t, err)
+	}
+	var deadline *big.Int
+	if len(args) > 2 {
+		deadline = args[2].(*big.Int)
+	}
+	return commands, unpacked, deadline
+}
+
+func TestRoutePlanner(t *testing.T) {
+	planner := NewRoutePlanner()
+	assert.ErrorIs(t, planner.AddCommand(CommandType(0x3f), false), ErrUnknownCommand)
+	assert.Error(t, planner.AddCommand(CommandSweep, false, token0.Address))
+	require.NoError(t, planner.AddCommand(CommandSweep, true, token0.Address, recipient, big.NewInt(1)))
+	require.NoError(t, planner.AddCommand(CommandWrapETH, false, AddressThis, big.NewInt(2)))
+	assert.Equal(t, []byte{0x84, 0x0b}, planner.Commands)
+
+	calldata, err := planner.EncodeExecute(big.NewInt(123))
+	require.NoError(t, err)
+	assert.Equal(t, selector("execute(bytes,bytes[],uint256)"), calldata[:4])
+	commands, inputs, deadline := unpackExecute(t, calldata)
+	assert.Equal(t, planner.Commands, commands)
+	assert.Equal(t, []interface{}{token0.Address, recipient, big.NewInt(1)}, inputs[0])
+	assert.Equal(t, []interface{}{AddressThis, big.NewInt(2)}, inputs[1])
+	assert.Equal(t, big.NewInt(123), deadline)
+
+	calldata, err = planner.EncodeExecute(nil)
+	require.NoError(t, err)
+	assert.Equal(t, selector("execute(bytes,bytes[])"), calldata[:4])
+}
+
+func TestUniversalRouterCallParameters(t *testing.T) {
+	pool_0_1 := makePool(token0, token1)
+	pool_1_weth := makePool(token1, weth)
+
+	slippageTolerance := core.NewPercent(big.NewInt(1), big.NewInt(100))
+	recipient := common.HexToAddress("0x0000000000000000000000000000000000000003")
+	deadline := big.NewInt(123)
+
+	// multi-hop exact input paid by the sender
+	r, _ := entities.NewRoute([]*entities.Pool{pool_0_1, pool_1_weth}, token0, weth)
+	trade, _ := entities.FromRoute(r, core.FromRawAmount(token0, big.NewInt(100)), core.ExactInput)
+	options := &SwapOptions{
+		Router:            UniversalRouter,
+		SlippageTolerance: slippageTolerance,
+		Recipient:         recipient,
+		Deadline:          deadline,
+	}
+	params, err := UniversalRouterCallParameters([]*entities.Trade{trade}, options)
+	require.NoError(t, err)
+	commands, inputs, d := unpackExecute(t, params.Calldata)
+	assert.Equal(t, deadline, d)
+	assert.Equal(t, []byte{byte(CommandV3SwapExactIn)}, commands)
+	path, err := EncodeRouteToPath(r, false)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{recipient, big.NewInt(100), big.NewInt(95), path, true}, inputs[0])
+	assert.Equal(t, "0x00", utils.ToHex(params.Value))
+
+	// the router is selected by the options
+	routed, err := SwapCallParameters([]*entities.Trade{trade}, options)
+	require.NoError(t, err)
+	assert.Equal(t, params, routed)
+
+	// with a Permit2 permit
+	permit := &Permit2Permit{
+		PermitSingle: PermitSingle{
+			Details: PermitDetails{
+				Token:      token0.Address,
+				Amount:     big.NewInt(100),
+				Expiration: big.NewInt(1000),
+				Nonce:      big.NewInt(1),
+			},
+			Spender:     common.HexToAddress("0x0000000000000000000000000000000000000005"),
+			SigDeadline: big.NewInt(1000),
+		},
+		Signature: []byte{0x01, 0x02},
+	}
+	params, err = UniversalRouterCallParameters([]*entities.Trade{trade}, &SwapOptions{
+		SlippageTolerance: slippageTolerance,
+		Recipient:         recipient,
+		InputTokenPermit:  &PermitOptions{Permit2Permit: permit},
+	})
+	require.NoError(t, err)
+	commands, inputs, d = unpackExecute(t, params.Calldata)
+	assert.Nil(t, d)
+	assert.Equal(t, []byte{byte(CommandPermit2Permit), byte(CommandV3SwapExactIn)}, commands)
+	expected, err := commandArguments[CommandPermit2Permit].Pack(permit.PermitSingle, permit.Signature)
+	require.NoError(t, err)
+	actual, err := commandArguments[CommandPermit2Permit].Pack(inputs[0]...)
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
+
+	// ETH in exact output, the router wraps the ETH and refunds what's left
+	r, _ = entities.NewRoute([]*entities.Pool{pool_1_weth}, ether, token1)
+	trade, _ = entities.FromRoute(r, core.FromRawAmount(token1, big.NewInt(100)), core.ExactOutput)
+	params, err = UniversalRouterCallParameters([]*entities.Trade{trade}, &SwapOptions{
+		SlippageTolerance: slippageTolerance,
+		Recipient:         recipient,
+		Deadline:          deadline,
+	})
+	require.NoError(t, err)
+	commands, inputs, _ = unpackExecute(t, params.Calldata)
+	assert.Equal(t, []byte{byte(CommandWrapETH), byte(CommandV3SwapExactOut), byte(CommandUnwrapWETH)}, commands)
+	assert.Equal(t, []interface{}{AddressThis, big.NewInt(103)}, inputs[0])
+	path, err = EncodeRouteToPath(r, true)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{recipient, big.NewInt(100), big.NewInt(103), path, false}, inputs[1])
+	assert.Equal(t, recipient, inputs[2][0])
+	assert.Zero(t, inputs[2][1].(*big.Int).Sign())
+	assert.Equal(t, "0x67", utils.ToHex(params.Value))
+
+	// ETH out exact input with a fee, the router pays the fee and unwraps the rest to the sender
+	r, _ = entities.NewRoute([]*entities.Pool{pool_1_weth}, token1, ether)
+	trade, _ = entities.FromRoute(r, core.FromRawAmount(token1, big.NewInt(100)), core.ExactInput)
+	params, err = UniversalRouterCallParameters([]*entities.Trade{trade}, &SwapOptions{
+		SlippageTolerance: slippageTolerance,
+		Deadline:          deadline,
+		Fee:               feeOptions,
+	})
+	require.NoError(t, err)
+	commands, inputs, _ = unpackExecute(t, params.Calldata)
+	assert.Equal(t, []byte{byte(CommandV3SwapExactIn), byte(CommandPayPortion), byte(CommandUnwrapWETH)}, commands)
+	assert.Equal(t, AddressThis, inputs[0][0])
+	assert.Equal(t, []interface{}{weth.Address, feeOptions.Recipient, big.NewInt(10)}, inputs[1])
+	assert.Equal(t, []interface{}{MsgSender, big.NewInt(97)}, inputs[2])
+
+	// token out exact output with a fee, the rest left after the fee is swept
+	r, _ = entities.NewRoute([]*entities.Pool{pool_0_1}, token0, token1)
+	trade, _ = entities.FromRoute(r, core.FromRawAmount(token1, big.NewInt(1000)), core.ExactOutput)
+	params, err = UniversalRouterCallParameters([]*entities.Trade{trade}, &SwapOptions{
+		SlippageTolerance: slippageTolerance,
+		Recipient:         recipient,
+		Fee:               feeOptions,
+	})
+	require.NoError(t, err)
+	commands, inputs, _ = unpackExecute(t, params.Calldata)
+	assert.Equal(t, []byte{byte(CommandV3SwapExactOut), byte(CommandPayPortion), byte(CommandSweep)}, commands)
+	assert.Equal(t, []interface{}{token1.Address, recipient, big.NewInt(999)}, inputs[2])
+
+	// options of the other routers
+	_, err = UniversalRouterCallParameters([]*entities.Trade{trade}, &SwapOptions{
+		SlippageTolerance: slippageTolerance,
+		SqrtPriceLimitX96: big.NewInt(1),
+	})
+	assert.ErrorIs(t, err, ErrUnsupportedOption)
+	_, err = UniversalRouterCallParameters([]*entities.Trade{trade}, &SwapOptions{
+		SlippageTolerance: slippageTolerance,
+		InputTokenPermit:  &PermitOptions{StandardPermitArguments: &StandardPermitArguments{}},
+	})
+	assert.ErrorIs(t, err, ErrUnsupportedOption)
+	_, err = SwapCallParameters([]*entities.Trade{trade}, &SwapOptions{
+		SlippageTolerance: slippageTolerance,
+		InputTokenPermit:  &PermitOptions{Permit2Permit: permit},
+	})
+	assert.ErrorIs(t, err, ErrInvalidOptions)
+}
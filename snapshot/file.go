@@ -0,0 +1,223 @@
+package snapshot
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/entities"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+)
+
+// The file of a store is a log of JSON records, one per line. The first record of a pool holds the whole pool, the
+// next ones only the state and the ticks which changed since the previous record.
+type record struct {
+	Pool        common.Address  `json:"pool"`
+	BlockNumber uint64          `json:"blockNumber"`
+	LogIndex    uint            `json:"logIndex"`
+	Base        *entities.Pool  `json:"base,omitempty"`
+	State       *stateJSON      `json:"state,omitempty"`
+	Ticks       []entities.Tick `json:"ticks,omitempty"`
+	Removed     []int           `json:"removed,omitempty"`
+}
+
+type stateJSON struct {
+	SqrtRatioX96         string `json:"sqrtRatioX96"`
+	Liquidity            string `json:"liquidity"`
+	TickCurrent          int    `json:"tickCurrent"`
+	FeeGrowthGlobal0X128 string `json:"feeGrowthGlobal0X128,omitempty"`
+	FeeGrowthGlobal1X128 string `json:"feeGrowthGlobal1X128,omitempty"`
+	FeeProtocol          uint8  `json:"feeProtocol,omitempty"`
+	ProtocolFees0        string `json:"protocolFees0,omitempty"`
+	ProtocolFees1        string `json:"protocolFees1,omitempty"`
+}
+
+func newStateJSON(s state) *stateJSON {
+	return &stateJSON{
+		SqrtRatioX96:         toDec(s.SqrtRatioX96),
+		Liquidity:            toDec(s.Liquidity),
+		TickCurrent:          s.TickCurrent,
+		FeeGrowthGlobal0X128: toDec(s.FeeGrowthGlobal0X128),
+		FeeGrowthGlobal1X128: toDec(s.FeeGrowthGlobal1X128),
+		FeeProtocol:          s.FeeProtocol,
+		ProtocolFees0:        toDec(s.ProtocolFees0),
+		ProtocolFees1:        toDec(s.ProtocolFees1),
+	}
+}
+
+func (j *stateJSON) state() (state, error) {
+	s := state{TickCurrent: j.TickCurrent, FeeProtocol: j.FeeProtocol}
+	for _, field := range []struct {
+		dec   string
+		value **uint256.Int
+	}{
+		{j.SqrtRatioX96, &s.SqrtRatioX96},
+		{j.Liquidity, &s.Liquidity},
+		{j.FeeGrowthGlobal0X128, &s.FeeGrowthGlobal0X128},
+		{j.FeeGrowthGlobal1X128, &s.FeeGrowthGlobal1X128},
+		{j.ProtocolFees0, &s.ProtocolFees0},
+		{j.ProtocolFees1, &s.ProtocolFees1},
+	} {
+		if field.dec == "" {
+			continue
+		}
+		value, err := uint256.FromDecimal(field.dec)
+		if err != nil {
+			return state{}, err
+		}
+		*field.value = value
+	}
+	if s.SqrtRatioX96 == nil || s.Liquidity == nil {
+		return state{}, ErrCorruptedFile
+	}
+	return s, nil
+}
+
+func toDec(x *uint256.Int) string {
+	if x == nil {
+		return ""
+	}
+	return x.Dec()
+}
+
+/**
+ * Opens a store backed by a file, the snapshots already in the file are loaded and the new ones are appended to it.
+ * The deployments of the pools must be registered. A record left incomplete by a crash or a failed write is discarded,
+ * with the records after it since each record depends on the previous ones.
+ * @param path The path of the file, it is created if it doesn't exist
+ */
+func Open(path string) (*Store, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	s := NewStore()
+	if err := s.load(file); err != nil {
+		file.Close()
+		return nil, err
+	}
+	s.file = file
+	return s, nil
+}
+
+// load replays the records of the file, and truncates it from the first incomplete record
+func (s *Store) load(file *os.File) error {
+	reader := bufio.NewReader(file)
+	var offset int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		if len(line) == 0 {
+			break
+		}
+		// a record is complete if it is valid JSON followed by a new line
+		if errors.Is(err, io.EOF) || !json.Valid(line) {
+			if err := file.Truncate(offset); err != nil {
+				return err
+			}
+			break
+		}
+		if err := s.replay(line); err != nil {
+			return err
+		}
+		offset += int64(len(line))
+	}
+	s.size = offset
+	return nil
+}
+
+func (s *Store) replay(line []byte) error {
+	var r record
+	if err := json.Unmarshal(line, &r); err != nil {
+		return err
+	}
+	version := Version{BlockNumber: r.BlockNumber, LogIndex: r.LogIndex}
+
+	if r.Base != nil {
+		if _, ok := s.pools[r.Pool]; ok {
+			return ErrCorruptedFile
+		}
+		h, err := newHistory(r.Base)
+		if err != nil {
+			return err
+		}
+		var ticks []entities.Tick
+		if r.Base.TickDataProvider != nil {
+			ticks = r.Base.TickDataProvider.(entities.ListableTickDataProvider).Ticks()
+		}
+		if err := h.append(version, newState(r.Base), ticks, nil); err != nil {
+			return err
+		}
+		s.pools[r.Pool] = h
+		return nil
+	}
+
+	h, ok := s.pools[r.Pool]
+	if !ok || r.State == nil {
+		return ErrCorruptedFile
+	}
+	st, err := r.State.state()
+	if err != nil {
+		return err
+	}
+	return h.append(version, st, r.Ticks, r.Removed)
+}
+
+// write appends the record of a snapshot to the file, in a single write which is undone if it fails
+func (s *Store) write(address common.Address, version Version, pool *entities.Pool, first bool, st state,
+	changed []entities.Tick, removed []int) error {
+	r := record{Pool: address, BlockNumber: version.BlockNumber, LogIndex: version.LogIndex}
+	if first {
+		r.Base = pool
+	} else {
+		r.State = newStateJSON(st)
+		r.Ticks = changed
+		r.Removed = removed
+	}
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(r); err != nil {
+		return err
+	}
+	n, err := s.file.Write(buf.Bytes())
+	if err != nil {
+		// remove the part of the record which was written, so that the next records follow the last complete one
+		if n > 0 {
+			if truncateErr := s.file.Truncate(s.size); truncateErr != nil {
+				return errors.Join(err, truncateErr)
+			}
+		}
+		return err
+	}
+	s.size += int64(n)
+	return nil
+}
+
+// Sync commits the file of the store to stable storage
+func (s *Store) Sync() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil || s.closed {
+		return nil
+	}
+	return s.file.Sync()
+}
+
+// Close closes the file of the store, the store can't record snapshots afterwards
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	if s.file == nil {
+		return nil
+	}
+	return s.file.Close()
+}
@@ -0,0 +1,261 @@
+package snapshot
+
+import (
+	"errors"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/constants"
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/entities"
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/utils"
+	core "github.com/daoleno/uniswap-sdk-core/entities"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	ErrNoSnapshot    = errors.New("no snapshot of the pool at this version")
+	ErrVersionOrder  = errors.New("version must be after the last snapshot of the pool")
+	ErrPoolMismatch  = errors.New("pool doesn't match the previous snapshots")
+	ErrStoreClosed   = errors.New("snapshot store is closed")
+	ErrCorruptedFile = errors.New("corrupted snapshot file")
+)
+
+// Version orders the snapshots of a pool, by the log that changed its state
+type Version struct {
+	BlockNumber uint64
+	LogIndex    uint
+}
+
+// Less returns true if the version is before the other version
+func (v Version) Less(other Version) bool {
+	if v.BlockNumber != other.BlockNumber {
+		return v.BlockNumber < other.BlockNumber
+	}
+	return v.LogIndex < other.LogIndex
+}
+
+// state holds the fields of a pool which aren't ticks
+type state struct {
+	SqrtRatioX96         *utils.Uint160
+	Liquidity            *utils.Uint128
+	TickCurrent          int
+	FeeGrowthGlobal0X128 *utils.Uint256
+	FeeGrowthGlobal1X128 *utils.Uint256
+	FeeProtocol          uint8
+	ProtocolFees0        *utils.Uint128
+	ProtocolFees1        *utils.Uint128
+}
+
+func newState(pool *entities.Pool) state {
+	return state{
+		SqrtRatioX96:         copyUint256(pool.SqrtRatioX96),
+		Liquidity:            copyUint256(pool.Liquidity),
+		TickCurrent:          pool.TickCurrent,
+		FeeGrowthGlobal0X128: copyUint256(pool.FeeGrowthGlobal0X128),
+		FeeGrowthGlobal1X128: copyUint256(pool.FeeGrowthGlobal1X128),
+		FeeProtocol:          pool.FeeProtocol,
+		ProtocolFees0:        copyUint256(pool.ProtocolFees0),
+		ProtocolFees1:        copyUint256(pool.ProtocolFees1),
+	}
+}
+
+type snapshot struct {
+	version Version
+	state   state
+	ticks   *tickSet
+}
+
+// history holds the snapshots of a pool, sorted by version, with the fields that never change
+type history struct {
+	token0, token1 *core.Token
+	fee            constants.FeeAmount
+	tickSpacing    int
+	deployment     *constants.Deployment
+	snapshots      []snapshot
+}
+
+func newHistory(pool *entities.Pool) (*history, error) {
+	tickSpacing := pool.TickSpacing()
+	if tickSpacing <= 0 {
+		return nil, entities.ErrZeroTickSpacing
+	}
+	return &history{
+		token0:      pool.Token0,
+		token1:      pool.Token1,
+		fee:         pool.Fee,
+		tickSpacing: tickSpacing,
+		deployment:  pool.Deployment,
+	}, nil
+}
+
+func (h *history) matches(pool *entities.Pool) bool {
+	return h.token0.Equal(pool.Token0) && h.token1.Equal(pool.Token1) && h.fee == pool.Fee &&
+		h.tickSpacing == pool.TickSpacing()
+}
+
+func (h *history) last() *snapshot {
+	if len(h.snapshots) == 0 {
+		return nil
+	}
+	return &h.snapshots[len(h.snapshots)-1]
+}
+
+// at returns the last snapshot at or before the version, or nil if there is none
+func (h *history) at(version Version) *snapshot {
+	i := sort.Search(len(h.snapshots), func(i int) bool {
+		return version.Less(h.snapshots[i].version)
+	})
+	if i == 0 {
+		return nil
+	}
+	return &h.snapshots[i-1]
+}
+
+// append adds the snapshot made of the changes to the last snapshot
+func (h *history) append(version Version, s state, changed []entities.Tick, removed []int) error {
+	ticks := newTickSet(h.tickSpacing)
+	if last := h.last(); last != nil {
+		if !last.version.Less(version) {
+			return ErrVersionOrder
+		}
+		ticks = last.ticks
+	}
+	h.snapshots = append(h.snapshots, snapshot{version: version, state: s, ticks: ticks.apply(changed, removed)})
+	return nil
+}
+
+// Store records the states of pools at each version, and returns the state of a pool at any past version. The ticks
+// which don't change between versions are shared, and the snapshots are appended to a file if the store is opened
+// from one.
+type Store struct {
+	mu    sync.RWMutex
+	pools map[common.Address]*history
+
+	file   *os.File
+	size   int64 // The size of the complete records of the file
+	closed bool
+}
+
+// NewStore constructs an in-memory store
+func NewStore() *Store {
+	return &Store{pools: map[common.Address]*history{}}
+}
+
+/**
+ * Records the state of a pool at a version, which must be after its last recorded version
+ * @param address The address of the pool
+ * @param version The version of the state, i.e. the block and the index of the log which last changed it
+ * @param pool The state of the pool, its tick data provider must be a ListableTickDataProvider or nil
+ */
+func (s *Store) Record(address common.Address, version Version, pool *entities.Pool) error {
+	var ticks []entities.Tick
+	if pool.TickDataProvider != nil {
+		provider, ok := pool.TickDataProvider.(entities.ListableTickDataProvider)
+		if !ok {
+			return entities.ErrTickDataNotListable
+		}
+		ticks = provider.Ticks()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrStoreClosed
+	}
+	h, ok := s.pools[address]
+	if !ok {
+		var err error
+		if h, err = newHistory(pool); err != nil {
+			return err
+		}
+	} else if !h.matches(pool) {
+		return ErrPoolMismatch
+	}
+	if last := h.last(); last != nil && !last.version.Less(version) {
+		return ErrVersionOrder
+	}
+
+	previous := newTickSet(h.tickSpacing)
+	if last := h.last(); last != nil {
+		previous = last.ticks
+	}
+	changed, removed := previous.diff(ticks)
+	st := newState(pool)
+	if s.file != nil {
+		if err := s.write(address, version, pool, h.last() == nil, st, changed, removed); err != nil {
+			return err
+		}
+	}
+	if err := h.append(version, st, changed, removed); err != nil {
+		return err
+	}
+	s.pools[address] = h
+	return nil
+}
+
+/**
+ * Returns the state of a pool at a version, i.e. as of its last recorded version at or before it
+ * @param address The address of the pool
+ * @param version The version, e.g. the end of a block is {BlockNumber: block, LogIndex: math.MaxUint}
+ * @returns A new pool, with a TickListDataProvider, which can be modified without changing the store
+ */
+func (s *Store) At(address common.Address, version Version) (*entities.Pool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	h, ok := s.pools[address]
+	if !ok {
+		return nil, ErrNoSnapshot
+	}
+	snap := h.at(version)
+	if snap == nil {
+		return nil, ErrNoSnapshot
+	}
+
+	ticks, err := entities.NewTickListDataProvider(snap.ticks.list(), h.tickSpacing)
+	if err != nil {
+		return nil, err
+	}
+	st := snap.state
+	pool, err := entities.NewPoolWithTickSpacing(h.token0, h.token1, h.fee, h.tickSpacing,
+		copyUint256(st.SqrtRatioX96), copyUint256(st.Liquidity), st.TickCurrent, ticks)
+	if err != nil {
+		return nil, err
+	}
+	pool.Deployment = h.deployment
+	pool.FeeGrowthGlobal0X128 = copyUint256(st.FeeGrowthGlobal0X128)
+	pool.FeeGrowthGlobal1X128 = copyUint256(st.FeeGrowthGlobal1X128)
+	pool.FeeProtocol = st.FeeProtocol
+	pool.ProtocolFees0 = copyUint256(st.ProtocolFees0)
+	pool.ProtocolFees1 = copyUint256(st.ProtocolFees1)
+	return pool, nil
+}
+
+/**
+ * Returns the recorded versions of a pool, in order
+ * @param address The address of the pool
+ */
+func (s *Store) Versions(address common.Address) []Version {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	h, ok := s.pools[address]
+	if !ok {
+		return nil
+	}
+	versions := make([]Version, len(h.snapshots))
+	for i, snap := range h.snapshots {
+		versions[i] = snap.version
+	}
+	return versions
+}
+
+// Pools returns the addresses of the recorded pools
+func (s *Store) Pools() []common.Address {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	addresses := make([]common.Address, 0, len(s.pools))
+	for address := range s.pools {
+		addresses = append(addresses, address)
+	}
+	return addresses
+}
@@ -0,0 +1,208 @@
+package snapshot
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/KyberNetwork/int256"
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/constants"
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/entities"
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/utils"
+	core "github.com/daoleno/uniswap-sdk-core/entities"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	token0      = core.NewToken(1, common.HexToAddress("0x0000000000000000000000000000000000000001"), 18, "t0", "token0")
+	token1      = core.NewToken(1, common.HexToAddress("0x0000000000000000000000000000000000000002"), 18, "t1", "token1")
+	poolAddress = common.HexToAddress("0x8ad599c3A0ff1De082011EFDDc58f1908eb6e6D8")
+)
+
+// recordHistory records a pool through mints and swaps, and returns the versions and the JSON of each state
+func recordHistory(t *testing.T, store *Store) ([]Version, [][]byte) {
+	tickSpacing := constants.TickSpacings[constants.FeeMedium]
+	liquidity := uint256.NewInt(1e18)
+	ticks, err := entities.NewTickListDataProvider([]entities.Tick{
+		{Index: entities.NearestUsableTick(utils.MinTick, tickSpacing), LiquidityNet: int256.NewInt(1e18), LiquidityGross: liquidity},
+		{Index: entities.NearestUsableTick(utils.MaxTick, tickSpacing), LiquidityNet: int256.NewInt(-1e18), LiquidityGross: liquidity},
+	}, tickSpacing)
+	require.NoError(t, err)
+	pool, err := entities.NewPoolWithTickSpacing(token0, token1, constants.FeeMedium, tickSpacing,
+		uint256.MustFromBig(utils.EncodeSqrtRatioX96(big.NewInt(1), big.NewInt(1))), liquidity, 0, ticks)
+	require.NoError(t, err)
+	pool.Deployment = constants.UniswapV3Mainnet
+	pool.FeeGrowthGlobal0X128 = new(uint256.Int)
+	pool.FeeGrowthGlobal1X128 = new(uint256.Int)
+
+	var (
+		versions []Version
+		states   [][]byte
+	)
+	record := func(version Version) {
+		require.NoError(t, store.Record(poolAddress, version, pool))
+		data, err := json.Marshal(pool)
+		require.NoError(t, err)
+		versions = append(versions, version)
+		states = append(states, data)
+	}
+
+	record(Version{BlockNumber: 100})
+	require.NoError(t, pool.Mint(-120, 120, uint256.NewInt(5e17), nil))
+	record(Version{BlockNumber: 100, LogIndex: 3})
+	result, err := pool.GetOutputAmount(core.FromRawAmount(token0, big.NewInt(1e17)), nil)
+	require.NoError(t, err)
+	pool = result.NewPoolState
+	pool.Deployment = constants.UniswapV3Mainnet
+	record(Version{BlockNumber: 101})
+	require.NoError(t, pool.Mint(60000, 60060, uint256.NewInt(1e15), nil))
+	record(Version{BlockNumber: 102, LogIndex: 1})
+	require.NoError(t, pool.Burn(-120, 120, uint256.NewInt(5e17)))
+	record(Version{BlockNumber: 102, LogIndex: 2})
+	return versions, states
+}
+
+func assertHistory(t *testing.T, store *Store, versions []Version, states [][]byte) {
+	assert.Equal(t, versions, store.Versions(poolAddress))
+	for i, version := range versions {
+		pool, err := store.At(poolAddress, version)
+		require.NoError(t, err)
+		data, err := json.Marshal(pool)
+		require.NoError(t, err)
+		assert.JSONEq(t, string(states[i]), string(data), "the state at %v", version)
+	}
+}
+
+func TestStore(t *testing.T) {
+	store := NewStore()
+	versions, states := recordHistory(t, store)
+	assertHistory(t, store, versions, states)
+	assert.Equal(t, []common.Address{poolAddress}, store.Pools())
+
+	// the state as of the last version at or before
+	pool, err := store.At(poolAddress, Version{BlockNumber: 100, LogIndex: 2})
+	require.NoError(t, err)
+	data, err := json.Marshal(pool)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(states[0]), string(data))
+	pool, err = store.At(poolAddress, Version{BlockNumber: 1000, LogIndex: math.MaxUint})
+	require.NoError(t, err)
+	data, err = json.Marshal(pool)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(states[len(states)-1]), string(data))
+	_, err = store.At(poolAddress, Version{BlockNumber: 99})
+	assert.ErrorIs(t, err, ErrNoSnapshot)
+	_, err = store.At(common.Address{}, Version{BlockNumber: 100})
+	assert.ErrorIs(t, err, ErrNoSnapshot)
+
+	// the returned pool is a copy
+	pool, err = store.At(poolAddress, versions[1])
+	require.NoError(t, err)
+	require.NoError(t, pool.Mint(-60, 60, uint256.NewInt(1e15), nil))
+	pool, err = store.At(poolAddress, versions[1])
+	require.NoError(t, err)
+	data, err = json.Marshal(pool)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(states[1]), string(data))
+
+	assert.ErrorIs(t, store.Record(poolAddress, versions[len(versions)-1], pool), ErrVersionOrder)
+	other, err := entities.NewPoolWithTickSpacing(token0, token1, constants.FeeLow, 10, pool.SqrtRatioX96, pool.Liquidity,
+		pool.TickCurrent, nil)
+	require.NoError(t, err)
+	assert.ErrorIs(t, store.Record(poolAddress, Version{BlockNumber: 200}, other), ErrPoolMismatch)
+
+	require.NoError(t, store.Close())
+	assert.ErrorIs(t, store.Record(poolAddress, Version{BlockNumber: 200}, pool), ErrStoreClosed)
+}
+
+func TestStoreSharesTicks(t *testing.T) {
+	store := NewStore()
+	recordHistory(t, store)
+	snapshots := store.pools[poolAddress].snapshots
+
+	// the mint far from the other ticks only copies its chunk
+	before, after := snapshots[2].ticks, snapshots[3].ticks
+	assert.Len(t, after.chunks, len(before.chunks)+1)
+	for position, chunk := range before.chunks {
+		assert.Same(t, chunk, after.chunks[position])
+	}
+	// the swap doesn't change any tick
+	assert.Equal(t, snapshots[1].ticks.chunks, snapshots[2].ticks.chunks)
+	// the burn removes the ticks of the first mint
+	assert.Len(t, snapshots[4].ticks.list(), 4)
+}
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshots.jsonl")
+	store, err := Open(path)
+	require.NoError(t, err)
+	versions, states := recordHistory(t, store)
+	require.NoError(t, store.Sync())
+	require.NoError(t, store.Close())
+
+	store, err = Open(path)
+	require.NoError(t, err)
+	assertHistory(t, store, versions, states)
+	require.NoError(t, store.Close())
+
+	// an incomplete record is discarded, and the next ones are appended after the last complete one
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"pool":"0x8ad599c3`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	store, err = Open(path)
+	require.NoError(t, err)
+	assertHistory(t, store, versions, states)
+	truncated, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, info.Size(), truncated.Size())
+	pool, err := store.At(poolAddress, versions[len(versions)-1])
+	require.NoError(t, err)
+	require.NoError(t, pool.Mint(-60, 60, uint256.NewInt(1e15), nil))
+	require.NoError(t, store.Record(poolAddress, Version{BlockNumber: 200}, pool))
+	data, err := json.Marshal(pool)
+	require.NoError(t, err)
+	require.NoError(t, store.Close())
+
+	store, err = Open(path)
+	require.NoError(t, err)
+	versions, states = append(versions, Version{BlockNumber: 200}), append(states, data)
+	assertHistory(t, store, versions, states)
+	require.NoError(t, store.Close())
+
+	// a torn record in the middle of the file is discarded with the records after it
+	info, err = os.Stat(path)
+	require.NoError(t, err)
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := bytes.SplitAfter(content, []byte("\n"))
+	file, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	require.NoError(t, err)
+	_, err = file.Write(append([]byte(`{"pool":"0x8ad599c3`), lines[len(lines)-2]...))
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	store, err = Open(path)
+	require.NoError(t, err)
+	defer store.Close()
+	assertHistory(t, store, versions, states)
+	truncated, err = os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, info.Size(), truncated.Size())
+	require.NoError(t, store.Record(poolAddress, Version{BlockNumber: 300}, pool))
+
+	require.NoError(t, os.WriteFile(path, []byte("{}\n"), 0o644))
+	_, err = Open(path)
+	assert.ErrorIs(t, err, ErrCorruptedFile)
+}
@@ -0,0 +1,187 @@
+package snapshot
+
+import (
+	"sort"
+
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/entities"
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/utils"
+	"github.com/holiman/uint256"
+)
+
+// The number of usable ticks in a chunk of a tick set, as in a word of the tick bitmap
+const chunkSize = 256
+
+// tickSet is an immutable set of ticks, split in chunks of consecutive usable ticks. A new version of the set copies
+// the map of chunks but shares the chunks it doesn't change with the previous versions.
+type tickSet struct {
+	tickSpacing int
+	chunks      map[int]*tickChunk
+}
+
+// tickChunk holds the ticks of a chunk, sorted by index, it is never modified once in a set
+type tickChunk struct {
+	ticks []entities.Tick
+}
+
+func newTickSet(tickSpacing int) *tickSet {
+	return &tickSet{tickSpacing: tickSpacing, chunks: map[int]*tickChunk{}}
+}
+
+// chunk returns the position of the chunk of a tick, rounding towards negative infinity
+func (s *tickSet) chunk(tick int) int {
+	size := s.tickSpacing * chunkSize
+	chunk := tick / size
+	if tick < 0 && tick%size != 0 {
+		chunk--
+	}
+	return chunk
+}
+
+// get returns the tick at an index, if it is in the set
+func (s *tickSet) get(index int) (entities.Tick, bool) {
+	chunk, ok := s.chunks[s.chunk(index)]
+	if !ok {
+		return entities.Tick{}, false
+	}
+	i := sort.Search(len(chunk.ticks), func(i int) bool {
+		return chunk.ticks[i].Index >= index
+	})
+	if i < len(chunk.ticks) && chunk.ticks[i].Index == index {
+		return chunk.ticks[i], true
+	}
+	return entities.Tick{}, false
+}
+
+/**
+ * Returns the ticks that must be set and removed to go from the set to a list of ticks
+ * @param ticks The new ticks, sorted by index
+ */
+func (s *tickSet) diff(ticks []entities.Tick) (changed []entities.Tick, removed []int) {
+	present := make(map[int]struct{}, len(ticks))
+	for _, tick := range ticks {
+		present[tick.Index] = struct{}{}
+		if previous, ok := s.get(tick.Index); !ok || !equalTicks(previous, tick) {
+			changed = append(changed, copyTick(tick))
+		}
+	}
+	for _, chunk := range s.chunks {
+		for _, tick := range chunk.ticks {
+			if _, ok := present[tick.Index]; !ok {
+				removed = append(removed, tick.Index)
+			}
+		}
+	}
+	sort.Ints(removed)
+	return changed, removed
+}
+
+/**
+ * Returns a new version of the set with the ticks set and removed, only the chunks of these ticks are copied
+ * @param changed The ticks to set, they must not be modified afterwards
+ * @param removed The indexes of the ticks to remove
+ */
+func (s *tickSet) apply(changed []entities.Tick, removed []int) *tickSet {
+	next := &tickSet{tickSpacing: s.tickSpacing, chunks: make(map[int]*tickChunk, len(s.chunks))}
+	for position, chunk := range s.chunks {
+		next.chunks[position] = chunk
+	}
+
+	// the ticks of each modified chunk, by index
+	modified := map[int]map[int]entities.Tick{}
+	chunkTicks := func(position int) map[int]entities.Tick {
+		ticks, ok := modified[position]
+		if !ok {
+			ticks = map[int]entities.Tick{}
+			if chunk, ok := s.chunks[position]; ok {
+				for _, tick := range chunk.ticks {
+					ticks[tick.Index] = tick
+				}
+			}
+			modified[position] = ticks
+		}
+		return ticks
+	}
+	for _, tick := range changed {
+		chunkTicks(s.chunk(tick.Index))[tick.Index] = tick
+	}
+	for _, index := range removed {
+		delete(chunkTicks(s.chunk(index)), index)
+	}
+
+	for position, ticks := range modified {
+		if len(ticks) == 0 {
+			delete(next.chunks, position)
+			continue
+		}
+		chunk := &tickChunk{ticks: make([]entities.Tick, 0, len(ticks))}
+		for _, tick := range ticks {
+			chunk.ticks = append(chunk.ticks, tick)
+		}
+		sort.Slice(chunk.ticks, func(i, j int) bool {
+			return chunk.ticks[i].Index < chunk.ticks[j].Index
+		})
+		next.chunks[position] = chunk
+	}
+	return next
+}
+
+// list returns a copy of all the ticks of the set, sorted by index
+func (s *tickSet) list() []entities.Tick {
+	positions := make([]int, 0, len(s.chunks))
+	for position := range s.chunks {
+		positions = append(positions, position)
+	}
+	sort.Ints(positions)
+	var ticks []entities.Tick
+	for _, position := range positions {
+		for _, tick := range s.chunks[position].ticks {
+			ticks = append(ticks, copyTick(tick))
+		}
+	}
+	return ticks
+}
+
+// copyTick returns a copy of the tick which doesn't share its numbers
+func copyTick(tick entities.Tick) entities.Tick {
+	tick.LiquidityGross = copyUint256(tick.LiquidityGross)
+	if tick.LiquidityNet != nil {
+		tick.LiquidityNet = new(utils.Int128).Set(tick.LiquidityNet)
+	}
+	tick.FeeGrowthOutside0X128 = copyUint256(tick.FeeGrowthOutside0X128)
+	tick.FeeGrowthOutside1X128 = copyUint256(tick.FeeGrowthOutside1X128)
+	tick.SecondsPerLiquidityOutsideX128 = copyUint256(tick.SecondsPerLiquidityOutsideX128)
+	return tick
+}
+
+func copyUint256(x *uint256.Int) *uint256.Int {
+	if x == nil {
+		return nil
+	}
+	return new(uint256.Int).Set(x)
+}
+
+func equalTicks(a, b entities.Tick) bool {
+	return a.Index == b.Index &&
+		equalUint256(a.LiquidityGross, b.LiquidityGross) &&
+		equalInt128(a.LiquidityNet, b.LiquidityNet) &&
+		equalUint256(a.FeeGrowthOutside0X128, b.FeeGrowthOutside0X128) &&
+		equalUint256(a.FeeGrowthOutside1X128, b.FeeGrowthOutside1X128) &&
+		a.TickCumulativeOutside == b.TickCumulativeOutside &&
+		equalUint256(a.SecondsPerLiquidityOutsideX128, b.SecondsPerLiquidityOutsideX128) &&
+		a.SecondsOutside == b.SecondsOutside
+}
+
+// equalUint256 compares the numbers, a nil number is only equal to nil
+func equalUint256(a, b *uint256.Int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Eq(b)
+}
+
+func equalInt128(a, b *utils.Int128) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Eq(b)
+}
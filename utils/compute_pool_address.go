@@ -1,7 +1,11 @@
 package utils
 
 import (
+	"bytes"
+	"encoding/binary"
+	"errors"
 	"math/big"
+	"sort"
 
 	"github.com/daoleno/uniswap-sdk-core/entities"
 	"github.com/ethereum/go-ethereum/accounts/abi"
@@ -11,6 +15,8 @@ import (
 	"github.com/KyberNetwork/uniswapv3-sdk-uint256/constants"
 )
 
+var ErrNoFactoryAddress = errors.New("deployment has no factory address")
+
 /**
  * Computes a pool address
  * @param factoryAddress The Uniswap V3 factory address
@@ -39,17 +45,148 @@ func ComputePoolAddress(factoryAddress common.Address, tokenA *entities.Token, t
 	return getCreate2Address(factoryAddress, token0.Address, token1.Address, fee, initCodeHashManualOverride), nil
 }
 
+/**
+ * Computes the address of a pool of a deployment
+ * @param deployment The deployment, whose factory and init code hash are used
+ * @param tokenA The first token of the pair, irrespective of sort order
+ * @param tokenB The second token of the pair, irrespective of sort order
+ * @param fee The fee tier of the pool
+ * @returns The pool address
+ */
+func ComputeDeploymentPoolAddress(deployment *constants.Deployment, tokenA *entities.Token, tokenB *entities.Token,
+	fee constants.FeeAmount) (common.Address, error) {
+	if deployment.FactoryAddress == (common.Address{}) {
+		return common.Address{}, ErrNoFactoryAddress
+	}
+	return ComputePoolAddress(deployment.FactoryAddress, tokenA, tokenB, fee, deployment.PoolInitCodeHash)
+}
+
 func getCreate2Address(factoyAddress, addressA, addressB common.Address, fee constants.FeeAmount,
 	initCodeHashManualOverride string) common.Address {
 	var salt [32]byte
 	copy(salt[:], crypto.Keccak256(abiEncode(addressA, addressB, fee)))
 
 	if initCodeHashManualOverride != "" {
-		crypto.CreateAddress2(factoyAddress, salt, common.FromHex(initCodeHashManualOverride))
+		return crypto.CreateAddress2(factoyAddress, salt, common.FromHex(initCodeHashManualOverride))
 	}
 	return crypto.CreateAddress2(factoyAddress, salt, common.FromHex(constants.PoolInitCodeHash))
 }
 
+// PoolKey identifies a pool of a factory
+type PoolKey struct {
+	Token0 common.Address
+	Token1 common.Address
+	Fee    constants.FeeAmount
+}
+
+// PoolAddress is a pool key with the address of its pool
+type PoolAddress struct {
+	PoolKey
+	Address common.Address
+}
+
+/**
+ * Computes the addresses of the pools of every pair of tokens for every fee tier
+ * @param factoryAddress The Uniswap V3 factory address
+ * @param tokens The tokens, irrespective of sort order, duplicates are ignored
+ * @param fees The fee tiers of the pools
+ * @param initCodeHashManualOverride Override the init code hash used to compute the pool addresses if necessary
+ * @returns The pool addresses, by pair and then by fee
+ */
+func ComputePoolAddresses(factoryAddress common.Address, tokens []common.Address, fees []constants.FeeAmount,
+	initCodeHashManualOverride string) []PoolAddress {
+	initCodeHash := common.FromHex(constants.PoolInitCodeHash)
+	if initCodeHashManualOverride != "" {
+		initCodeHash = common.FromHex(initCodeHashManualOverride)
+	}
+
+	var (
+		pools []PoolAddress
+		seen  = make(map[PoolKey]struct{})
+		// the abi encoding of the salt: token0, token1 and fee, each padded to 32 bytes
+		encoded [96]byte
+		salt    [32]byte
+	)
+	hasher := crypto.NewKeccakState()
+	for i, tokenA := range tokens {
+		for _, tokenB := range tokens[i+1:] {
+			if tokenA == tokenB {
+				continue
+			}
+			token0, token1 := tokenA, tokenB
+			if bytes.Compare(token0[:], token1[:]) > 0 {
+				token0, token1 = token1, token0
+			}
+			copy(encoded[12:32], token0[:])
+			copy(encoded[44:64], token1[:])
+			for _, fee := range fees {
+				key := PoolKey{Token0: token0, Token1: token1, Fee: fee}
+				if _, ok := seen[key]; ok {
+					continue
+				}
+				seen[key] = struct{}{}
+
+				binary.BigEndian.PutUint64(encoded[88:], uint64(fee))
+				hasher.Reset()
+				hasher.Write(encoded[:])
+				hasher.Read(salt[:])
+				pools = append(pools, PoolAddress{
+					PoolKey: key,
+					Address: crypto.CreateAddress2(factoryAddress, salt, initCodeHash),
+				})
+			}
+		}
+	}
+	return pools
+}
+
+/**
+ * Computes the addresses of the pools of a deployment for every pair of tokens
+ * @param deployment The deployment, whose factory and init code hash are used
+ * @param tokens The tokens, irrespective of sort order, duplicates are ignored
+ * @param fees The fee tiers of the pools, all the fee tiers of the deployment if nil
+ * @returns The pool addresses, by pair and then by fee, none if the deployment has no factory address
+ */
+func ComputeDeploymentPoolAddresses(deployment *constants.Deployment, tokens []common.Address,
+	fees []constants.FeeAmount) []PoolAddress {
+	if deployment.FactoryAddress == (common.Address{}) {
+		return nil
+	}
+	if fees == nil {
+		for fee := range deployment.TickSpacings {
+			fees = append(fees, fee)
+		}
+		sort.Slice(fees, func(i, j int) bool {
+			return fees[i] < fees[j]
+		})
+	}
+	return ComputePoolAddresses(deployment.FactoryAddress, tokens, fees, deployment.PoolInitCodeHash)
+}
+
+// PoolAddressIndex maps pool addresses to their keys, e.g. to identify the pools of logs
+type PoolAddressIndex map[common.Address]PoolKey
+
+/**
+ * Constructs the reverse index of pool addresses
+ * @param pools The pool addresses, as computed by ComputePoolAddresses
+ */
+func NewPoolAddressIndex(pools []PoolAddress) PoolAddressIndex {
+	index := make(PoolAddressIndex, len(pools))
+	for _, pool := range pools {
+		index[pool.Address] = pool.PoolKey
+	}
+	return index
+}
+
+/**
+ * Returns the key of the pool at an address
+ * @param address The address of the pool
+ */
+func (i PoolAddressIndex) Lookup(address common.Address) (PoolKey, bool) {
+	key, ok := i[address]
+	return key, ok
+}
+
 func abiEncode(addressA, addressB common.Address, fee constants.FeeAmount) []byte {
 	addressTy, _ := abi.NewType("address", "address", nil)
 	uint256Ty, _ := abi.NewType("uint256", "uint256", nil)
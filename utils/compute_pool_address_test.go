@@ -6,6 +6,7 @@ import (
 	"github.com/KyberNetwork/uniswapv3-sdk-uint256/constants"
 	"github.com/daoleno/uniswap-sdk-core/entities"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -31,3 +32,84 @@ func TestComputePoolAddress(t *testing.T) {
 	}
 	assert.Equal(t, resultA, resultB, "should correctly compute the pool address")
 }
+
+func TestComputeDeploymentPoolAddress(t *testing.T) {
+	USDC := entities.NewToken(1, common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"), 6, "USDC", "USD Coin")
+	WETH := entities.NewToken(1, common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"), 18, "WETH", "Wrapped Ether")
+	result, err := ComputeDeploymentPoolAddress(constants.UniswapV3Mainnet, USDC, WETH, constants.FeeLow)
+	assert.NoError(t, err)
+	assert.Equal(t, common.HexToAddress("0x88e6A0c2dDD26FEEb64F039a2c41296FcB3f5640"), result, "matches the mainnet pool")
+
+	deployment, ok := constants.GetDeployment(42161, constants.ProtocolUniswapV3)
+	assert.True(t, ok)
+	assert.Equal(t, constants.UniswapV3Arbitrum, deployment)
+	_, ok = constants.GetDeployment(42161, "fork")
+	assert.False(t, ok)
+
+	fork := &constants.Deployment{
+		ChainID:          42161,
+		Protocol:         "fork",
+		FactoryAddress:   constants.FactoryAddress,
+		PoolInitCodeHash: "0x2222222222222222222222222222222222222222222222222222222222222222",
+	}
+	result, err = ComputeDeploymentPoolAddress(fork, USDC, WETH, constants.FeeLow)
+	assert.NoError(t, err)
+	assert.Equal(t, common.HexToAddress("0x3c6cA0781ad0d7f0C9478eA25326B82Df44D5caD"), result,
+		"uses the init code hash of the deployment")
+
+	// a registry of the test, so that the default one is left as it is
+	registry := constants.NewRegistry()
+	assert.NoError(t, registry.Register(fork))
+	assert.ErrorIs(t, registry.Register(fork), constants.ErrDeploymentExists)
+	deployment, ok = registry.Get(42161, "fork")
+	assert.True(t, ok)
+	assert.Equal(t, fork, deployment)
+	_, ok = constants.GetDeployment(42161, "fork")
+	assert.False(t, ok)
+}
+
+func TestComputePoolAddressInitCodeHashOverride(t *testing.T) {
+	factoryAddress := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	USDC := entities.NewToken(1, common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"), 6, "USDC", "USD Coin")
+	DAI := entities.NewToken(1, common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F"), 18, "DAI", "Dai Stablecoin")
+	initCodeHash := "0x2222222222222222222222222222222222222222222222222222222222222222"
+
+	result, err := ComputePoolAddress(factoryAddress, USDC, DAI, constants.FeeLow, initCodeHash)
+	assert.NoError(t, err)
+	assert.NotEqual(t, common.HexToAddress("0x90B1b09A9715CaDbFD9331b3A7652B24BfBEfD32"), result, "uses the override")
+	var salt [32]byte
+	copy(salt[:], crypto.Keccak256(abiEncode(DAI.Address, USDC.Address, constants.FeeLow)))
+	assert.Equal(t, crypto.CreateAddress2(factoryAddress, salt, common.FromHex(initCodeHash)), result)
+}
+
+func TestComputePoolAddresses(t *testing.T) {
+	USDC := common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	DAI := common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F")
+	WETH := common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2")
+
+	pools := ComputeDeploymentPoolAddresses(constants.UniswapV3Mainnet, []common.Address{USDC, DAI, WETH, USDC}, nil)
+	assert.Len(t, pools, 3*4, "every pair for every fee tier, without duplicates")
+	for _, pool := range pools {
+		tokenA := entities.NewToken(1, pool.Token0, 18, "", "")
+		tokenB := entities.NewToken(1, pool.Token1, 18, "", "")
+		sorted, err := SortsBefore(tokenA, tokenB)
+		assert.NoError(t, err)
+		assert.True(t, sorted)
+		expected, err := ComputeDeploymentPoolAddress(constants.UniswapV3Mainnet, tokenA, tokenB, pool.Fee)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, pool.Address)
+	}
+
+	index := NewPoolAddressIndex(pools)
+	key, ok := index.Lookup(common.HexToAddress("0x88e6A0c2dDD26FEEb64F039a2c41296FcB3f5640"))
+	assert.True(t, ok)
+	assert.Equal(t, PoolKey{Token0: USDC, Token1: WETH, Fee: constants.FeeLow}, key)
+	_, ok = index.Lookup(common.HexToAddress("0x1111111111111111111111111111111111111111"))
+	assert.False(t, ok)
+
+	// the override is used
+	fork := ComputePoolAddresses(constants.FactoryAddress, []common.Address{USDC, WETH}, []constants.FeeAmount{constants.FeeLow},
+		"0x2222222222222222222222222222222222222222222222222222222222222222")
+	assert.Len(t, fork, 1)
+	assert.NotEqual(t, common.HexToAddress("0x88e6A0c2dDD26FEEb64F039a2c41296FcB3f5640"), fork[0].Address)
+}
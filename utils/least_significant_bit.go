@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"math/bits"
+
+	"github.com/holiman/uint256"
+)
+
+// LeastSignificantBit returns the index of the least significant set bit of x, see BitMath.leastSignificantBit
+func LeastSignificantBit(x *uint256.Int) (uint, error) {
+	if x.IsZero() {
+		return 0, ErrInvalidInput
+	}
+
+	var lsb uint
+	for _, word := range x {
+		if word != 0 {
+			return lsb + uint(bits.TrailingZeros64(word)), nil
+		}
+		lsb += 64
+	}
+	return lsb, nil
+}
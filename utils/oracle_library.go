@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"github.com/daoleno/uniswap-sdk-core/entities"
+	"github.com/holiman/uint256"
+)
+
+var (
+	q64  = new(Uint256).Lsh(uint256.NewInt(1), 64)
+	q128 = new(Uint256).Lsh(uint256.NewInt(1), 128)
+	q192 = new(Uint256).Lsh(uint256.NewInt(1), 192)
+)
+
+/**
+ * Given a tick and a token amount, calculates the amount of token received in exchange, see OracleLibrary.getQuoteAtTick
+ * @param tick Tick value used to calculate the quote
+ * @param baseAmount Amount of token to be converted
+ * @param baseToken The token to be converted
+ * @param quoteToken The token used as the quote
+ * @returns quoteAmount Amount of quoteToken received for baseAmount of baseToken
+ */
+func GetQuoteAtTick(tick int, baseAmount *Uint128, baseToken, quoteToken *entities.Token) (*Uint256, error) {
+	var sqrtRatioX96 Uint160
+	if err := GetSqrtRatioAtTickV2(tick, &sqrtRatioX96); err != nil {
+		return nil, err
+	}
+	sorted, err := SortsBefore(baseToken, quoteToken)
+	if err != nil {
+		return nil, err
+	}
+
+	// calculate quoteAmount with better precision if it doesn't overflow when multiplied by itself
+	if sqrtRatioX96.Cmp(Uint128Max) <= 0 {
+		ratioX192 := new(Uint256).Mul(&sqrtRatioX96, &sqrtRatioX96)
+		if sorted {
+			return MulDiv(ratioX192, baseAmount, q192)
+		}
+		return MulDiv(q192, baseAmount, ratioX192)
+	}
+
+	ratioX128, err := MulDiv(&sqrtRatioX96, &sqrtRatioX96, q64)
+	if err != nil {
+		return nil, err
+	}
+	if sorted {
+		return MulDiv(ratioX128, baseAmount, q128)
+	}
+	return MulDiv(q128, baseAmount, ratioX128)
+}
@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetQuoteAtTick(t *testing.T) {
+	oneEther := uint256.NewInt(1e18)
+
+	quote, err := GetQuoteAtTick(0, oneEther, token0, token1)
+	require.NoError(t, err)
+	assert.Equal(t, oneEther, quote, "tick 0 is a 1:1 price")
+
+	quote, err = GetQuoteAtTick(100, oneEther, token0, token1)
+	require.NoError(t, err)
+	assert.Equal(t, uint256.MustFromDecimal("1010049662092876568"), quote)
+
+	quote, err = GetQuoteAtTick(100, oneEther, token1, token0)
+	require.NoError(t, err)
+	assert.Equal(t, uint256.MustFromDecimal("990050328741209481"), quote)
+
+	// sqrt ratio greater than type(uint128).max
+	quote, err = GetQuoteAtTick(500000, uint256.NewInt(1), token0, token1)
+	require.NoError(t, err)
+	assert.Equal(t, uint256.MustFromDecimal("5171760815372400971558"), quote)
+
+	_, err = GetQuoteAtTick(MaxTick+1, oneEther, token0, token1)
+	assert.ErrorIs(t, err, ErrInvalidTick)
+}
@@ -114,7 +114,9 @@ func ComputeSwapStep(
 		// we didn't reach the target, so take the remainder of the maximum input as fee
 		feeAmount.Sub(&amountRemainingU, amountIn)
 	} else {
-		err := MulDivRoundingUpV2(amountIn, uint256.NewInt(uint64(feePips)), &maxFeeMinusFeePips, feeAmount)
+		var feePipsU uint256.Int
+		feePipsU.SetUint64(uint64(feePips))
+		err := MulDivRoundingUpV2(amountIn, &feePipsU, &maxFeeMinusFeePips, feeAmount)
 		if err != nil {
 			return err
 		}